@@ -0,0 +1,63 @@
+// epd - runs the engine on EPD test suites (WAC, STS...) and reports the solve rate,
+// it can also check that the positions of imported puzzles have a unique solution
+//
+// usage: epd [-time 1s] [-depth 0] [-hash 16] [-unique] suite.epd...
+//
+// references:
+// - https://www.chessprogramming.org/Test-Positions
+// - https://www.chessprogramming.org/Extended_Position_Description
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/tommjj/chess_OG/chess_core/engine"
+	"github.com/tommjj/chess_OG/chess_core/epd"
+)
+
+func main() {
+	var opts options
+	flag.DurationVar(&opts.moveTime, "time", defaultMoveTime, "search time per position")
+	flag.IntVar(&opts.depth, "depth", 0, "maximum search depth in plies, 0 for no limit")
+	flag.BoolVar(&opts.unique, "unique", false, "check that no other move is as good as the best moves")
+	flag.IntVar(&opts.margin, "margin", defaultMargin, "score margin in centipawns of a unique solution")
+	hash := flag.Int("hash", engine.DefaultHashSize, "transposition table size in megabytes")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var records []*epd.Record
+	for _, name := range flag.Args() {
+		f, err := os.Open(name)
+		if err != nil {
+			log.Fatal(err)
+		}
+		recs, err := epd.Parse(f)
+		f.Close()
+		if err != nil {
+			log.Fatalf("%s: %v", name, err)
+		}
+		records = append(records, recs...)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	r := &runner{engine: engine.New(*hash), opts: opts, out: os.Stdout}
+	solved, total := r.run(ctx, records)
+	if total > 0 {
+		fmt.Printf("solved %d/%d (%.1f%%)\n", solved, total, 100*float64(solved)/float64(total))
+	}
+	if r.failed > 0 {
+		os.Exit(1)
+	}
+}
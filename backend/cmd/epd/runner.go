@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	chess "github.com/tommjj/chess_OG/chess_core"
+	"github.com/tommjj/chess_OG/chess_core/engine"
+	"github.com/tommjj/chess_OG/chess_core/epd"
+)
+
+const (
+	defaultMoveTime = time.Second
+	defaultMargin   = 100 // centipawns
+)
+
+type options struct {
+	moveTime time.Duration
+	depth    int
+
+	// search the other moves of the solved positions, the solution is unique when they score
+	// at least margin centipawns less than the best move and do not mate
+	unique bool
+	margin int
+}
+
+// runner searches the records one by one and writes a line per record
+type runner struct {
+	engine *engine.Engine
+	opts   options
+	out    io.Writer
+
+	failed int // records which are not solved or, with opts.unique, have several solutions
+}
+
+// run searches the records until ctx is done, it returns the number of solved and searched records.
+// records without bm and am operations are skipped
+func (r *runner) run(ctx context.Context, records []*epd.Record) (solved, total int) {
+	for i, rec := range records {
+		if ctx.Err() != nil {
+			break
+		}
+		if len(rec.BestMoves) == 0 && len(rec.AvoidMoves) == 0 {
+			continue
+		}
+
+		id := rec.ID()
+		if id == "" {
+			id = fmt.Sprintf("#%d", i+1)
+		}
+
+		ok, line, err := r.solve(ctx, rec)
+		if err != nil {
+			fmt.Fprintf(r.out, "%s error: %v\n", id, err)
+			r.failed++
+			continue
+		}
+		total++
+		if ok {
+			solved++
+		} else {
+			r.failed++
+		}
+		fmt.Fprintf(r.out, "%s %s\n", id, line)
+	}
+
+	return solved, total
+}
+
+// solve searches the record and returns whether it is solved and its report line
+func (r *runner) solve(ctx context.Context, rec *epd.Record) (bool, string, error) {
+	gs, err := rec.GameState()
+	if err != nil {
+		return false, "", err
+	}
+
+	move, score := r.search(ctx, gs, nil)
+	if move == 0 {
+		return false, "", fmt.Errorf("no legal move")
+	}
+
+	pos := rec.Position
+	ok := rec.IsSolved(move)
+
+	var sb strings.Builder
+	if ok {
+		sb.WriteString("ok  ")
+	} else {
+		sb.WriteString("fail")
+	}
+	fmt.Fprintf(&sb, " %s (%s)", pos.MoveToSAN(move), formatScore(score))
+	if len(rec.BestMoves) > 0 {
+		sb.WriteString(" bm " + formatMoves(pos, rec.BestMoves))
+	}
+	if len(rec.AvoidMoves) > 0 {
+		sb.WriteString(" am " + formatMoves(pos, rec.AvoidMoves))
+	}
+
+	if ok && r.opts.unique && len(rec.BestMoves) > 0 {
+		others := slices.DeleteFunc(gs.LegalMoves(), func(m chess.Move) bool {
+			return slices.Contains(rec.BestMoves, m)
+		})
+		if len(others) > 0 {
+			other, otherScore := r.search(ctx, gs, others)
+			if !r.isUnique(score, otherScore) {
+				ok = false
+				fmt.Fprintf(&sb, " not unique: %s (%s)", pos.MoveToSAN(other), formatScore(otherScore))
+			}
+		}
+	}
+
+	return ok, sb.String(), nil
+}
+
+// search searches gs with a cleared transposition table, searchMoves limits the root moves if not empty
+func (r *runner) search(ctx context.Context, gs *chess.GameState, searchMoves []chess.Move) (chess.Move, int) {
+	r.engine.Clear()
+
+	move, score, _ := r.engine.Search(ctx, gs, engine.Limits{
+		Depth:       r.opts.depth,
+		MoveTime:    r.opts.moveTime,
+		SearchMoves: searchMoves,
+	})
+	return move, score
+}
+
+// isUnique reports whether the best score is clearly better than the score of the other moves
+func (r *runner) isUnique(best, other int) bool {
+	if _, mate := engine.MateDistance(other); mate && other > 0 {
+		return false
+	}
+	return best-other >= r.opts.margin
+}
+
+func formatScore(score int) string {
+	if moves, ok := engine.MateDistance(score); ok {
+		return fmt.Sprintf("mate %d", moves)
+	}
+	return fmt.Sprintf("cp %d", score)
+}
+
+func formatMoves(pos chess.Position, moves []chess.Move) string {
+	sans := make([]string, len(moves))
+	for i, move := range moves {
+		sans[i] = pos.MoveToSAN(move)
+	}
+	return strings.Join(sans, " ")
+}
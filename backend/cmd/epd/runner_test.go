@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tommjj/chess_OG/chess_core/engine"
+	"github.com/tommjj/chess_OG/chess_core/epd"
+)
+
+func TestRunner(t *testing.T) {
+	records, err := epd.ParseString(`
+6k1/5ppp/8/8/8/8/8/R5K1 w - - bm Ra8#; id "back rank";
+4k3/8/8/3q4/8/8/3R4/4K3 w - - am Rxd5; id "wrong am";
+6k1/5ppp/8/8/8/8/8/RR4K1 w - - bm Ra8#; id "two mates";
+4k3/8/8/8/8/8/8/4K3 w - - id "no operation";
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	r := &runner{engine: engine.New(1), opts: options{depth: 3, unique: true, margin: defaultMargin}, out: &out}
+
+	solved, total := r.run(context.Background(), records)
+	if solved != 1 || total != 3 || r.failed != 2 {
+		t.Errorf("run() = %d/%d with %d failed, want 1/3 with 2 failed\n%s", solved, total, r.failed, out.String())
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "back rank ok") || !strings.Contains(lines[2], "not unique: Rb8#") {
+		t.Errorf("run() output:\n%s", out.String())
+	}
+}
@@ -37,25 +37,10 @@ func main() {
 			continue
 		}
 
-		from, to, ok := strings.Cut(line, " ")
-		if !ok {
-			fmt.Println("sai")
-			continue
-		}
-
-		fromSq, ok := toSquare(from)
-		if !ok {
-			fmt.Println("sai")
-			continue
-		}
-
-		toSq, ok := toSquare(to)
-		if !ok {
-			fmt.Println("sai")
-			continue
-		}
+		// accept "e2e4", "e2 e4" and "E2E4"
+		move := strings.ToLower(strings.ReplaceAll(line, " ", ""))
 
-		result, err := gs.MakeMove(gs.SideToMove, fromSq, toSq, 0)
+		result, err := gs.MakeMoveUCI(gs.SideToMove(), move)
 		if err != nil {
 			fmt.Println(err)
 			continue
@@ -68,20 +53,3 @@ func main() {
 		fmt.Println("Lỗi đọc:", err)
 	}
 }
-
-func toSquare(p string) (chess.Square, bool) {
-	if len(p) != 2 {
-		return -1, false
-	}
-	p = strings.ToLower(p)
-
-	if p[0] < 'a' || p[0] > 'h' {
-		return -1, false
-	}
-	if p[1] < '1' || p[1] > '8' {
-		return -1, false
-	}
-
-	return chess.Square((p[0] - 'a') + (p[1]-'1')*8), true
-
-}
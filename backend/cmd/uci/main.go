@@ -0,0 +1,17 @@
+// uci - Universal Chess Interface server backed by the chess_core engine
+//
+// references:
+// - https://www.wbec-ridderkerk.nl/html/UCIProtocol.html
+
+package main
+
+import (
+	"log"
+	"os"
+)
+
+func main() {
+	if err := newServer(os.Stdout).run(os.Stdin); err != nil {
+		log.Fatal(err)
+	}
+}
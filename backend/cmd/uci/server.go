@@ -0,0 +1,330 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	chess "github.com/tommjj/chess_OG/chess_core"
+	"github.com/tommjj/chess_OG/chess_core/engine"
+	"github.com/tommjj/chess_OG/chess_core/polyglot"
+)
+
+const (
+	engineName   = "chess_OG"
+	engineAuthor = "tommjj"
+
+	maxHashSize = 1024
+)
+
+// server handles the UCI commands of one GUI session
+type server struct {
+	out   io.Writer
+	outMu sync.Mutex
+
+	engine   *engine.Engine
+	pos      *chess.GameState
+	chess960 bool
+	book     *polyglot.Book
+
+	// running search
+	cancel   context.CancelFunc
+	searchWG sync.WaitGroup
+}
+
+func newServer(out io.Writer) *server {
+	s := &server{
+		out:    out,
+		engine: engine.New(engine.DefaultHashSize),
+	}
+	s.newGame()
+
+	return s
+}
+
+// run reads commands until quit or the end of the input
+func (s *server) run(in io.Reader) error {
+	defer s.stop()
+
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch cmd, args := fields[0], fields[1:]; cmd {
+		case "uci":
+			s.println("id name " + engineName)
+			s.println("id author " + engineAuthor)
+			s.println(fmt.Sprintf("option name Hash type spin default %d min 1 max %d", engine.DefaultHashSize, maxHashSize))
+			s.println("option name UCI_Chess960 type check default false")
+			s.println("option name BookFile type string default <empty>")
+			s.println("uciok")
+		case "isready":
+			s.println("readyok")
+		case "setoption":
+			s.setOption(args)
+		case "ucinewgame":
+			s.stop()
+			s.engine.Clear()
+			s.newGame()
+		case "position":
+			s.stop()
+			if err := s.position(args); err != nil {
+				s.println("info string " + err.Error())
+			}
+		case "go":
+			s.stop()
+			s.goSearch(args)
+		case "stop":
+			s.stop()
+		case "quit":
+			return nil
+		default:
+			s.println("info string unknown command " + cmd)
+		}
+	}
+
+	return scanner.Err()
+}
+
+func (s *server) println(line string) {
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+
+	fmt.Fprintln(s.out, line)
+}
+
+func (s *server) newGame() {
+	s.pos = chess.NewGame()
+	_ = s.pos.FromFEN(chess.StartingFEN)
+	s.pos.SetChess960(s.chess960)
+}
+
+// setOption handles "setoption name <id> [value <x>]"
+func (s *server) setOption(args []string) {
+	var name, value string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "name":
+			for i+1 < len(args) && args[i+1] != "value" {
+				i++
+				name = strings.TrimSpace(name + " " + args[i])
+			}
+		case "value":
+			value = strings.Join(args[i+1:], " ")
+			i = len(args)
+		}
+	}
+
+	switch strings.ToLower(name) {
+	case "hash":
+		size, err := strconv.Atoi(value)
+		if err != nil || size < 1 || size > maxHashSize {
+			s.println("info string invalid Hash value " + value)
+			return
+		}
+		s.stop()
+		s.engine = engine.New(size)
+	case "uci_chess960":
+		s.chess960 = value == "true"
+		s.pos.SetChess960(s.chess960)
+	case "bookfile":
+		if value == "" || value == "<empty>" {
+			s.book = nil
+			return
+		}
+		book, err := polyglot.Open(value)
+		if err != nil {
+			s.println("info string " + err.Error())
+			return
+		}
+		s.book = book
+	default:
+		s.println("info string unknown option " + name)
+	}
+}
+
+// position handles "position startpos|fen <fen> [moves <move>...]"
+func (s *server) position(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing position")
+	}
+
+	fen := chess.StartingFEN
+	rest := args[1:]
+	switch args[0] {
+	case "startpos":
+	case "fen":
+		end := len(rest)
+		for i, arg := range rest {
+			if arg == "moves" {
+				end = i
+				break
+			}
+		}
+		fen = strings.Join(rest[:end], " ")
+		rest = rest[end:]
+	default:
+		return fmt.Errorf("invalid position %q", args[0])
+	}
+
+	pos := chess.NewGame()
+	if err := pos.FromFEN(fen); err != nil {
+		return err
+	}
+	pos.SetChess960(s.chess960)
+
+	if len(rest) > 0 && rest[0] == "moves" {
+		for _, move := range rest[1:] {
+			if _, err := pos.MakeMoveUCI(pos.SideToMove(), move); err != nil {
+				return fmt.Errorf("move %s: %w", move, err)
+			}
+		}
+	}
+
+	s.pos = pos
+	return nil
+}
+
+// goSearch handles "go [depth|nodes|movetime|wtime|btime|winc|binc|movestogo <x>] [infinite]",
+// the best move is written when the search ends
+func (s *server) goSearch(args []string) {
+	var limits engine.Limits
+	var wtime, btime, winc, binc time.Duration
+	infinite := false
+
+	for i := 0; i < len(args); i++ {
+		if args[i] == "infinite" {
+			infinite = true
+			continue
+		}
+		if i+1 >= len(args) {
+			break
+		}
+
+		n, err := strconv.Atoi(args[i+1])
+		if err != nil {
+			continue
+		}
+		ms := time.Duration(n) * time.Millisecond
+
+		switch args[i] {
+		case "depth":
+			limits.Depth = n
+		case "nodes":
+			limits.Nodes = uint64(n)
+		case "movetime":
+			limits.MoveTime = ms
+		case "wtime":
+			wtime = ms
+		case "btime":
+			btime = ms
+		case "winc":
+			winc = ms
+		case "binc":
+			binc = ms
+		case "movestogo":
+			limits.MovesToGo = n
+		default:
+			continue
+		}
+		i++
+	}
+
+	// book moves are played without searching
+	if s.book != nil && !infinite {
+		if move, ok := s.book.RandomMove(s.pos, nil); ok {
+			s.println("bestmove " + s.pos.MoveToUCI(move))
+			return
+		}
+	}
+
+	if !infinite {
+		if s.pos.SideToMove() == chess.White {
+			limits.Time, limits.Increment = wtime, winc
+		} else {
+			limits.Time, limits.Increment = btime, binc
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	pos := s.pos.Copy()
+	e := s.engine
+	e.Info = func(info engine.Info) {
+		s.println(formatInfo(pos, info))
+	}
+
+	s.searchWG.Add(1)
+	go func() {
+		defer s.searchWG.Done()
+
+		move, _, pv := e.Search(ctx, pos, limits)
+
+		// an infinite search waits for stop before sending the best move
+		if infinite {
+			<-ctx.Done()
+		}
+
+		if move == 0 {
+			s.println("bestmove 0000")
+			return
+		}
+
+		line := "bestmove " + pos.MoveToUCI(move)
+		if len(pv) > 1 {
+			next, _ := pos.Position().MakeUnsafeMove(move)
+			line += " ponder " + next.MoveToUCI(pv[1])
+		}
+		s.println(line)
+	}()
+}
+
+// stop stops the running search and waits for its best move
+func (s *server) stop() {
+	if s.cancel == nil {
+		return
+	}
+
+	s.cancel()
+	s.searchWG.Wait()
+	s.cancel = nil
+}
+
+// formatInfo returns the UCI info line of a completed iteration
+func formatInfo(pos *chess.GameState, info engine.Info) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "info depth %d", info.Depth)
+	if moves, ok := engine.MateDistance(info.Score); ok {
+		fmt.Fprintf(&sb, " score mate %d", moves)
+	} else {
+		fmt.Fprintf(&sb, " score cp %d", info.Score)
+	}
+
+	ms := info.Time.Milliseconds()
+	fmt.Fprintf(&sb, " nodes %d time %d", info.Nodes, ms)
+	if ms > 0 {
+		fmt.Fprintf(&sb, " nps %d", info.Nodes*1000/uint64(ms))
+	}
+
+	if len(info.PV) > 0 {
+		sb.WriteString(" pv")
+
+		line := pos.Position()
+		for _, move := range info.PV {
+			sb.WriteString(" " + line.MoveToUCI(move))
+			line, _ = line.MakeUnsafeMove(move)
+		}
+	}
+
+	return sb.String()
+}
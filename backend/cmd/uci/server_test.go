@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	chess "github.com/tommjj/chess_OG/chess_core"
+	"github.com/tommjj/chess_OG/chess_core/polyglot"
+)
+
+// session drives a server through pipes like a GUI
+type session struct {
+	t     *testing.T
+	in    *io.PipeWriter
+	lines chan string
+	done  chan error
+}
+
+func newSession(t *testing.T) *session {
+	t.Helper()
+
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	s := &session{t: t, in: inW, lines: make(chan string, 1024), done: make(chan error, 1)}
+
+	go func() {
+		s.done <- newServer(outW).run(inR)
+		outW.Close()
+	}()
+	go func() {
+		scanner := bufio.NewScanner(outR)
+		for scanner.Scan() {
+			s.lines <- scanner.Text()
+		}
+		close(s.lines)
+	}()
+
+	t.Cleanup(s.quit)
+	return s
+}
+
+func (s *session) send(cmd string) {
+	s.t.Helper()
+
+	if _, err := io.WriteString(s.in, cmd+"\n"); err != nil {
+		s.t.Fatalf("send %q: %v", cmd, err)
+	}
+}
+
+// expect returns the lines read up to and including the first line starting with prefix
+func (s *session) expect(prefix string) []string {
+	s.t.Helper()
+
+	var lines []string
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case line, ok := <-s.lines:
+			if !ok {
+				s.t.Fatalf("output closed before %q, got %q", prefix, lines)
+			}
+			lines = append(lines, line)
+			if strings.HasPrefix(line, prefix) {
+				return lines
+			}
+		case <-timeout:
+			s.t.Fatalf("timeout waiting for %q, got %q", prefix, lines)
+		}
+	}
+}
+
+func (s *session) quit() {
+	io.WriteString(s.in, "quit\n")
+	s.in.Close()
+	if err := <-s.done; err != nil {
+		s.t.Errorf("run: %v", err)
+	}
+}
+
+func TestHandshake(t *testing.T) {
+	s := newSession(t)
+
+	s.send("uci")
+	lines := s.expect("uciok")
+	if lines[0] != "id name "+engineName {
+		t.Errorf("got %q, want id name first", lines)
+	}
+
+	s.send("isready")
+	s.expect("readyok")
+}
+
+func TestGoDepth(t *testing.T) {
+	tests := []struct {
+		name     string
+		position string
+		want     string
+	}{
+		{"mate in one", "position fen 6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", "bestmove a1a8"},
+		{"after moves", "position fen 6k1/5ppp/8/8/8/8/8/R6K w - - 0 1 moves h1g1 g8h8 g1h1 h8g8", "bestmove a1a8"},
+		{"checkmated", "position startpos moves f2f3 e7e5 g2g4 d8h4", "bestmove 0000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSession(t)
+
+			s.send(tt.position)
+			s.send("go depth 3")
+			lines := s.expect("bestmove")
+
+			if got := lines[len(lines)-1]; got != tt.want {
+				t.Errorf("got %q, want %q (output %q)", got, tt.want, lines)
+			}
+		})
+	}
+}
+
+func TestGoInfiniteStop(t *testing.T) {
+	s := newSession(t)
+
+	s.send("position startpos moves e2e4")
+	s.send("go infinite")
+	s.expect("info depth 2")
+
+	s.send("stop")
+	s.expect("bestmove")
+}
+
+func TestPosition(t *testing.T) {
+	tests := []struct {
+		name     string
+		chess960 bool
+		args     string
+		want     string
+		wantErr  bool
+	}{
+		{"startpos", false, "startpos", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", false},
+		{"moves", false, "startpos moves e2e4 c7c5", "rnbqkbnr/pp1ppppp/8/2p5/4P3/8/PPPP1PPP/RNBQKBNR w KQkq c6 0 2", false},
+		{"chess960 castling", true, "fen bqnbrkrn/pppppppp/8/8/8/8/PPPPPPPP/BQNBRKRN w GEge - 0 1 moves f1g1",
+			"bqnbrkrn/pppppppp/8/8/8/8/PPPPPPPP/BQNBRRKN b kq - 1 1", false},
+		{"illegal move", false, "startpos moves e2e5", "", true},
+		{"invalid fen", false, "fen 8/8/8 w - - 0 1", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newServer(io.Discard)
+			s.chess960 = tt.chess960
+
+			err := s.position(strings.Fields(tt.args))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("position() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if got := s.pos.ToFEN(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBookMove(t *testing.T) {
+	start := chess.NewGame()
+	if err := start.FromFEN(chess.StartingFEN); err != nil {
+		t.Fatal(err)
+	}
+
+	// one entry for g1f3
+	entry := make([]byte, 16)
+	binary.BigEndian.PutUint64(entry, polyglot.Key(start))
+	binary.BigEndian.PutUint16(entry[8:], uint16(chess.SquareF3)|uint16(chess.SquareG1)<<6)
+	binary.BigEndian.PutUint16(entry[10:], 1)
+
+	path := filepath.Join(t.TempDir(), "book.bin")
+	if err := os.WriteFile(path, entry, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := newSession(t)
+	s.send("setoption name BookFile value " + path)
+	s.send("position startpos")
+	s.send("go depth 5")
+
+	if lines := s.expect("bestmove"); lines[len(lines)-1] != "bestmove g1f3" {
+		t.Errorf("got %q, want bestmove g1f3", lines)
+	}
+}
@@ -0,0 +1,171 @@
+// bughouse two boards game of four players
+
+package game
+
+import (
+	"sync"
+
+	chess "github.com/tommjj/chess_OG/chess_core"
+)
+
+// Bughouse boards
+const (
+	BoardA = 0
+	BoardB = 1
+)
+
+// BughouseResult is the result of a Bughouse match, the match ends with the first board that ends
+type BughouseResult struct {
+	// Winner team, named by its color on board A
+	//  White - White on board A and Black on board B win
+	//  Black - Black on board A and White on board B win
+	//  Both - Draw
+	Winner Color
+	// Board that decided the match
+	Board int
+
+	// Results of board A and board B
+	Results [2]GameResult
+}
+
+// BughouseGame is a Bughouse match of two teams of two players on two boards.
+// a player plays White on one board and their partner Black on the other,
+// the pieces captured on one board go to the pocket of the partner on the other board.
+type BughouseGame struct {
+	boards [2]*GameState
+
+	ended       bool
+	endCallBack func(result BughouseResult)
+
+	mu sync.Mutex
+}
+
+// NewBughouseGame creates a new Bughouse match. you need call Start() to start the timers of both boards.
+//
+//	clock: time control of the four players
+//	endCallBack: callback function when the match ends
+func NewBughouseGame(clock Clock, endCallBack func(result BughouseResult)) (*BughouseGame, error) {
+	b := &BughouseGame{endCallBack: endCallBack}
+
+	for board := range b.boards {
+		state, err := NewClockGame(chess.Bughouse{}, chess.Bughouse{}.StartingFEN(), clock, func(result GameResult) {
+			b.handleBoardEnd(board, result)
+		})
+		if err != nil {
+			return nil, err
+		}
+		b.boards[board] = state
+	}
+
+	return b, nil
+}
+
+// Board returns the game of board BoardA or BoardB
+func (b *BughouseGame) Board(board int) *GameState {
+	return b.boards[board]
+}
+
+// TeamColor returns the color of the team named by its color on board A on the board
+func TeamColor(board int, team Color) Color {
+	if board == BoardB {
+		return team.Opposite()
+	}
+	return team
+}
+
+// Start the timers of both boards. Returns true if the timers were started, false if they were already started.
+func (b *BughouseGame) Start() bool {
+	started := b.boards[BoardA].Start()
+	return b.boards[BoardB].Start() || started
+}
+
+// MakeMove makes a move on the board, the captured piece goes to the partner on the other board.
+// see GameState.MakeMove
+func (b *BughouseGame) MakeMove(board int, side Color, from Square, to Square, promo PieceType) (GameStatus, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	result, err := b.boards[board].MakeMove(side, from, to, promo)
+	if err != nil {
+		return result, err
+	}
+
+	// the partner plays the other color on the other board, the color of the captured piece
+	if captured := b.boards[board].capturedPiece(); captured != chess.Empty {
+		if err := b.boards[1-board].addToPocket(captured); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// MakeDrop drops a piece from the pocket of side on the board, see GameState.MakeDrop
+func (b *BughouseGame) MakeDrop(board int, side Color, pieceType PieceType, to Square) (GameStatus, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.boards[board].MakeDrop(side, pieceType, to)
+}
+
+// handleBoardEnd ends the match when the first board ends, the other board is stopped with the same team result
+func (b *BughouseGame) handleBoardEnd(board int, result GameResult) {
+	b.mu.Lock()
+
+	if b.ended { // the other board stopped
+		b.mu.Unlock()
+		return
+	}
+	b.ended = true
+
+	team := TeamColor(board, result.Winner)
+	other := 1 - board
+
+	match := BughouseResult{
+		Winner: team,
+		Board:  board,
+	}
+	match.Results[board] = result
+	match.Results[other] = b.boards[other].endByPartner(TeamColor(other, team))
+
+	b.mu.Unlock()
+
+	if b.endCallBack != nil {
+		b.endCallBack(match)
+	}
+}
+
+// capturedPiece returns the piece captured by the last move as it goes to a pocket, chess.Empty if none
+func (g *GameState) capturedPiece() Piece {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.state.CapturedPiece()
+}
+
+// addToPocket puts the piece in the pocket of its color
+func (g *GameState) addToPocket(piece Piece) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.state.AddToPocket(piece); err != nil {
+		return err
+	}
+	g.currentFen = g.state.ToFEN()
+
+	return nil
+}
+
+// endByPartner stops the game because the other board of the Bughouse match ended and returns its result
+func (g *GameState) endByPartner(winner Color) GameResult {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.status == ResultOngoing {
+		g.timer.Stop()
+		g.winner = winner
+		g.status = ResultPartnerBoardEnded
+	}
+
+	return g.result()
+}
@@ -0,0 +1,72 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	chess "github.com/tommjj/chess_OG/chess_core"
+)
+
+func TestBughouseGame(t *testing.T) {
+	ended := make(chan BughouseResult, 1)
+	match, err := BuildBughouseGame(ModeBugBz3m0s, func(result BughouseResult) {
+		ended <- result
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	match.Start()
+
+	// the pawn captured on board A goes to Black on board B
+	for _, uci := range []string{"e2e4", "d7d5", "e4d5"} {
+		from, to, promo, _ := chess.ParseUCIMove(uci)
+		side := White
+		if uci == "d7d5" {
+			side = Black
+		}
+		if _, err := match.MakeMove(BoardA, side, from, to, promo); err != nil {
+			t.Fatalf("MakeMove(%s) error: %v", uci, err)
+		}
+	}
+	if _, err := match.MakeDrop(BoardB, Black, chess.Pawn, chess.SquareE5); err != chess.ErrMoveOutOfTurn {
+		t.Errorf("MakeDrop() before White moved error = %v, want %v", err, chess.ErrMoveOutOfTurn)
+	}
+	if got, want := match.Board(BoardB).currentFen, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR[p] w KQkq - 0 1"; got != want {
+		t.Errorf("board B FEN = %q, want %q", got, want)
+	}
+
+	// a draw on board A ends board B
+	if err := match.Board(BoardA).MakeDraw(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case result := <-ended:
+		if result.Winner != Both || result.Board != BoardA {
+			t.Errorf("result winner = %v on board %d, want %v on board %d", result.Winner, result.Board, Both, BoardA)
+		}
+		if got := result.Results[BoardB].Result; got != ResultPartnerBoardEnded {
+			t.Errorf("board B result = %s, want %s", got, ResultPartnerBoardEnded)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the match did not end")
+	}
+
+	if _, err := BuildGameState(ModeBugBz3m0s, nil); err != ErrInvalidGameMode {
+		t.Errorf("BuildGameState(%s) error = %v, want %v", ModeBugBz3m0s, err, ErrInvalidGameMode)
+	}
+}
+
+func TestBughouseClock(t *testing.T) {
+	clock, _ := ParsePGNTimeControl("180b2")
+	match, err := NewBughouseGame(clock, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for board := range match.boards {
+		if got := match.Board(board).timer.strategy; got != (BronsteinStrategy{Delay: 2 * time.Second}) {
+			t.Errorf("strategy of board %d = %#v, want a 2 seconds Bronstein delay", board, got)
+		}
+	}
+}
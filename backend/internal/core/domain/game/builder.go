@@ -2,7 +2,11 @@
 
 package game
 
-import "time"
+import (
+	"time"
+
+	chess "github.com/tommjj/chess_OG/chess_core"
+)
 
 type GameMode string
 
@@ -11,6 +15,17 @@ type GameMode string
 // Bz - Blitz
 // Rd - Rapid
 // Cl - Classical
+// C960 - Chess960 (Fischer Random Chess)
+// Koth - King of the Hill
+// 3Check - Three-check
+// Anti - Antichess
+// Atomic - Atomic
+// Horde - Horde
+// Zh - Crazyhouse
+// Bug - Bughouse, two boards of four players
+// Fide - FIDE classical, 40 moves in 90 minutes then 30 minutes with 30 seconds increment from move 1
+// Hg - Hourglass
+// d - simple (US) delay, b - Bronstein delay
 const (
 	ModeBt1m0s GameMode = "bt_1m_0s"
 	ModeBt2m1s GameMode = "bt_2m_1s"
@@ -25,50 +40,158 @@ const (
 
 	ModeCl30m0s GameMode = "cl_30m_0s"
 	ModeCl60m0s GameMode = "cl_60m_0s"
+
+	ModeClFide90m30s GameMode = "cl_fide_90m_30s"
+	ModeCl90mD5s     GameMode = "cl_90m_d5s"
+	ModeCl90mB5s     GameMode = "cl_90m_b5s"
+	ModeHgBt1m       GameMode = "hg_bt_1m"
+
+	ModeC960Bz3m2s  GameMode = "c960_bz_3m_2s"
+	ModeC960Bz5m3s  GameMode = "c960_bz_5m_3s"
+	ModeC960Rd10m5s GameMode = "c960_rd_10m_5s"
+
+	ModeKothBz3m2s       GameMode = "koth_bz_3m_2s"
+	ModeThreeCheckBz3m2s GameMode = "3check_bz_3m_2s"
+	ModeAntiBz3m2s       GameMode = "anti_bz_3m_2s"
+	ModeAtomicBz3m2s     GameMode = "atomic_bz_3m_2s"
+	ModeHordeBz5m3s      GameMode = "horde_bz_5m_3s"
+	ModeZhBz3m2s         GameMode = "zh_bz_3m_2s"
+	ModeBugBz3m0s        GameMode = "bug_bz_3m_0s"
 )
 
 type timeControl struct {
-	minutes          int
-	incrementSeconds int
+	clock    string // clock in the syntax of ParsePGNTimeControl
+	chess960 bool
+	variant  chess.Variant // nil for standard chess
 }
 
 var modeTimeControlMap = map[GameMode]timeControl{
-	ModeBt1m0s: {1, 0},
-	ModeBt2m1s: {2, 1},
-
-	ModeBz3m0s: {3, 0},
-	ModeBz3m2s: {3, 2},
-	ModeBz5m0s: {5, 0},
-	ModeBz5m5s: {5, 5},
-
-	ModeRd10m0s:  {10, 0},
-	ModeRd15m10s: {15, 10},
-
-	ModeCl30m0s: {30, 0},
-	ModeCl60m0s: {60, 0},
+	ModeBt1m0s: {"60", false, nil},
+	ModeBt2m1s: {"120+1", false, nil},
+
+	ModeBz3m0s: {"180", false, nil},
+	ModeBz3m2s: {"180+2", false, nil},
+	ModeBz5m0s: {"300", false, nil},
+	ModeBz5m5s: {"300+5", false, nil},
+
+	ModeRd10m0s:  {"600", false, nil},
+	ModeRd15m10s: {"900+10", false, nil},
+
+	ModeCl30m0s: {"1800", false, nil},
+	ModeCl60m0s: {"3600", false, nil},
+
+	ModeClFide90m30s: {"40/5400+30:1800+30", false, nil},
+	ModeCl90mD5s:     {"5400d5", false, nil},
+	ModeCl90mB5s:     {"5400b5", false, nil},
+	ModeHgBt1m:       {"*60", false, nil},
+
+	ModeC960Bz3m2s:  {"180+2", true, nil},
+	ModeC960Bz5m3s:  {"300+3", true, nil},
+	ModeC960Rd10m5s: {"600+5", true, nil},
+
+	ModeKothBz3m2s:       {"180+2", false, chess.KingOfTheHill{}},
+	ModeThreeCheckBz3m2s: {"180+2", false, chess.ThreeCheck{}},
+	ModeAntiBz3m2s:       {"180+2", false, chess.Antichess{}},
+	ModeAtomicBz3m2s:     {"180+2", false, chess.Atomic{}},
+	ModeHordeBz5m3s:      {"300+3", false, chess.Horde{}},
+	ModeZhBz3m2s:         {"180+2", false, chess.Crazyhouse{}},
+	ModeBugBz3m0s:        {"180", false, chess.Bughouse{}},
 }
 
+// InvalidGameMode reports whether the mode is not one of the presets,
+// the games of a custom time control are built with BuildTimeControlGame
 func InvalidGameMode(mode GameMode) bool {
 	_, ok := modeTimeControlMap[mode]
 	return !ok
 }
 
+// BuildGameTimeControl returns the time and the increment of the clock of the mode,
+// zero for the clocks that are not a time with an increment, ex: delays and multi-period clocks
+//
+// Deprecated: use ClockOfMode.
 func BuildGameTimeControl(mode GameMode) (minutes int, incrementSeconds int) {
-	if tc, ok := modeTimeControlMap[mode]; ok {
-		return tc.minutes, tc.incrementSeconds
+	clock := ClockOfMode(mode)
+	if _, ok := clock.Strategy().(FischerStrategy); !ok {
+		return
 	}
-	return
+	return int(clock.Periods[0].Time / time.Minute), int(clock.Periods[0].Increment / time.Second)
+}
+
+// ClockOfMode returns the clock of the mode, a clock without periods for an invalid mode
+func ClockOfMode(mode GameMode) Clock {
+	tc, ok := modeTimeControlMap[mode]
+	if !ok {
+		return Clock{}
+	}
+	clock, err := ParsePGNTimeControl(tc.clock)
+	if err != nil {
+		return Clock{}
+	}
+	return clock
+}
+
+// IsChess960Mode reports whether games of the mode start from a random Chess960 position
+func IsChess960Mode(mode GameMode) bool {
+	return modeTimeControlMap[mode].chess960
+}
+
+// VariantOfMode returns the variant played in games of the mode, nil for standard chess
+func VariantOfMode(mode GameMode) chess.Variant {
+	return modeTimeControlMap[mode].variant
+}
+
+// IsVariantMode reports whether games of the mode are played with the rules of a variant
+func IsVariantMode(mode GameMode) bool {
+	return VariantOfMode(mode) != nil
+}
+
+// IsBughouseMode reports whether games of the mode are Bughouse matches, see BuildBughouseGame
+func IsBughouseMode(mode GameMode) bool {
+	_, ok := VariantOfMode(mode).(chess.Bughouse)
+	return ok
 }
 
 const initialFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
 
-// BuildGameState builds a new GameState based on the given GameMode
+// BuildGameState builds a new rated GameState based on the given GameMode,
+// see BuildTimeControlGame for custom games
 func BuildGameState(mode GameMode, endCallBack func(result GameResult)) (*GameState, error) {
-	minutes, incrementSeconds := BuildGameTimeControl(mode)
+	clock := ClockOfMode(mode)
+
+	if clock.Initial() == 0 || IsBughouseMode(mode) {
+		return nil, ErrInvalidGameMode
+	}
+	if tc, ok := TimeControlOfMode(mode); ok {
+		return BuildTimeControlGame(tc, endCallBack)
+	}
+
+	variant := VariantOfMode(mode)
+
+	fen := initialFEN
+	if variant != nil {
+		fen = variant.StartingFEN()
+	} else if IsChess960Mode(mode) {
+		var err error
+		if fen, err = chess.Chess960FEN(chess.RandomChess960Index()); err != nil {
+			return nil, err
+		}
+	}
+
+	g, err := NewClockGame(variant, fen, clock, endCallBack)
+	if err != nil {
+		return nil, err
+	}
+	g.rated = true
+	return g, nil
+}
+
+// BuildBughouseGame builds a new Bughouse match based on the given Bughouse GameMode
+func BuildBughouseGame(mode GameMode, endCallBack func(result BughouseResult)) (*BughouseGame, error) {
+	clock := ClockOfMode(mode)
 
-	if minutes == 0 {
+	if clock.Initial() == 0 || !IsBughouseMode(mode) {
 		return nil, ErrInvalidGameMode
 	}
 
-	return NewGame(initialFEN, minutes*60, time.Duration(incrementSeconds)*time.Second, endCallBack)
+	return NewBughouseGame(clock, endCallBack)
 }
@@ -0,0 +1,261 @@
+// clock time controls and the strategies of the game timer
+//
+// references:
+// - http://www.saremba.de/chessgml/standards/pgn/pgn-complete.htm#c9.6.1
+// - https://handbook.fide.com/chapter/E012023 (Laws of Chess, 6.1 and 6.3)
+// - https://en.wikipedia.org/wiki/Time_control
+
+package game
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DelayKind is the kind of delay of a clock
+type DelayKind int
+
+const (
+	DelayNone      DelayKind = iota
+	DelayBronstein           // the time used by a move is given back after the move, up to the delay
+	DelaySimple              // the clock waits for the delay before counting down, the US delay
+)
+
+// Period is a period of a clock, ex: 40 moves in 90 minutes with 30 seconds added after each move
+type Period struct {
+	Moves     int           // moves of the period, 0 until the end of the game
+	Time      time.Duration // time added to the clock at the start of the period
+	Increment time.Duration // time added after each move of the period
+}
+
+// Clock describes the time control of a game, see ParsePGNTimeControl for its string syntax
+type Clock struct {
+	Periods []Period
+
+	Delay     time.Duration
+	DelayKind DelayKind
+
+	// the time used by a player is added to the clock of the opponent, the clock has one period
+	Hourglass bool
+}
+
+// NewClock returns a clock of one period with an increment
+func NewClock(initial, increment time.Duration) Clock {
+	return Clock{Periods: []Period{{Time: initial, Increment: increment}}}
+}
+
+// ParsePGNTimeControl parses a clock in the syntax of the PGN TimeControl tag with delays, all the times are
+// in seconds, unlike the base time in minutes of ParseTimeControl ("300+3" here is "5+3" there):
+//
+//	"300+3"              5 minutes with a 3 seconds increment
+//	"40/5400+30:1800+30" 40 moves in 90 minutes then 30 minutes, with a 30 seconds increment from move 1
+//	"5400d5"             90 minutes with a 5 seconds simple (US) delay
+//	"5400b5"             90 minutes with a 5 seconds Bronstein delay
+//	"*180"               hourglass of 3 minutes
+func ParsePGNTimeControl(s string) (Clock, error) {
+	if rest, ok := strings.CutPrefix(s, "*"); ok {
+		seconds, err := parseSeconds(rest)
+		if err != nil || seconds <= 0 {
+			return Clock{}, fmt.Errorf("%w: %q", ErrInvalidClock, s)
+		}
+		return Clock{Periods: []Period{{Time: seconds}}, Hourglass: true}, nil
+	}
+
+	var c Clock
+	for i, part := range strings.Split(s, ":") {
+		var p Period
+		var err error
+
+		if moves, rest, ok := strings.Cut(part, "/"); ok {
+			if p.Moves, err = strconv.Atoi(moves); err != nil || p.Moves <= 0 {
+				return Clock{}, fmt.Errorf("%w: moves of %q", ErrInvalidClock, part)
+			}
+			part = rest
+		}
+
+		if strings.Contains(part, "+") && strings.ContainsAny(part, "db") {
+			return Clock{}, fmt.Errorf("%w: increment and delay in %q", ErrInvalidClock, part)
+		}
+
+		base, extra := part, ""
+		if j := strings.IndexAny(part, "+db"); j >= 0 {
+			base, extra = part[:j], part[j+1:]
+
+			var amount time.Duration
+			if amount, err = parseSeconds(extra); err != nil {
+				return Clock{}, fmt.Errorf("%w: %q", ErrInvalidClock, part)
+			}
+
+			switch part[j] {
+			case '+':
+				p.Increment = amount
+			case 'd':
+				c.Delay, c.DelayKind = amount, DelaySimple
+			case 'b':
+				c.Delay, c.DelayKind = amount, DelayBronstein
+			}
+		}
+
+		if p.Time, err = parseSeconds(base); err != nil || (i == 0 && p.Time <= 0) {
+			return Clock{}, fmt.Errorf("%w: time of %q", ErrInvalidClock, part)
+		}
+		c.Periods = append(c.Periods, p)
+	}
+
+	if c.DelayKind != DelayNone && (len(c.Periods) > 1 || c.Periods[0].Moves > 0) {
+		return Clock{}, fmt.Errorf("%w: delay of a clock with moves per period %q", ErrInvalidClock, s)
+	}
+	return c, nil
+}
+
+func parseSeconds(s string) (time.Duration, error) {
+	seconds, err := strconv.Atoi(s)
+	if err != nil || seconds < 0 {
+		return 0, fmt.Errorf("%w: seconds %q", ErrInvalidClock, s)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// String returns the clock in the syntax of ParsePGNTimeControl
+func (c Clock) String() string {
+	if len(c.Periods) == 0 {
+		return "-"
+	}
+	if c.Hourglass {
+		return "*" + formatSeconds(c.Periods[0].Time)
+	}
+
+	parts := make([]string, len(c.Periods))
+	for i, p := range c.Periods {
+		var sb strings.Builder
+		if p.Moves > 0 {
+			fmt.Fprintf(&sb, "%d/", p.Moves)
+		}
+		sb.WriteString(formatSeconds(p.Time))
+		if p.Increment > 0 {
+			sb.WriteString("+" + formatSeconds(p.Increment))
+		}
+		parts[i] = sb.String()
+	}
+
+	switch c.DelayKind {
+	case DelaySimple:
+		parts[0] += "d" + formatSeconds(c.Delay)
+	case DelayBronstein:
+		parts[0] += "b" + formatSeconds(c.Delay)
+	}
+
+	return strings.Join(parts, ":")
+}
+
+func formatSeconds(d time.Duration) string {
+	return strconv.Itoa(int(d / time.Second))
+}
+
+// Initial returns the time of both clocks at the start of the game
+func (c Clock) Initial() time.Duration {
+	if len(c.Periods) == 0 {
+		return 0
+	}
+	return c.Periods[0].Time
+}
+
+// Strategy returns the strategy of the timer of the clock
+func (c Clock) Strategy() ClockStrategy {
+	switch {
+	case c.Hourglass:
+		return HourglassStrategy{}
+	case c.DelayKind == DelayBronstein:
+		return BronsteinStrategy{Delay: c.Delay}
+	case c.DelayKind == DelaySimple:
+		return SimpleDelayStrategy{Delay: c.Delay}
+	case len(c.Periods) == 1 && c.Periods[0].Moves == 0:
+		return FischerStrategy{Increment: c.Periods[0].Increment}
+	default:
+		return MultiPeriodStrategy{Periods: c.Periods}
+	}
+}
+
+// ClockStrategy decides how the time of a move is counted and what is added to the clocks after it
+type ClockStrategy interface {
+	// CountdownDelay returns the time at the start of every move before the clock counts down
+	CountdownDelay() time.Duration
+
+	// Credit returns the time added after the moves-th move of a player (from 1), which lasted elapsed,
+	// to the clock of the player and to the clock of the opponent
+	Credit(moves int, elapsed time.Duration) (own, opponent time.Duration)
+}
+
+// FischerStrategy adds an increment after every move
+type FischerStrategy struct {
+	Increment time.Duration
+}
+
+func (c FischerStrategy) CountdownDelay() time.Duration { return 0 }
+
+func (c FischerStrategy) Credit(moves int, elapsed time.Duration) (time.Duration, time.Duration) {
+	return c.Increment, 0
+}
+
+// BronsteinStrategy gives back the time used by every move, up to the delay
+type BronsteinStrategy struct {
+	Delay time.Duration
+}
+
+func (c BronsteinStrategy) CountdownDelay() time.Duration { return 0 }
+
+func (c BronsteinStrategy) Credit(moves int, elapsed time.Duration) (time.Duration, time.Duration) {
+	return min(elapsed, c.Delay), 0
+}
+
+// SimpleDelayStrategy waits for the delay at the start of every move before counting down
+type SimpleDelayStrategy struct {
+	Delay time.Duration
+}
+
+func (c SimpleDelayStrategy) CountdownDelay() time.Duration { return c.Delay }
+
+func (c SimpleDelayStrategy) Credit(moves int, elapsed time.Duration) (time.Duration, time.Duration) {
+	return 0, 0
+}
+
+// HourglassStrategy adds the time used by a player to the clock of the opponent after every move
+type HourglassStrategy struct{}
+
+func (HourglassStrategy) CountdownDelay() time.Duration { return 0 }
+
+func (HourglassStrategy) Credit(moves int, elapsed time.Duration) (time.Duration, time.Duration) {
+	return 0, elapsed
+}
+
+// MultiPeriodStrategy adds the time of the next period when a player completes the moves of a period,
+// the last period is repeated when it has a number of moves
+type MultiPeriodStrategy struct {
+	Periods []Period
+}
+
+func (c MultiPeriodStrategy) CountdownDelay() time.Duration { return 0 }
+
+func (c MultiPeriodStrategy) Credit(moves int, elapsed time.Duration) (time.Duration, time.Duration) {
+	if len(c.Periods) == 0 {
+		return 0, 0
+	}
+
+	// the period of the move and the move that completes it
+	i, end := 0, c.Periods[0].Moves
+	for end > 0 && moves > end {
+		i = min(i+1, len(c.Periods)-1)
+		if c.Periods[i].Moves == 0 {
+			break
+		}
+		end += c.Periods[i].Moves
+	}
+
+	own := c.Periods[i].Increment
+	if moves == end {
+		own += c.Periods[min(i+1, len(c.Periods)-1)].Time
+	}
+	return own, 0
+}
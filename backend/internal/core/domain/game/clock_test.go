@@ -0,0 +1,96 @@
+package game
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParsePGNTimeControl(t *testing.T) {
+	tests := []struct {
+		clock    string
+		periods  int
+		strategy ClockStrategy
+	}{
+		{"300+3", 1, FischerStrategy{Increment: 3 * time.Second}},
+		{"60", 1, FischerStrategy{}},
+		{"40/5400+30:1800+30", 2, nil},
+		{"40/7200", 1, nil},
+		{"5400d5", 1, SimpleDelayStrategy{Delay: 5 * time.Second}},
+		{"5400b5", 1, BronsteinStrategy{Delay: 5 * time.Second}},
+		{"*180", 1, HourglassStrategy{}},
+	}
+
+	for _, tt := range tests {
+		c, err := ParsePGNTimeControl(tt.clock)
+		if err != nil {
+			t.Fatalf("ParsePGNTimeControl(%q): %v", tt.clock, err)
+		}
+		if got := c.String(); got != tt.clock || len(c.Periods) != tt.periods {
+			t.Errorf("ParsePGNTimeControl(%q) = %q with %d periods", tt.clock, got, len(c.Periods))
+		}
+		if tt.strategy != nil && c.Strategy() != tt.strategy {
+			t.Errorf("ParsePGNTimeControl(%q).Strategy() = %#v, want %#v", tt.clock, c.Strategy(), tt.strategy)
+		}
+	}
+
+	for _, s := range []string{"", "abc", "0", "40/", "300+x", "*", "40/5400d5", "300d5:60", "300+2d5", "300d5+2"} {
+		if _, err := ParsePGNTimeControl(s); !errors.Is(err, ErrInvalidClock) {
+			t.Errorf("ParsePGNTimeControl(%q) error = %v, want %v", s, err, ErrInvalidClock)
+		}
+	}
+
+	for mode := range modeTimeControlMap {
+		if ClockOfMode(mode).Initial() == 0 {
+			t.Errorf("clock of mode %q is invalid", mode)
+		}
+	}
+}
+
+func TestMultiPeriodStrategy(t *testing.T) {
+	c, _ := ParsePGNTimeControl("40/5400+30:1800+30")
+	s := c.Strategy()
+
+	tests := []struct {
+		moves int
+		want  time.Duration
+	}{
+		{1, 30 * time.Second},
+		{39, 30 * time.Second},
+		{40, 30*time.Minute + 30*time.Second},
+		{41, 30 * time.Second},
+		{80, 30 * time.Second},
+	}
+	for _, tt := range tests {
+		if own, _ := s.Credit(tt.moves, time.Minute); own != tt.want {
+			t.Errorf("Credit(%d) = %v, want %v", tt.moves, own, tt.want)
+		}
+	}
+
+	// the last period is repeated when it has moves
+	repeated, _ := ParsePGNTimeControl("40/7200")
+	if own, _ := repeated.Strategy().Credit(80, time.Minute); own != 2*time.Hour {
+		t.Errorf("Credit(80) of 40/7200 = %v, want %v", own, 2*time.Hour)
+	}
+}
+
+func TestClockTimer(t *testing.T) {
+	delay, _ := ParsePGNTimeControl("60d1")
+	timer := NewClockTimer(delay, White, nil)
+	timer.Start()
+	time.Sleep(100 * time.Millisecond)
+	if got := timer.WhiteRemaining(); got != time.Minute {
+		t.Errorf("remaining time during the delay = %v, want %v", got, time.Minute)
+	}
+	timer.Stop()
+
+	hourglass, _ := ParsePGNTimeControl("*60")
+	timer = NewClockTimer(hourglass, White, nil)
+	timer.Start()
+	time.Sleep(100 * time.Millisecond)
+	timer.SwitchTurn()
+	timer.Stop()
+	if white, black := timer.WhiteRemaining(), timer.BlackRemaining(); white > time.Minute-100*time.Millisecond || black < time.Minute+90*time.Millisecond {
+		t.Errorf("hourglass clocks = %v %v, want the time of white added to black", white, black)
+	}
+}
@@ -30,6 +30,10 @@ const (
 	ResultDrawBy75Move         = GameStatus(chess.ResultDrawBy75Move)
 	ResultInsufficientMaterial = GameStatus(chess.ResultInsufficientMaterial)
 	ResultThreefoldRepetition  = GameStatus(chess.ResultThreefoldRepetition)
+	ResultKingOfTheHill        = GameStatus(chess.ResultKingOfTheHill)
+	ResultThreeCheck           = GameStatus(chess.ResultThreeCheck)
+	ResultKingExploded         = GameStatus(chess.ResultKingExploded)
+	ResultVariantWin           = GameStatus(chess.ResultVariantWin)
 	ResultTimeout              = GameStatus("Result Timeout")
 	ResultDrawByTimeClaim      = GameStatus("Result Draw By Time Claim") // Yêu cầu hòa do hết giờ của đối thủ nhưng không đủ vật chất (theo luật FIDE)
 
@@ -38,6 +42,9 @@ const (
 	ResultDrawByAgreement = GameStatus("Result Draw By Agreement") // Hòa do đồng thuận giữa hai người chơi
 	ResultForfeit         = GameStatus("Result Forfeit")           // Thua do mất kết nối/hết thời gian kết nối lại (Walkover)
 
+	// bughouse
+	ResultPartnerBoardEnded = GameStatus("Result Partner Board Ended") // Bàn còn lại của trận Bughouse đã kết thúc
+
 )
 
 type EndReason string
@@ -20,6 +20,8 @@ var (
 	ErrTooManyPieces     = chess.ErrTooManyPieces
 	ErrNegativeHalfmove  = chess.ErrNegativeHalfmove
 	ErrNegativeFullmove  = chess.ErrNegativeFullmove
+	ErrOpponentInCheck   = chess.ErrOpponentInCheck
+	ErrImpossibleCheck   = chess.ErrImpossibleCheck
 	ErrNoMovesAvailable  = chess.ErrNoMovesAvailable
 
 	ErrMatchEnd = chess.ErrMatchEnd
@@ -34,5 +36,9 @@ var (
 	ErrGameNotStarted = errors.New("error game not started")
 
 	// Create game errors
-	ErrInvalidGameMode = errors.New("error invalid game mode")
+	ErrInvalidGameMode    = errors.New("error invalid game mode")
+	ErrInvalidTimeControl = errors.New("error invalid time control")
+
+	// Clock errors, see ParsePGNTimeControl
+	ErrInvalidClock = errors.New("error invalid clock")
 )
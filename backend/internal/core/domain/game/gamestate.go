@@ -3,6 +3,7 @@ package game
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"sync"
 	"time"
 
@@ -41,6 +42,14 @@ type GameResult struct {
 	BlackTime time.Duration
 	WhiteTime time.Duration
 
+	// remaining time of the player after each move
+	MoveClocks []time.Duration
+
+	// time control
+	Clock Clock
+	Rated bool
+
+	Variant  chess.Variant // rules of the game
 	Moves    []Move
 	StartFen string
 	FinalFen string
@@ -64,6 +73,9 @@ type GameState struct {
 	//  None - Game is ongoing
 	winner Color
 
+	rated  bool
+	clocks []time.Duration // remaining time of the player after each move
+
 	endCallBack func(result GameResult)
 
 	mu sync.Mutex
@@ -76,7 +88,21 @@ type GameState struct {
 //	increaseDuration: time to add to the clock after each move
 //	endCallBack: callback function when game ends
 func NewGame(fen string, timeSeconds int, increaseDuration time.Duration, endCallBack func(result GameResult)) (*GameState, error) {
+	return NewVariantGame(nil, fen, timeSeconds, increaseDuration, endCallBack)
+}
+
+// NewVariantGame creates a new GameState played with the rules of the variant, nil for standard chess.
+// the fen string must be a position of the variant
+func NewVariantGame(variant chess.Variant, fen string, timeSeconds int, increaseDuration time.Duration, endCallBack func(result GameResult)) (*GameState, error) {
+	return NewClockGame(variant, fen, NewClock(time.Duration(timeSeconds)*time.Second, increaseDuration), endCallBack)
+}
+
+// NewClockGame is like NewVariantGame with the time control of a clock, ex: a multi-period or a delay clock
+func NewClockGame(variant chess.Variant, fen string, clock Clock, endCallBack func(result GameResult)) (*GameState, error) {
 	board := chess.NewGame()
+	if variant != nil {
+		board.SetVariant(variant)
+	}
 	err := board.FromFEN(fen)
 	if err != nil {
 		return nil, err
@@ -90,7 +116,7 @@ func NewGame(fen string, timeSeconds int, increaseDuration time.Duration, endCal
 		endCallBack: endCallBack,
 	}
 
-	s.timer = NewTimer(timeSeconds, increaseDuration, board.SideToMove, s.handleTimeout)
+	s.timer = NewClockTimer(clock, board.SideToMove(), s.handleTimeout)
 
 	return s, nil
 }
@@ -204,6 +230,26 @@ func (g *GameState) EndByForfeit(color Color) error {
 //
 //	returns the game status after the move and an error if the move is invalid or if the game has ended.
 func (g *GameState) MakeMove(side Color, from Square, to Square, promo PieceType) (GameStatus, error) {
+	return g.play(side, func() (GameStatus, error) {
+		return g.state.MakeMove(side, from, to, promo)
+	})
+}
+
+// MakeDrop drops a piece from the pocket of side in Crazyhouse and Bughouse games.
+//
+//	side: the side making the drop
+//	pieceType: the type of the dropped piece
+//	to: the empty square the piece is dropped on
+//
+//	returns the game status after the drop and an error if the drop is invalid or if the game has ended.
+func (g *GameState) MakeDrop(side Color, pieceType PieceType, to Square) (GameStatus, error) {
+	return g.play(side, func() (GameStatus, error) {
+		return g.state.MakeDrop(side, pieceType, to)
+	})
+}
+
+// play makes the move of side with makeMove, then switches the timer or ends the match
+func (g *GameState) play(side Color, makeMove func() (GameStatus, error)) (GameStatus, error) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
@@ -225,7 +271,7 @@ func (g *GameState) MakeMove(side Color, from Square, to Square, promo PieceType
 		return "", ErrTimeout
 	}
 
-	result, err := g.state.MakeMove(side, from, to, promo)
+	result, err := makeMove()
 	if err != nil {
 		return result, err
 	}
@@ -234,8 +280,9 @@ func (g *GameState) MakeMove(side Color, from Square, to Square, promo PieceType
 	// handle match end
 	if result != ResultOngoing {
 		g.timer.Stop()
-		if result == ResultCheckmate {
-			g.winner = side
+		// checkmate and the variant wins have a winner
+		if winner, ok := g.state.Winner(); ok {
+			g.winner = winner
 		} else {
 			g.winner = Both
 		}
@@ -247,6 +294,7 @@ func (g *GameState) MakeMove(side Color, from Square, to Square, promo PieceType
 			return result, ErrTimeout // cant switch turn, timeout
 		}
 	}
+	g.clocks = append(g.clocks, g.timer.Remaining(side))
 	g.currentFen = g.state.ToFEN()
 
 	return result, err
@@ -255,18 +303,32 @@ func (g *GameState) MakeMove(side Color, from Square, to Square, promo PieceType
 func (g *GameState) handleMatchEnd() {
 	g.mu.Lock()
 
-	if g.endCallBack != nil {
+	if g.endCallBack == nil {
+		g.mu.Unlock()
 		return
 	} // return if callback is nil
 
+	result := g.result()
+
+	g.mu.Unlock()
+
+	g.endCallBack(result)
+}
+
+// result returns the result of the match, g.mu must be held
+func (g *GameState) result() GameResult {
 	result := GameResult{
-		Winner:    g.winner,
-		Result:    g.status,
-		Duration:  g.timer.GetDuration(),
-		BlackTime: g.timer.BlackRemaining(),
-		WhiteTime: g.timer.BlackRemaining(),
-		StartFen:  g.state.StartFen(),
-		FinalFen:  g.state.ToFEN(),
+		Winner:     g.winner,
+		Result:     g.status,
+		Duration:   g.timer.GetDuration(),
+		BlackTime:  g.timer.BlackRemaining(),
+		WhiteTime:  g.timer.WhiteRemaining(),
+		MoveClocks: slices.Clone(g.clocks),
+		Clock:      g.timer.Clock,
+		Rated:      g.rated,
+		Variant:    g.state.Variant(),
+		StartFen:   g.state.StartFen(),
+		FinalFen:   g.state.ToFEN(),
 	}
 
 	history := g.state.History()
@@ -277,9 +339,7 @@ func (g *GameState) handleMatchEnd() {
 	}
 	result.Moves = moves
 
-	g.mu.Unlock()
-
-	g.endCallBack(result)
+	return result
 }
 
 // timeoutColor
@@ -291,6 +351,7 @@ func (g *GameState) handleTimeout(timeoutColor Color) {
 	g.mu.Lock()
 
 	if g.status != ResultOngoing { // Do nothing if game ended
+		g.mu.Unlock()
 		return
 	}
 
@@ -0,0 +1,87 @@
+package game
+
+import (
+	"time"
+
+	"github.com/tommjj/chess_OG/chess_core/pgn"
+)
+
+const (
+	pgnEvent = "Online game"
+	pgnSite  = "chess_OG"
+)
+
+// PGN builds the PGN of a finished game.
+//
+//	white: white player name
+//	black: black player name
+//	date: the date the game was played
+func (r GameResult) PGN(white, black string, date time.Time) (*pgn.Game, error) {
+	g, err := pgn.NewVariantGame(r.Variant, r.StartFen, r.Moves)
+	if err != nil {
+		return nil, err
+	}
+
+	g.Result = pgnResult(r.Result, r.Winner)
+
+	// the remaining time after each move, written as [%clk] commands
+	tree, err := g.Tree()
+	if err != nil {
+		return nil, err
+	}
+	for i, node := range tree.Mainline() {
+		if i < len(r.MoveClocks) {
+			node.Clock, node.HasClock = max(r.MoveClocks[i], 0).Round(time.Second), true
+		}
+	}
+	g.SetTree(tree)
+
+	g.Tags.Set("Event", pgnEvent)
+	g.Tags.Set("Site", pgnSite)
+	g.Tags.Set("Date", date.Format("2006.01.02"))
+	g.Tags.Set("Round", "-")
+	g.Tags.Set("White", white)
+	g.Tags.Set("Black", black)
+	g.Tags.Set("Result", g.Result)
+
+	g.Tags.Set("TimeControl", r.Clock.String())
+	g.Tags.Set("Termination", pgnTermination(r.Result))
+
+	return g, nil
+}
+
+// pgnResult converts the status and the winner to a PGN game termination marker
+func pgnResult(status GameStatus, winner Color) string {
+	switch status {
+	case ResultOngoing:
+		return pgn.Unknown
+	case ResultStalemate, ResultDrawBy50Move, ResultDrawBy75Move, ResultInsufficientMaterial,
+		ResultThreefoldRepetition, ResultDrawByTimeClaim, ResultDrawByAgreement:
+		return pgn.Draw
+	}
+
+	switch winner {
+	case White:
+		return pgn.WhiteWins
+	case Black:
+		return pgn.BlackWins
+	case Both:
+		return pgn.Draw
+	default:
+		return pgn.Unknown
+	}
+}
+
+// pgnTermination converts the game status to a PGN Termination tag
+func pgnTermination(status GameStatus) string {
+	switch status {
+	case ResultOngoing:
+		return "unterminated"
+	case ResultTimeout, ResultDrawByTimeClaim:
+		return "time forfeit"
+	case ResultForfeit:
+		return "abandoned"
+	default:
+		return "normal"
+	}
+}
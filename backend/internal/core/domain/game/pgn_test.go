@@ -0,0 +1,113 @@
+package game
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	chess "github.com/tommjj/chess_OG/chess_core"
+	"github.com/tommjj/chess_OG/chess_core/pgn"
+)
+
+func TestGameResultPGN(t *testing.T) {
+	board := chess.NewGame()
+	if err := board.FromFEN(initialFEN); err != nil {
+		t.Fatal(err)
+	}
+	for _, uci := range []string{"e2e4", "e7e5", "d1h5", "b8c6", "f1c4", "g8f6", "h5f7"} {
+		if _, err := board.MakeMoveUCI(board.SideToMove(), uci); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var moves []Move
+	for _, h := range board.History() {
+		moves = append(moves, h.Move)
+	}
+
+	result := GameResult{
+		Winner:     White,
+		Result:     ResultCheckmate,
+		WhiteTime:  2*time.Minute + 55*time.Second,
+		BlackTime:  2*time.Minute + 41*time.Second,
+		MoveClocks: []time.Duration{3 * time.Minute, 3 * time.Minute, 2*time.Minute + 58*time.Second},
+		Clock:      NewClock(3*time.Minute, 2*time.Second),
+		Moves:      moves,
+		StartFen:   initialFEN,
+	}
+
+	g, err := result.PGN("alice", "bob", time.Date(2025, 3, 9, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := g.String()
+	for _, want := range []string{
+		`[Date "2025.03.09"]`,
+		`[White "alice"]`,
+		`[Result "1-0"]`,
+		`[TimeControl "180+2"]`,
+		`[Termination "normal"]`,
+		"1. e4 {[%clk 0:03:00]} 1... e5 {[%clk 0:03:00]} 2. Qh5 {[%clk 0:02:58]}",
+		"Nc6 3. Bc4 Nf6 4. Qxf7# 1-0",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("PGN() missing %q in\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "WhiteClock") {
+		t.Errorf("PGN() has a clock tag\n%s", got)
+	}
+	if strings.Contains(got, "[FEN") {
+		t.Errorf("PGN() of a standard start position has a FEN tag\n%s", got)
+	}
+}
+
+func TestVariantGameResultPGN(t *testing.T) {
+	g, err := BuildGameState(ModeAtomicBz3m2s, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := g.result()
+	if result.Variant != (chess.Atomic{}) {
+		t.Fatalf("result variant = %v, want atomic", result.Variant)
+	}
+
+	// exd5 explodes the pawns, it is not a standard move
+	board, _ := chess.NewVariantGame(chess.Atomic{})
+	for _, uci := range []string{"e2e4", "d7d5", "e4d5"} {
+		if _, err := board.MakeMoveUCI(board.SideToMove(), uci); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, h := range board.History() {
+		result.Moves = append(result.Moves, h.Move)
+	}
+
+	game, err := result.PGN("alice", "bob", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := game.String(); !strings.Contains(got, `[Variant "Atomic"]`) || !strings.Contains(got, "2. exd5") {
+		t.Errorf("PGN() of an atomic game =\n%s", got)
+	}
+}
+
+func TestPGNResult(t *testing.T) {
+	tests := []struct {
+		status GameStatus
+		winner Color
+		want   string
+	}{
+		{ResultCheckmate, Black, pgn.BlackWins},
+		{ResultTimeout, White, pgn.WhiteWins},
+		{ResultDrawByAgreement, Both, pgn.Draw},
+		{ResultStalemate, None, pgn.Draw},
+		{ResultOngoing, White, pgn.Unknown},
+	}
+	for _, tt := range tests {
+		if got := pgnResult(tt.status, tt.winner); got != tt.want {
+			t.Errorf("pgnResult(%q, %v) = %q, want %q", tt.status, tt.winner, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+// time control of custom games and challenges, beyond the GameMode presets
+
+package game
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	chess "github.com/tommjj/chess_OG/chess_core"
+)
+
+// limits of a custom time control
+const (
+	MaxBaseTime  = 3 * time.Hour
+	MaxIncrement = 3 * time.Minute
+	MaxDelay     = time.Minute
+)
+
+// TimeControl is the time control and the parameters of a custom game, ex: a private challenge
+type TimeControl struct {
+	Base      time.Duration // time of each player at the start, whole seconds
+	Increment time.Duration // time added after each move, whole seconds
+	Delay     time.Duration // delay of each move, whole seconds
+	DelayKind DelayKind     // kind of the delay, DelaySimple if not set
+
+	FEN     string        // start position, the start position of the variant if empty
+	Variant chess.Variant // nil for standard chess
+	Rated   bool          // the result of the game counts for the ratings
+}
+
+// ParseTimeControl parses the compact form of a time control, the base time is in minutes and the increment
+// or the delay in seconds: "5+3", "1+0", "0.5+0", "90d5" (simple delay), "90b5" (Bronstein delay).
+// the other parameters are zero. it is not the PGN syntax in seconds of ParsePGNTimeControl
+func ParseTimeControl(s string) (TimeControl, error) {
+	j := strings.IndexAny(s, "+db")
+	if j < 0 {
+		return TimeControl{}, fmt.Errorf("%w: %q", ErrInvalidTimeControl, s)
+	}
+
+	whole, fraction, _ := strings.Cut(s[:j], ".")
+	minutes, err := strconv.ParseFloat(s[:j], 64)
+	if err != nil || !isDigits(whole) || (strings.Contains(s[:j], ".") && !isDigits(fraction)) {
+		return TimeControl{}, fmt.Errorf("%w: base time of %q", ErrInvalidTimeControl, s)
+	}
+	seconds, err := strconv.Atoi(s[j+1:])
+	if err != nil || !isDigits(s[j+1:]) {
+		return TimeControl{}, fmt.Errorf("%w: increment of %q", ErrInvalidTimeControl, s)
+	}
+
+	tc := TimeControl{Base: time.Duration(minutes * float64(time.Minute)).Round(time.Millisecond)}
+	switch s[j] {
+	case '+':
+		tc.Increment = time.Duration(seconds) * time.Second
+	case 'd':
+		tc.Delay, tc.DelayKind = time.Duration(seconds)*time.Second, DelaySimple
+	case 'b':
+		tc.Delay, tc.DelayKind = time.Duration(seconds)*time.Second, DelayBronstein
+	}
+
+	if err := tc.validateClock(); err != nil {
+		return TimeControl{}, err
+	}
+	return tc, nil
+}
+
+// isDigits reports whether s is a non empty string of decimal digits, ParseFloat and Atoi accept signs and exponents
+func isDigits(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
+
+// String returns the compact form of the clock of the time control, see ParseTimeControl
+func (tc TimeControl) String() string {
+	base := strconv.FormatFloat(tc.Base.Minutes(), 'f', -1, 64)
+	seconds := strconv.Itoa(int(tc.Increment / time.Second))
+
+	if tc.Delay > 0 {
+		seconds = strconv.Itoa(int(tc.Delay / time.Second))
+		if tc.DelayKind == DelayBronstein {
+			return base + "b" + seconds
+		}
+		return base + "d" + seconds
+	}
+	return base + "+" + seconds
+}
+
+// Validate checks the clock and the start position of the time control
+func (tc TimeControl) Validate() error {
+	if err := tc.validateClock(); err != nil {
+		return err
+	}
+
+	if _, ok := tc.Variant.(chess.Bughouse); ok {
+		return fmt.Errorf("%w: bughouse games have four players", ErrInvalidTimeControl)
+	}
+	if tc.FEN != "" {
+		board := chess.NewGame()
+		if tc.Variant != nil {
+			board.SetVariant(tc.Variant)
+		}
+		if err := board.FromFEN(tc.FEN); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidTimeControl, err)
+		}
+	}
+
+	return nil
+}
+
+func (tc TimeControl) validateClock() error {
+	switch {
+	case tc.Base <= 0 || tc.Base > MaxBaseTime:
+		return fmt.Errorf("%w: base time %v", ErrInvalidTimeControl, tc.Base)
+	case tc.Increment < 0 || tc.Increment > MaxIncrement:
+		return fmt.Errorf("%w: increment %v", ErrInvalidTimeControl, tc.Increment)
+	case tc.Delay < 0 || tc.Delay > MaxDelay:
+		return fmt.Errorf("%w: delay %v", ErrInvalidTimeControl, tc.Delay)
+	case tc.Increment > 0 && tc.Delay > 0:
+		return fmt.Errorf("%w: increment and delay", ErrInvalidTimeControl)
+	case tc.Base%time.Second != 0 || tc.Increment%time.Second != 0 || tc.Delay%time.Second != 0:
+		return fmt.Errorf("%w: fractions of seconds", ErrInvalidTimeControl)
+	}
+	return nil
+}
+
+// Clock returns the clock of the time control
+func (tc TimeControl) Clock() Clock {
+	clock := NewClock(tc.Base, tc.Increment)
+	if tc.Delay > 0 {
+		clock.Delay, clock.DelayKind = tc.Delay, tc.DelayKind
+		if clock.DelayKind == DelayNone {
+			clock.DelayKind = DelaySimple
+		}
+	}
+	return clock
+}
+
+// TimeControlOfMode returns the time control of the mode, the games of the modes are rated.
+// false for an invalid mode and for the modes that a TimeControl can not describe (multi-period, hourglass and Chess960)
+func TimeControlOfMode(mode GameMode) (TimeControl, bool) {
+	clock := ClockOfMode(mode)
+	if len(clock.Periods) != 1 || clock.Periods[0].Moves > 0 || clock.Hourglass || IsChess960Mode(mode) {
+		return TimeControl{}, false
+	}
+
+	return TimeControl{
+		Base:      clock.Periods[0].Time,
+		Increment: clock.Periods[0].Increment,
+		Delay:     clock.Delay,
+		DelayKind: clock.DelayKind,
+		Variant:   VariantOfMode(mode),
+		Rated:     true,
+	}, true
+}
+
+// BuildTimeControlGame builds a new GameState from a validated time control, see BuildGameState
+func BuildTimeControlGame(tc TimeControl, endCallBack func(result GameResult)) (*GameState, error) {
+	if err := tc.Validate(); err != nil {
+		return nil, err
+	}
+
+	fen := tc.FEN
+	if fen == "" {
+		fen = initialFEN
+		if tc.Variant != nil {
+			fen = tc.Variant.StartingFEN()
+		}
+	}
+
+	g, err := NewClockGame(tc.Variant, fen, tc.Clock(), endCallBack)
+	if err != nil {
+		return nil, err
+	}
+	g.rated = tc.Rated
+	return g, nil
+}
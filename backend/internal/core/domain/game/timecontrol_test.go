@@ -0,0 +1,75 @@
+package game
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	chess "github.com/tommjj/chess_OG/chess_core"
+)
+
+func TestParseTimeControl(t *testing.T) {
+	tests := []struct {
+		s    string
+		want TimeControl
+	}{
+		{"5+3", TimeControl{Base: 5 * time.Minute, Increment: 3 * time.Second}},
+		{"7+2", TimeControl{Base: 7 * time.Minute, Increment: 2 * time.Second}},
+		{"1+0", TimeControl{Base: time.Minute}},
+		{"0.5+0", TimeControl{Base: 30 * time.Second}},
+		{"90d5", TimeControl{Base: 90 * time.Minute, Delay: 5 * time.Second, DelayKind: DelaySimple}},
+		{"90b5", TimeControl{Base: 90 * time.Minute, Delay: 5 * time.Second, DelayKind: DelayBronstein}},
+	}
+
+	for _, tt := range tests {
+		tc, err := ParseTimeControl(tt.s)
+		if err != nil {
+			t.Fatalf("ParseTimeControl(%q): %v", tt.s, err)
+		}
+		if tc != tt.want || tc.String() != tt.s {
+			t.Errorf("ParseTimeControl(%q) = %+v (%q), want %+v", tt.s, tc, tc.String(), tt.want)
+		}
+	}
+
+	for _, s := range []string{"", "5", "0+1", "x+3", "5+x", "500+0", "5+300", "0.01+0", "5++3", "5+-3", "1e1+0", "-0+3", "+5+3", ".5+0", "5.+0", "0x5+0"} {
+		if _, err := ParseTimeControl(s); !errors.Is(err, ErrInvalidTimeControl) {
+			t.Errorf("ParseTimeControl(%q) error = %v, want %v", s, err, ErrInvalidTimeControl)
+		}
+	}
+}
+
+func TestBuildTimeControlGame(t *testing.T) {
+	tc, _ := ParseTimeControl("7+2")
+	tc.FEN = "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1"
+	tc.Rated = true
+
+	g, err := BuildTimeControlGame(tc, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := g.state.StartFen(); got != tc.FEN {
+		t.Errorf("StartFen() = %q, want %q", got, tc.FEN)
+	}
+	if got := g.result().Clock; got.Initial() != 7*time.Minute || got.Strategy() != (FischerStrategy{Increment: 2 * time.Second}) {
+		t.Errorf("clock = %q, want 7 minutes with a 2 seconds increment", got.String())
+	}
+	if !g.result().Rated {
+		t.Errorf("result of a rated time control is not rated")
+	}
+
+	tc.Variant = chess.Horde{}
+	if _, err := BuildTimeControlGame(tc, nil); !errors.Is(err, ErrInvalidTimeControl) {
+		t.Errorf("BuildTimeControlGame() of a FEN of another variant error = %v, want %v", err, ErrInvalidTimeControl)
+	}
+	tc.FEN, tc.Rated = "", false
+	if g, err := BuildTimeControlGame(tc, nil); err != nil || g.state.StartFen() != (chess.Horde{}).StartingFEN() || g.result().Rated {
+		t.Errorf("BuildTimeControlGame() of a Horde game = %v", err)
+	}
+
+	if preset, ok := TimeControlOfMode(ModeBz3m2s); !ok || preset.String() != "3+2" {
+		t.Errorf("TimeControlOfMode(%q) = %q, %v, want 3+2", ModeBz3m2s, preset.String(), ok)
+	}
+	if _, ok := TimeControlOfMode(ModeClFide90m30s); ok {
+		t.Errorf("TimeControlOfMode(%q) of a multi-period clock is ok", ModeClFide90m30s)
+	}
+}
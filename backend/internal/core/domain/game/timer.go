@@ -9,8 +9,9 @@ var NullTime = time.Time{}
 
 // timer represents a chess game timer.
 type timer struct {
-	InitialTimeSeconds int // Initial time in seconds for each player.
-	IncreaseDuration   time.Duration
+	InitialTimeSeconds int           // Initial time in seconds for each player.
+	IncreaseDuration   time.Duration // Increment of the first period.
+	Clock              Clock         // Time control of the timer.
 
 	BlackTime time.Duration // Remaining time for Black.
 	WhiteTime time.Duration // Remaining time for White.
@@ -24,6 +25,10 @@ type timer struct {
 
 	duration time.Duration // time of game
 
+	strategy    ClockStrategy
+	moves       [2]int        // moves made by each player
+	moveElapsed time.Duration // time used by the current move until LastUpdate
+
 	mx sync.Mutex
 }
 
@@ -34,15 +39,28 @@ type timer struct {
 //	turn: color of the player to start
 //	timeoutCallback: callback function when a player's time runs out
 func NewTimer(initialTimeSeconds int, increaseDuration time.Duration, turn Color, timeoutCallback func(timeoutColor Color)) *timer {
-	return &timer{
-		InitialTimeSeconds: initialTimeSeconds,
-		IncreaseDuration:   increaseDuration,
-		BlackTime:          time.Duration(initialTimeSeconds) * time.Second,
-		WhiteTime:          time.Duration(initialTimeSeconds) * time.Second,
+	return NewClockTimer(NewClock(time.Duration(initialTimeSeconds)*time.Second, increaseDuration), turn, timeoutCallback)
+}
+
+// NewClockTimer creates a new Timer of the clock, the time added after the moves depends on the clock strategy.
+//
+//	turn: color of the player to start
+//	timeoutCallback: callback function when a player's time runs out
+func NewClockTimer(clock Clock, turn Color, timeoutCallback func(timeoutColor Color)) *timer {
+	t := &timer{
+		InitialTimeSeconds: int(clock.Initial() / time.Second),
+		Clock:              clock,
+		BlackTime:          clock.Initial(),
+		WhiteTime:          clock.Initial(),
 		CurrentTurn:        turn,
 		LastUpdate:         NullTime,
 		timeoutCallback:    timeoutCallback,
+		strategy:           clock.Strategy(),
 	}
+	if len(clock.Periods) > 0 {
+		t.IncreaseDuration = clock.Periods[0].Increment
+	}
+	return t
 }
 
 // HasStarted checks if the timer has started.
@@ -50,7 +68,7 @@ func (t *timer) HasStarted() bool {
 	t.mx.Lock()
 	defer t.mx.Unlock()
 
-	return t.duration == 0 && t.LastUpdate.Equal(NullTime)
+	return t.duration != 0 || !t.LastUpdate.Equal(NullTime)
 }
 
 // isStopped checks if the timer is stopped.
@@ -58,12 +76,7 @@ func (t *timer) isStopped() bool {
 	if t.LastUpdate.Equal(NullTime) {
 		return true
 	}
-	elapsed := time.Since(t.LastUpdate)
-	if t.CurrentTurn == White {
-		return t.WhiteTime-elapsed <= 0
-	} else {
-		return t.BlackTime-elapsed <= 0
-	}
+	return t.remaining(t.CurrentTurn) <= 0
 }
 
 // IsStopped checks if the timer is stopped.
@@ -79,12 +92,7 @@ func (t *timer) isRunning() bool {
 	if t.LastUpdate.Equal(NullTime) {
 		return false
 	}
-	elapsed := time.Since(t.LastUpdate)
-	if t.CurrentTurn == White {
-		return t.WhiteTime-elapsed > 0
-	} else {
-		return t.BlackTime-elapsed > 0
-	}
+	return t.remaining(t.CurrentTurn) > 0
 }
 
 // IsRunning checks if the timer is running.
@@ -104,21 +112,38 @@ func (t *timer) updateTime() {
 	now := time.Now()
 	elapsed := now.Sub(t.LastUpdate)
 
-	if t.CurrentTurn == White {
-		t.WhiteTime -= elapsed
-		if t.WhiteTime < 0 {
-			t.WhiteTime = 0
-		}
-	} else {
-		t.BlackTime -= elapsed
-		if t.BlackTime < 0 {
-			t.BlackTime = 0
-		}
-	}
+	clock := t.clockOf(t.CurrentTurn)
+	*clock = max(*clock-t.charged(elapsed), 0)
+
+	t.moveElapsed += elapsed
 	t.duration += elapsed
 	t.LastUpdate = now
 }
 
+// clockOf returns the remaining time of color at the last update
+func (t *timer) clockOf(color Color) *time.Duration {
+	if color == White {
+		return &t.WhiteTime
+	}
+	return &t.BlackTime
+}
+
+// charged returns the time taken from the clock of the current player for elapsed after the last update,
+// the time of the countdown delay of the move is not counted
+func (t *timer) charged(elapsed time.Duration) time.Duration {
+	delay := t.strategy.CountdownDelay()
+	return max(t.moveElapsed+elapsed-delay, 0) - max(t.moveElapsed-delay, 0)
+}
+
+// remaining returns the remaining time of color now
+func (t *timer) remaining(color Color) time.Duration {
+	clock := *t.clockOf(color)
+	if t.LastUpdate != NullTime && color == t.CurrentTurn {
+		clock -= t.charged(time.Since(t.LastUpdate))
+	}
+	return max(clock, 0)
+}
+
 // Stop the game timer. Returns true if the timer was stopped, false if it was already stopped.
 func (t *timer) Stop() bool {
 	t.mx.Lock()
@@ -158,12 +183,7 @@ func (t *timer) WhiteRemaining() time.Duration {
 	t.mx.Lock()
 	defer t.mx.Unlock()
 
-	if t.LastUpdate != NullTime && t.CurrentTurn == White {
-		elapsed := time.Since(t.LastUpdate)
-		return max(t.WhiteTime-elapsed, 0)
-	}
-
-	return t.WhiteTime
+	return t.remaining(White)
 }
 
 // BlackRemaining returns the remaining time for Black.
@@ -171,12 +191,7 @@ func (t *timer) BlackRemaining() time.Duration {
 	t.mx.Lock()
 	defer t.mx.Unlock()
 
-	if t.LastUpdate != NullTime && t.CurrentTurn == Black {
-		elapsed := time.Since(t.LastUpdate)
-		return max(t.BlackTime - elapsed)
-	}
-
-	return t.BlackTime
+	return t.remaining(Black)
 }
 
 // Remaining returns the remaining time for the specified color.
@@ -203,7 +218,7 @@ func (t *timer) CurrentPlayerRemaining() time.Duration {
 	}
 }
 
-// SwitchTurn switches the turn to the other player and adds increment to the player's clock.
+// SwitchTurn switches the turn to the other player and adds the time of the clock strategy to the clocks.
 func (t *timer) SwitchTurn() bool {
 	t.mx.Lock()
 	defer t.mx.Unlock()
@@ -218,12 +233,12 @@ func (t *timer) SwitchTurn() bool {
 		return false
 	}
 
-	if t.CurrentTurn == White {
-		t.WhiteTime += t.IncreaseDuration
-	} else {
-		t.BlackTime += t.IncreaseDuration
-	}
+	t.moves[t.CurrentTurn]++
+	own, opponent := t.strategy.Credit(t.moves[t.CurrentTurn], t.moveElapsed)
+	*t.clockOf(t.CurrentTurn) += own
+	*t.clockOf(t.CurrentTurn.Opposite()) += opponent
 
+	t.moveElapsed = 0
 	t.CurrentTurn = t.CurrentTurn.Opposite()
 
 	t.setTimeout()
@@ -235,16 +250,7 @@ func (t *timer) HasFlagged() bool {
 	t.mx.Lock()
 	defer t.mx.Unlock()
 
-	if t.isStopped() {
-		return t.WhiteTime == 0 || t.BlackTime == 0
-	}
-
-	elapsed := time.Since(t.LastUpdate)
-	if t.CurrentTurn == Black {
-		return t.WhiteTime == 0 || t.BlackTime-elapsed <= 0
-	} else {
-		return t.WhiteTime-elapsed <= 0 || t.BlackTime == 0
-	}
+	return t.remaining(White) <= 0 || t.remaining(Black) <= 0
 }
 
 // GetWinnerOnFlag returns the color of the player who flagged, if any.
@@ -301,9 +307,8 @@ func (t *timer) clearTimeout() {
 
 func (t *timer) setTimeout() {
 	t.clearTimeout()
-	if t.CurrentTurn == White {
-		t.activeTimer = time.AfterFunc(t.WhiteTime+time.Millisecond*50, t.handleTimeout) // add more 50ms
-	} else {
-		t.activeTimer = time.AfterFunc(t.BlackTime+time.Millisecond*50, t.handleTimeout) // add more 50ms
-	}
+
+	// the rest of the countdown delay of the move is not counted
+	delay := max(t.strategy.CountdownDelay()-t.moveElapsed, 0)
+	t.activeTimer = time.AfterFunc(*t.clockOf(t.CurrentTurn)+delay+time.Millisecond*50, t.handleTimeout) // add more 50ms
 }
@@ -0,0 +1,62 @@
+package ports
+
+import (
+	"context"
+	"time"
+)
+
+// EngineLimits limits an engine search, zero fields are not sent.
+type EngineLimits struct {
+	Depth     int
+	Nodes     uint64
+	MoveTime  time.Duration
+	WTime     time.Duration
+	BTime     time.Duration
+	WInc      time.Duration
+	BInc      time.Duration
+	MovesToGo int
+	// Infinite searches until the context is canceled.
+	Infinite bool
+}
+
+// EngineInfo is a search report parsed from an engine info line.
+type EngineInfo struct {
+	Depth    int
+	SelDepth int
+	MultiPV  int
+	// Score is in centipawns from the side to move point of view, unset when Mate is not zero.
+	Score int
+	// Mate is the number of moves to mate, negative when the side to move is mated.
+	Mate  int
+	Nodes uint64
+	NPS   uint64
+	Time  time.Duration
+	// PV is the principal variation in UCI notation.
+	PV []string
+}
+
+// EngineResult is the result of an engine search.
+type EngineResult struct {
+	// BestMove is the best move in UCI notation, "0000" or empty when there is no legal move.
+	BestMove string
+	// Ponder is the expected reply, may be empty.
+	Ponder string
+	// Info is the last info with a principal variation.
+	Info EngineInfo
+}
+
+// EnginePort interface for an external chess engine.
+type EnginePort interface {
+	// Name returns the engine name.
+	Name() string
+	// SetOption sets an engine option.
+	SetOption(ctx context.Context, name, value string) error
+	// NewGame tells the engine the next search is from a different game.
+	NewGame(ctx context.Context) error
+	// Search searches the position reached from fen (the start position when empty) after moves in UCI notation.
+	// onInfo is called for each info line with a score or a principal variation, it may be nil.
+	// Canceling ctx stops the search and returns the best move found so far.
+	Search(ctx context.Context, fen string, moves []string, limits EngineLimits, onInfo func(EngineInfo)) (EngineResult, error)
+	// Close quits the engine.
+	Close() error
+}
@@ -31,8 +31,9 @@ type GameState struct {
 
 // GameSession struct to manage a chess game session
 type GameSession struct {
-	mode  game.GameMode   // Game mode (e.g., Blitz, Rapid)
-	state *game.GameState // Game state
+	mode        game.GameMode    // Game mode (e.g., Blitz, Rapid), empty for a challenge
+	timeControl game.TimeControl // Time control of a challenge
+	state       *game.GameState  // Game state
 
 	white *Player // White player
 	black *Player // Black player
@@ -55,10 +56,32 @@ func NewGameSession(mode game.GameMode, white *Player, black *Player) *GameSessi
 	}
 }
 
+// NewChallengeSession creates the session of a private challenge, the game is built from its time control
+func NewChallengeSession(tc game.TimeControl, white *Player, black *Player, endCallBack func(result game.GameResult)) (*GameSession, error) {
+	state, err := game.BuildTimeControlGame(tc, endCallBack)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GameSession{
+		timeControl: tc,
+		state:       state,
+
+		white:         white,
+		black:         black,
+		drawOfferedBy: nil,
+		spectators:    []uuid.UUID{},
+	}, nil
+}
+
 func (gs *GameSession) GetMode() game.GameMode {
 	return gs.mode
 }
 
+func (gs *GameSession) GetTimeControl() game.TimeControl {
+	return gs.timeControl
+}
+
 func (gs *GameSession) GetState() *game.GameState {
 	return gs.state
 }
@@ -0,0 +1,435 @@
+package engine
+
+// references:
+// - https://www.wbec-ridderkerk.nl/html/UCIProtocol.html
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tommjj/chess_OG/backend/internal/core/ports"
+)
+
+var (
+	// ErrEngineClosed is an error for when the engine process has exited
+	ErrEngineClosed = errors.New("engine closed")
+	// ErrEngineTimeout is an error for when the engine does not reply in time
+	ErrEngineTimeout = errors.New("engine timeout")
+)
+
+// DefaultTimeout is the default time to wait for uciok, readyok and for bestmove after stop.
+const DefaultTimeout = 10 * time.Second
+
+// UCIOptionFunc is a function that can be used to configure the engine.
+type UCIOptionFunc func(*UCIEngine)
+
+// WithArgs sets the command line arguments of the engine.
+func WithArgs(args ...string) UCIOptionFunc {
+	return func(e *UCIEngine) {
+		e.args = args
+	}
+}
+
+// WithTimeout sets the time to wait for the engine replies.
+func WithTimeout(timeout time.Duration) UCIOptionFunc {
+	return func(e *UCIEngine) {
+		e.timeout = timeout
+	}
+}
+
+// WithOption sets an engine option after the handshake.
+func WithOption(name, value string) UCIOptionFunc {
+	return func(e *UCIEngine) {
+		e.options = append(e.options, [2]string{name, value})
+	}
+}
+
+// UCIEngine is an EnginePort adapter for a UCI engine running as a subprocess.
+type UCIEngine struct {
+	path    string
+	args    []string
+	timeout time.Duration
+	options [][2]string
+
+	name   string
+	author string
+
+	// mu serializes the commands
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	lines  chan string
+	exited chan struct{}
+
+	// readyok replies of the isready commands canceled before the reply, they are skipped
+	staleReady int
+
+	closeOnce sync.Once
+}
+
+var _ ports.EnginePort = (*UCIEngine)(nil)
+
+// NewUCIEngine starts the engine at path and waits for the UCI handshake.
+func NewUCIEngine(ctx context.Context, path string, options ...UCIOptionFunc) (*UCIEngine, error) {
+	e := &UCIEngine{
+		path:    path,
+		timeout: DefaultTimeout,
+		lines:   make(chan string, 64),
+		exited:  make(chan struct{}),
+	}
+	for _, option := range options {
+		option(e)
+	}
+
+	e.cmd = exec.Command(e.path, e.args...)
+	stdin, err := e.cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := e.cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	e.stdin = stdin
+
+	if err := e.cmd.Start(); err != nil {
+		return nil, err
+	}
+	go e.readLoop(stdout)
+
+	if err := e.handshake(ctx); err != nil {
+		e.kill()
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// readLoop sends the engine output lines to e.lines until the process exits
+func (e *UCIEngine) readLoop(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		e.lines <- scanner.Text()
+	}
+	close(e.lines)
+
+	_ = e.cmd.Wait()
+	close(e.exited)
+}
+
+func (e *UCIEngine) handshake(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.send("uci"); err != nil {
+		return err
+	}
+
+	err := e.readUntil(ctx, "uciok", func(line string) {
+		if name, ok := strings.CutPrefix(line, "id name "); ok {
+			e.name = name
+		} else if author, ok := strings.CutPrefix(line, "id author "); ok {
+			e.author = author
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, option := range e.options {
+		if err := e.setOption(option[0], option[1]); err != nil {
+			return err
+		}
+	}
+
+	return e.isReady(ctx)
+}
+
+// Name returns the engine id name.
+func (e *UCIEngine) Name() string {
+	return e.name
+}
+
+// Author returns the engine id author.
+func (e *UCIEngine) Author() string {
+	return e.author
+}
+
+// SetOption sets an engine option, value is ignored for button options when empty.
+func (e *UCIEngine) SetOption(ctx context.Context, name, value string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.setOption(name, value); err != nil {
+		return err
+	}
+	return e.isReady(ctx)
+}
+
+func (e *UCIEngine) setOption(name, value string) error {
+	if value == "" {
+		return e.send("setoption name " + name)
+	}
+	return e.send("setoption name " + name + " value " + value)
+}
+
+// NewGame sends ucinewgame and waits for the engine.
+func (e *UCIEngine) NewGame(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.send("ucinewgame"); err != nil {
+		return err
+	}
+	return e.isReady(ctx)
+}
+
+// Search searches the position reached from fen (the start position when empty) after moves.
+// Canceling ctx sends stop and returns the best move found so far.
+func (e *UCIEngine) Search(ctx context.Context, fen string, moves []string, limits ports.EngineLimits, onInfo func(ports.EngineInfo)) (ports.EngineResult, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.send(positionCommand(fen, moves)); err != nil {
+		return ports.EngineResult{}, err
+	}
+	if err := e.send(goCommand(limits)); err != nil {
+		return ports.EngineResult{}, err
+	}
+
+	var result ports.EngineResult
+	done := ctx.Done()
+	var timeout <-chan time.Time
+
+	for {
+		select {
+		case line, ok := <-e.lines:
+			if !ok {
+				return result, ErrEngineClosed
+			}
+
+			fields := strings.Fields(line)
+			if len(fields) == 0 || e.isStale(line) {
+				continue
+			}
+
+			switch fields[0] {
+			case "info":
+				info, ok := parseInfo(fields[1:])
+				if !ok {
+					continue
+				}
+				if len(info.PV) > 0 && info.MultiPV <= 1 {
+					result.Info = info
+				}
+				if onInfo != nil {
+					onInfo(info)
+				}
+			case "bestmove":
+				if len(fields) > 1 {
+					result.BestMove = fields[1]
+				}
+				if len(fields) > 3 && fields[2] == "ponder" {
+					result.Ponder = fields[3]
+				}
+				return result, nil
+			}
+		case <-done:
+			if err := e.send("stop"); err != nil {
+				return result, err
+			}
+			done = nil
+			timeout = time.After(e.timeout)
+		case <-timeout:
+			e.kill()
+			return result, fmt.Errorf("%w: no bestmove after stop", ErrEngineTimeout)
+		}
+	}
+}
+
+// Close quits the engine, it is killed if it does not exit in time.
+func (e *UCIEngine) Close() error {
+	e.closeOnce.Do(func() {
+		_ = e.send("quit")
+		_ = e.stdin.Close()
+
+		select {
+		case <-e.exited:
+		case <-time.After(e.timeout):
+			e.kill()
+		}
+	})
+	return nil
+}
+
+func (e *UCIEngine) kill() {
+	if e.cmd.Process != nil {
+		_ = e.cmd.Process.Kill()
+	}
+}
+
+func (e *UCIEngine) send(command string) error {
+	if _, err := io.WriteString(e.stdin, command+"\n"); err != nil {
+		return fmt.Errorf("%w: %v", ErrEngineClosed, err)
+	}
+	return nil
+}
+
+func (e *UCIEngine) isReady(ctx context.Context) error {
+	if err := e.send("isready"); err != nil {
+		return err
+	}
+	return e.readUntil(ctx, "readyok", nil)
+}
+
+// readUntil reads lines until one equals want, other lines are passed to handle.
+// a readyok that is not read is skipped by the next reads
+func (e *UCIEngine) readUntil(ctx context.Context, want string, handle func(line string)) error {
+	timeout := time.NewTimer(e.timeout)
+	defer timeout.Stop()
+
+	for {
+		select {
+		case line, ok := <-e.lines:
+			if !ok {
+				return ErrEngineClosed
+			}
+			if e.isStale(line) {
+				continue
+			}
+			if strings.TrimSpace(line) == want {
+				return nil
+			}
+			if handle != nil {
+				handle(line)
+			}
+		case <-ctx.Done():
+			e.abandon(want)
+			return ctx.Err()
+		case <-timeout.C:
+			e.abandon(want)
+			return fmt.Errorf("%w: waiting for %s", ErrEngineTimeout, want)
+		}
+	}
+}
+
+// abandon records that the reply want will not be read
+func (e *UCIEngine) abandon(want string) {
+	if want == "readyok" {
+		e.staleReady++
+	}
+}
+
+// isStale reports whether line is the reply of a canceled isready command, the reply is consumed
+func (e *UCIEngine) isStale(line string) bool {
+	if e.staleReady > 0 && strings.TrimSpace(line) == "readyok" {
+		e.staleReady--
+		return true
+	}
+	return false
+}
+
+// positionCommand returns the UCI position command
+func positionCommand(fen string, moves []string) string {
+	var sb strings.Builder
+
+	sb.WriteString("position ")
+	if fen == "" {
+		sb.WriteString("startpos")
+	} else {
+		sb.WriteString("fen " + fen)
+	}
+
+	if len(moves) > 0 {
+		sb.WriteString(" moves " + strings.Join(moves, " "))
+	}
+
+	return sb.String()
+}
+
+// goCommand returns the UCI go command
+func goCommand(limits ports.EngineLimits) string {
+	var sb strings.Builder
+
+	sb.WriteString("go")
+	writeInt := func(name string, value int64) {
+		if value > 0 {
+			fmt.Fprintf(&sb, " %s %d", name, value)
+		}
+	}
+
+	writeInt("wtime", limits.WTime.Milliseconds())
+	writeInt("btime", limits.BTime.Milliseconds())
+	writeInt("winc", limits.WInc.Milliseconds())
+	writeInt("binc", limits.BInc.Milliseconds())
+	writeInt("movestogo", int64(limits.MovesToGo))
+	writeInt("depth", int64(limits.Depth))
+	writeInt("nodes", int64(limits.Nodes))
+	writeInt("movetime", limits.MoveTime.Milliseconds())
+	if limits.Infinite {
+		sb.WriteString(" infinite")
+	}
+
+	return sb.String()
+}
+
+// parseInfo parses the fields of an info line after "info",
+// it returns false for lines without a score or a principal variation
+func parseInfo(fields []string) (ports.EngineInfo, bool) {
+	var info ports.EngineInfo
+	hasScore := false
+
+	for i := 0; i < len(fields); i++ {
+		next := func() int64 {
+			if i+1 >= len(fields) {
+				return 0
+			}
+			i++
+			n, _ := strconv.ParseInt(fields[i], 10, 64)
+			return n
+		}
+
+		switch fields[i] {
+		case "depth":
+			info.Depth = int(next())
+		case "seldepth":
+			info.SelDepth = int(next())
+		case "multipv":
+			info.MultiPV = int(next())
+		case "nodes":
+			info.Nodes = uint64(next())
+		case "nps":
+			info.NPS = uint64(next())
+		case "time":
+			info.Time = time.Duration(next()) * time.Millisecond
+		case "score":
+			if i+2 >= len(fields) {
+				continue
+			}
+			hasScore = true
+			i++
+			switch fields[i] {
+			case "cp":
+				info.Score = int(next())
+			case "mate":
+				info.Mate = int(next())
+			}
+		case "pv":
+			info.PV = append([]string(nil), fields[i+1:]...)
+			i = len(fields)
+		case "string":
+			// the rest of the line is free text
+			return info, false
+		}
+	}
+
+	return info, hasScore || len(info.PV) > 0
+}
@@ -0,0 +1,205 @@
+package engine
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tommjj/chess_OG/backend/internal/core/ports"
+)
+
+// the test binary runs as a fake engine when fakeEngineEnv is set
+const fakeEngineEnv = "CHESS_OG_FAKE_ENGINE"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(fakeEngineEnv) == "1" {
+		fakeEngine()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// fakeEngine prints scripted UCI replies,
+// the option "Hang" makes it ignore stop and isready, the option "Slow" delays readyok
+func fakeEngine() {
+	hang, slow := false, false
+	scanner := bufio.NewScanner(os.Stdin)
+	stop := make(chan struct{}, 1)
+	lines := make(chan string)
+
+	go func() {
+		for scanner.Scan() {
+			if scanner.Text() == "stop" {
+				stop <- struct{}{}
+				continue
+			}
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+
+	for line := range lines {
+		switch {
+		case line == "uci":
+			fmt.Println("id name Fake 1.0")
+			fmt.Println("id author tester")
+			fmt.Println("option name Hang type check default false")
+			fmt.Println("uciok")
+		case line == "isready":
+			if slow {
+				time.Sleep(100 * time.Millisecond)
+			}
+			if !hang {
+				fmt.Println("readyok")
+			}
+		case line == "setoption name Hang value true":
+			hang = true
+		case line == "setoption name Slow value true":
+			slow = true
+		case strings.HasPrefix(line, "position"):
+			fmt.Println("info string " + line)
+		case strings.HasPrefix(line, "go infinite"):
+			fmt.Println("info depth 1 score cp 20 nodes 10 time 1 pv e2e4")
+			<-stop
+			if hang {
+				continue
+			}
+			fmt.Println("bestmove e2e4 ponder e7e5")
+		case strings.HasPrefix(line, "go"):
+			fmt.Println("info depth 1 seldepth 2 score cp 15 nodes 20 nps 20000 time 1 pv d2d4")
+			fmt.Println("info currmove d2d4 currmovenumber 1")
+			fmt.Println("info depth 2 multipv 1 score mate -3 nodes 400 time 2 pv d2d4 d7d5")
+			fmt.Println("info string " + line)
+			fmt.Println("bestmove d2d4 ponder d7d5")
+		case line == "quit":
+			return
+		}
+	}
+}
+
+func newFakeEngine(t *testing.T, options ...UCIOptionFunc) *UCIEngine {
+	t.Helper()
+
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(fakeEngineEnv, "1")
+
+	e, err := NewUCIEngine(context.Background(), exe, append([]UCIOptionFunc{WithTimeout(2 * time.Second)}, options...)...)
+	if err != nil {
+		t.Fatalf("NewUCIEngine: %v", err)
+	}
+	t.Cleanup(func() { e.Close() })
+
+	return e
+}
+
+func TestUCIEngineHandshake(t *testing.T) {
+	e := newFakeEngine(t)
+
+	if e.Name() != "Fake 1.0" || e.Author() != "tester" {
+		t.Errorf("got id %q %q", e.Name(), e.Author())
+	}
+	if err := e.NewGame(context.Background()); err != nil {
+		t.Errorf("NewGame: %v", err)
+	}
+}
+
+func TestUCIEngineSearch(t *testing.T) {
+	e := newFakeEngine(t)
+
+	var infos []ports.EngineInfo
+	result, err := e.Search(context.Background(), "", []string{"e2e4"}, ports.EngineLimits{Depth: 2}, func(info ports.EngineInfo) {
+		infos = append(infos, info)
+	})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+
+	if result.BestMove != "d2d4" || result.Ponder != "d7d5" {
+		t.Errorf("got bestmove %q ponder %q", result.BestMove, result.Ponder)
+	}
+
+	want := []ports.EngineInfo{
+		{Depth: 1, SelDepth: 2, Score: 15, Nodes: 20, NPS: 20000, Time: time.Millisecond, PV: []string{"d2d4"}},
+		{Depth: 2, MultiPV: 1, Mate: -3, Nodes: 400, Time: 2 * time.Millisecond, PV: []string{"d2d4", "d7d5"}},
+	}
+	if !reflect.DeepEqual(infos, want) {
+		t.Errorf("got infos %+v, want %+v", infos, want)
+	}
+	if !reflect.DeepEqual(result.Info, want[1]) {
+		t.Errorf("got result info %+v, want %+v", result.Info, want[1])
+	}
+}
+
+func TestUCIEngineSearchCancel(t *testing.T) {
+	e := newFakeEngine(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	result, err := e.Search(ctx, "8/8/8/8/8/8/8/K6k w - - 0 1", nil, ports.EngineLimits{Infinite: true}, nil)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if result.BestMove != "e2e4" {
+		t.Errorf("got bestmove %q, want e2e4", result.BestMove)
+	}
+}
+
+func TestUCIEngineTimeout(t *testing.T) {
+	e := newFakeEngine(t, WithTimeout(200*time.Millisecond))
+
+	if err := e.SetOption(context.Background(), "Hang", "true"); !errors.Is(err, ErrEngineTimeout) {
+		t.Fatalf("SetOption: got %v, want ErrEngineTimeout", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := e.Search(ctx, "", nil, ports.EngineLimits{Infinite: true}, nil)
+	if !errors.Is(err, ErrEngineTimeout) {
+		t.Errorf("Search: got %v, want ErrEngineTimeout", err)
+	}
+}
+
+func TestUCIEngineStaleReadyOK(t *testing.T) {
+	e := newFakeEngine(t, WithOption("Slow", "true"), WithTimeout(300*time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := e.NewGame(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("NewGame: got %v, want context.DeadlineExceeded", err)
+	}
+
+	// the readyok of the canceled NewGame does not answer the next isready
+	if err := e.SetOption(context.Background(), "Hang", "true"); !errors.Is(err, ErrEngineTimeout) {
+		t.Errorf("SetOption: got %v, want ErrEngineTimeout", err)
+	}
+}
+
+func TestGoCommand(t *testing.T) {
+	tests := []struct {
+		limits ports.EngineLimits
+		want   string
+	}{
+		{ports.EngineLimits{}, "go"},
+		{ports.EngineLimits{Depth: 12}, "go depth 12"},
+		{ports.EngineLimits{WTime: time.Minute, BTime: 30 * time.Second, WInc: time.Second, MovesToGo: 20}, "go wtime 60000 btime 30000 winc 1000 movestogo 20"},
+		{ports.EngineLimits{MoveTime: 500 * time.Millisecond, Nodes: 1000}, "go nodes 1000 movetime 500"},
+		{ports.EngineLimits{Infinite: true}, "go infinite"},
+	}
+
+	for _, tt := range tests {
+		if got := goCommand(tt.limits); got != tt.want {
+			t.Errorf("goCommand(%+v) = %q, want %q", tt.limits, got, tt.want)
+		}
+	}
+}
@@ -130,7 +130,7 @@ var bishopMagicNumbers = [64]BitBoard{
 	0x8004200962a00220, 0x8422100208500202, 0x2000402200300c08, 0x8646020080080080,
 	0x80020a0200100808, 0x2010004880111000, 0x623000a080011400, 0x42008c0340209202,
 	0x209188240001000, 0x400408a884001800, 0x110400a6080400, 0x1840060a44020800,
-	0x90080104000041, 0x201011000808101, 0x1a2208080504f080, 0x801202060021121,
+	0x90080104000041, 0x201011000808101, 0x1a2208080504f080, 0x4412420403020020,
 	0x500861011240000, 0x180806108200800, 0x4000020e01040044, 0x300000261044000a,
 	0x802241102020002, 0x20906061210001, 0x5a84841004010310, 0x4010801011c04,
 	0xa010109502200, 0x4a02012000, 0x500201010098b028, 0x8040002811040900,
@@ -159,17 +159,17 @@ func maskPawnAttacks(side Color, sq Square) BitBoard {
 	pawn := BitBoard(1) << sq
 
 	if side == Black {
-		if (pawn>>7)&notHFile != 0 {
+		if (pawn>>7)&notAFile != 0 {
 			attacks |= pawn >> 7
 		}
-		if (pawn>>9)&notAFile != 0 {
+		if (pawn>>9)&notHFile != 0 {
 			attacks |= pawn >> 9
 		}
 	} else {
-		if (pawn<<7)&notAFile != 0 {
+		if (pawn<<7)&notHFile != 0 {
 			attacks |= pawn << 7
 		}
-		if (pawn<<9)&notHFile != 0 {
+		if (pawn<<9)&notAFile != 0 {
 			attacks |= pawn << 9
 		}
 	}
@@ -560,12 +560,13 @@ func IsAttacked(sq Square, defendingColor Color, bb *BitBoards) bool {
 	return false
 }
 
+// AttackersTo returns the pieces of the opponent of defendingColor attacking the square sq
 func AttackersTo(sq Square, defendingColor Color, bb *BitBoards) BitBoard {
 	var attackers BitBoard
 	oop := defendingColor.Opposite()
 
 	attackers |= PawnAttacks(sq, defendingColor) & bb.PiecesByType(oop, Pawn)
-	attackers |= KnightAttacks(sq) & bb.PiecesByType(oop, King)
+	attackers |= KnightAttacks(sq) & bb.PiecesByType(oop, Knight)
 	attackers |= BishopAttacks(sq, bb.AllPieces) & (bb.PiecesByType(oop, Bishop) | bb.PiecesByType(oop, Queen))
 	attackers |= RookAttacks(sq, bb.AllPieces) & (bb.PiecesByType(oop, Rook) | bb.PiecesByType(oop, Queen))
 	attackers |= KingAttacks(sq) & bb.PiecesByType(oop, King)
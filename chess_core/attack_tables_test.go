@@ -28,3 +28,42 @@ func BenchmarkIsKingAttacked(b *testing.B) {
 		})
 	}
 }
+
+func TestSliderAttacks(t *testing.T) {
+	for sq := range Square(64) {
+		for index := 0; index < 1<<rookRelevantBits[sq]; index++ {
+			occupancy := setOccupancy(index, rookRelevantBits[sq], rookMasks[sq])
+			if got, want := RookAttacks(sq, occupancy), computeRookAttacks(sq, occupancy); got != want {
+				t.Fatalf("RookAttacks(%s) with occupancy %#x = %#x, want %#x", sq, uint64(occupancy), uint64(got), uint64(want))
+			}
+		}
+
+		for index := 0; index < 1<<bishopRelevantBits[sq]; index++ {
+			occupancy := setOccupancy(index, bishopRelevantBits[sq], bishopMasks[sq])
+			if got, want := BishopAttacks(sq, occupancy), computeBishopAttacks(sq, occupancy); got != want {
+				t.Fatalf("BishopAttacks(%s) with occupancy %#x = %#x, want %#x", sq, uint64(occupancy), uint64(got), uint64(want))
+			}
+		}
+	}
+}
+
+func TestPawnAttacks(t *testing.T) {
+	tests := []struct {
+		sq   Square
+		side Color
+		want BitBoard
+	}{
+		{SquareE4, White, SquareD5.ToBB() | SquareF5.ToBB()},
+		{SquareA2, White, SquareB3.ToBB()},
+		{SquareH2, White, SquareG3.ToBB()},
+		{SquareE5, Black, SquareD4.ToBB() | SquareF4.ToBB()},
+		{SquareA7, Black, SquareB6.ToBB()},
+		{SquareH7, Black, SquareG6.ToBB()},
+	}
+
+	for _, tt := range tests {
+		if got := PawnAttacks(tt.sq, tt.side); got != tt.want {
+			t.Errorf("PawnAttacks(%s, %s) =\n%s\nwant\n%s", tt.sq, tt.side, got, tt.want)
+		}
+	}
+}
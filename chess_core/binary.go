@@ -0,0 +1,409 @@
+// Compact binary encoding of positions and games
+//
+// references:
+// - https://www.chessprogramming.org/Encoding_Moves
+// - https://www.chessprogramming.org/Board_Representation
+// - https://pkg.go.dev/encoding#BinaryMarshaler
+
+package chess_core
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// BinaryVersion is the version of the binary encoding, it is the first byte of the encoded positions and games
+const BinaryVersion = 1
+
+// flags of the second byte of an encoded position, the castling rights are in bits 1-4
+const (
+	binaryBlackToMove     = 1 << 0
+	binaryCastlingSquares = 1 << 5 // the castling squares are not the standard ones
+	binaryVariant         = 1 << 6 // the position is not standard chess
+)
+
+// binaryVariants are the variants of the encoding indexed by their variant byte,
+// a byte is never changed or reused so the encoded positions stay readable, new variants are appended
+var binaryVariants = [...]Variant{
+	0: Standard{},
+	1: KingOfTheHill{},
+	2: ThreeCheck{},
+	3: Antichess{},
+	4: Atomic{},
+	5: Horde{},
+	6: Crazyhouse{},
+	7: Bughouse{},
+}
+
+// binaryVariantID returns the variant byte of the variant
+func binaryVariantID(v Variant) (byte, bool) {
+	for id, variant := range binaryVariants {
+		if variant.Name() == v.Name() {
+			return byte(id), true
+		}
+	}
+	return 0, false
+}
+
+// MarshalBinary encodes the position in about 30 bytes:
+//
+//	version byte
+//	flags byte: side to move, castling rights, castling squares and variant flags
+//	en passant square byte, 64 if none
+//	castling squares: 3 bytes with the files of the kings and the castling rooks, if not standard
+//	variant byte: the index of the variant in binaryVariants, if not standard chess
+//	occupancy: 8 bytes little endian
+//	pieces: 4 bits per occupied square from a1 to h8, the piece index of the move encoding
+//	halfmove clock and fullmove number: uvarints
+//	variant state: the checks of Three-check (2 bytes), the pockets (10 bytes) and the promoted pieces (8 bytes) of the drop variants
+func (p Position) MarshalBinary() ([]byte, error) {
+	return p.appendBinary(make([]byte, 0, 32))
+}
+
+func (p *Position) appendBinary(data []byte) ([]byte, error) {
+	var variantID byte
+	if p.variant != nil {
+		id, ok := binaryVariantID(p.variant)
+		if !ok {
+			return nil, fmt.Errorf("%w: %w %q", ErrInvalidEncoding, ErrUnknownVariant, p.variant.Name())
+		}
+		variantID = id
+	}
+
+	ci := p.castlingSquares()
+
+	flags := byte(p.castlingRights&AllCastling) << 1
+	if p.sideToMove == Black {
+		flags |= binaryBlackToMove
+	}
+	if ci != standardCastling {
+		flags |= binaryCastlingSquares
+	}
+	if p.variant != nil {
+		flags |= binaryVariant
+	}
+	data = append(data, BinaryVersion, flags, byte(p.enPassantSquare))
+
+	if ci != standardCastling {
+		// 6 files of 3 bits and the Chess960 flag
+		files := uint32(ci.king[White]%8) | uint32(ci.king[Black]%8)<<3
+		for i, sq := range ci.rook {
+			files |= uint32(sq%8) << (6 + 3*i)
+		}
+		if ci.chess960 {
+			files |= 1 << 18
+		}
+		data = append(data, byte(files), byte(files>>8), byte(files>>16))
+	}
+
+	if p.variant != nil {
+		data = append(data, variantID)
+	}
+
+	data = binary.LittleEndian.AppendUint64(data, uint64(p.bb.AllPieces))
+
+	var nibbles byte
+	odd := false
+	for occupied := p.bb.AllPieces; occupied != 0; {
+		code := byte(encodedPieceIndex(p.bb.GetPieceAt(popLSB(&occupied))))
+		if odd {
+			data = append(data, nibbles|code<<4)
+		} else {
+			nibbles = code
+		}
+		odd = !odd
+	}
+	if odd {
+		data = append(data, nibbles)
+	}
+
+	data = binary.AppendUvarint(data, uint64(p.halfmoveClock))
+	data = binary.AppendUvarint(data, uint64(p.fullmoveNumber))
+
+	if _, ok := p.variant.(ThreeCheck); ok {
+		data = append(data, byte(p.checks[White]), byte(p.checks[Black]))
+	}
+	if hasPockets(p.variant) {
+		for _, pocket := range p.pockets {
+			for pt := Pawn; pt < King; pt++ {
+				data = append(data, byte(pocket[pt]))
+			}
+		}
+		data = binary.LittleEndian.AppendUint64(data, uint64(p.promoted))
+	}
+
+	return data, nil
+}
+
+// UnmarshalBinary decodes a position encoded by MarshalBinary, the position is validated like in FromFEN
+func (p *Position) UnmarshalBinary(data []byte) error {
+	n, err := p.decodeBinary(data)
+	if err != nil {
+		return err
+	}
+	if n != len(data) {
+		return fmt.Errorf("%w: %d trailing bytes", ErrInvalidEncoding, len(data)-n)
+	}
+	return nil
+}
+
+// decodeBinary decodes the position at the start of data and returns the number of bytes read
+func (p *Position) decodeBinary(data []byte) (int, error) {
+	r := binaryReader{data: data}
+
+	header := r.bytes(3)
+	if r.err != nil {
+		return 0, r.err
+	}
+	if header[0] != BinaryVersion {
+		return 0, fmt.Errorf("%w: version %d", ErrInvalidEncoding, header[0])
+	}
+	flags := header[1]
+
+	pos := Position{
+		sideToMove:      White,
+		castlingRights:  int(flags>>1) & AllCastling,
+		enPassantSquare: Square(header[2]),
+		castling:        standardCastling,
+	}
+	if flags&binaryBlackToMove != 0 {
+		pos.sideToMove = Black
+	}
+	if pos.enPassantSquare > NoEnPassant {
+		return 0, fmt.Errorf("%w: en passant square %d", ErrInvalidEncoding, pos.enPassantSquare)
+	}
+
+	if flags&binaryCastlingSquares != 0 {
+		b := r.bytes(3)
+		if r.err != nil {
+			return 0, r.err
+		}
+		files := uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16
+
+		file := func(i int) Square { return Square(files >> (3 * i) & 7) }
+		king := [2]Square{SquareA1 + file(0), SquareA8 + file(1)}
+		rook := [4]Square{SquareA1 + file(2), SquareA1 + file(3), SquareA8 + file(4), SquareA8 + file(5)}
+		pos.castling = newCastlingInfo(king, rook, files&(1<<18) != 0)
+	}
+
+	if flags&binaryVariant != 0 {
+		id := r.byte()
+		if r.err != nil {
+			return 0, r.err
+		}
+		if int(id) >= len(binaryVariants) {
+			return 0, fmt.Errorf("%w: variant %d", ErrInvalidEncoding, id)
+		}
+		if _, ok := binaryVariants[id].(Standard); !ok {
+			pos.variant = binaryVariants[id]
+		}
+	}
+
+	occupied := BitBoard(r.uint64())
+	pieces := r.bytes((occupied.Count() + 1) / 2)
+	if r.err != nil {
+		return 0, r.err
+	}
+	for i := 0; occupied != 0; i++ {
+		code := pieces[i/2] >> (4 * (i % 2)) & 0xF
+		if code == 0 || int(code) >= len(ASCIIPieces) {
+			return 0, fmt.Errorf("%w: piece code %d", ErrInvalidEncoding, code)
+		}
+		pos.bb.setPieceAt(popLSB(&occupied), ASCIIPieces[code])
+	}
+	pos.bb.UpdateAggregate()
+
+	pos.halfmoveClock = int(r.uvarint())
+	pos.fullmoveNumber = int(r.uvarint())
+
+	if _, ok := pos.variant.(ThreeCheck); ok {
+		checks := r.bytes(2)
+		if r.err != nil {
+			return 0, r.err
+		}
+		pos.checks = [2]int{int(checks[White]), int(checks[Black])}
+	}
+	if hasPockets(pos.variant) {
+		counts := r.bytes(10)
+		if r.err != nil {
+			return 0, r.err
+		}
+		for i, count := range counts {
+			pos.pockets[i/5][Pawn+PieceType(i%5)] = int(count)
+		}
+		pos.promoted = BitBoard(r.uint64()) & pos.bb.AllPieces
+	}
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	if pos.fullmoveNumber <= 0 {
+		return 0, wrapError(ErrNegativeFullmove, "fullmove in UnmarshalBinary")
+	}
+
+	b := pos.board()
+	if err := pos.rules().Validate(&b); err != nil {
+		return 0, fmt.Errorf("position in UnmarshalBinary: %w", err)
+	}
+	pos.hash = pos.computeZobristHash()
+
+	*p = pos
+	return r.n, nil
+}
+
+// CompactMove is a move in 16 bits: the source square (bits 0-5), the target square (bits 6-11),
+// the promotion or dropped piece type (bits 12-14) and the castling flag (bit 15).
+// drops have the same source and target square
+type CompactMove uint16
+
+// Compact returns the 16 bits encoding of the move, see Position.ExpandMove
+func (m Move) Compact() CompactMove {
+	c := CompactMove(m.From()) | CompactMove(m.To())<<6
+	switch {
+	case m.IsDrop():
+		c |= CompactMove(ASCIIPieces[m.Piece()].Type()) << 12
+	case m.IsPromotion():
+		c |= CompactMove(m.Promoted()) << 12
+	case m.IsCastle():
+		c |= 1 << 15
+	}
+	return c
+}
+
+// ExpandMove returns the legal move of the position encoded by Move.Compact
+func (p Position) ExpandMove(c CompactMove) (Move, error) {
+	ml := make(MoveList, 0, 64)
+	p.generateMoves(&ml)
+
+	for _, move := range ml {
+		if move.Compact() == c {
+			return move, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: compact move %#04x", ErrInvalidMove, uint16(c))
+}
+
+// MarshalBinary encodes the game as its start position followed by the number of moves (uvarint)
+// and the moves in 16 bits little endian, see Position.MarshalBinary and Move.Compact.
+// Bughouse games can not be encoded, their pockets depend on the other board
+func (gs *GameState) MarshalBinary() ([]byte, error) {
+	gs.mx.Lock()
+	defer gs.mx.Unlock()
+
+	if _, ok := gs.pos.variant.(Bughouse); ok {
+		return nil, fmt.Errorf("%w: bughouse game", ErrInvalidEncoding)
+	}
+
+	moves := gs.node.Path()
+	data, err := gs.tree.root.position.appendBinary(make([]byte, 0, 32+2*len(moves)))
+	if err != nil {
+		return nil, err
+	}
+	data = binary.AppendUvarint(data, uint64(len(moves)))
+	for _, move := range moves {
+		data = binary.LittleEndian.AppendUint16(data, uint16(move.Compact()))
+	}
+
+	return data, nil
+}
+
+// UnmarshalBinary replaces the game with the game encoded by MarshalBinary, the moves are played again
+func (gs *GameState) UnmarshalBinary(data []byte) error {
+	var start Position
+	n, err := start.decodeBinary(data)
+	if err != nil {
+		return err
+	}
+
+	r := binaryReader{data: data, n: n}
+	count := r.uvarint()
+	moves := r.bytes(2 * int(count))
+	if r.err != nil {
+		return r.err
+	}
+	if r.n != len(data) {
+		return fmt.Errorf("%w: %d trailing bytes", ErrInvalidEncoding, len(data)-r.n)
+	}
+
+	tree := NewGameTree(start)
+	game := &GameState{
+		pos:      start,
+		startFen: start.FEN(),
+		tree:     tree,
+		node:     tree.root,
+		state:    ResultOngoing,
+		winner:   None,
+	}
+	for i := 0; i < len(moves); i += 2 {
+		if game.state != ResultOngoing {
+			return fmt.Errorf("%w: move %d after the end of the game", ErrInvalidEncoding, i/2+1)
+		}
+
+		move, err := game.pos.ExpandMove(CompactMove(binary.LittleEndian.Uint16(moves[i:])))
+		if err != nil {
+			return fmt.Errorf("move %d in UnmarshalBinary: %w", i/2+1, err)
+		}
+		if _, err := game.makeMove(move); err != nil {
+			return fmt.Errorf("move %d in UnmarshalBinary: %w", i/2+1, err)
+		}
+	}
+
+	gs.mx.Lock()
+	defer gs.mx.Unlock()
+
+	gs.pos = game.pos
+	gs.startFen = game.startFen
+	gs.tree = game.tree
+	gs.node = game.node
+	gs.state = game.state
+	gs.winner = game.winner
+
+	return nil
+}
+
+// binaryReader reads the encoded data, it keeps the first error
+type binaryReader struct {
+	data []byte
+	n    int // bytes read
+	err  error
+}
+
+func (r *binaryReader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || r.n+n > len(r.data) {
+		r.err = fmt.Errorf("%w: unexpected end of data", ErrInvalidEncoding)
+		return nil
+	}
+	b := r.data[r.n : r.n+n]
+	r.n += n
+	return b
+}
+
+func (r *binaryReader) byte() byte {
+	if b := r.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *binaryReader) uint64() uint64 {
+	if b := r.bytes(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (r *binaryReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.data[r.n:])
+	if n <= 0 || v > 1<<31 {
+		r.err = fmt.Errorf("%w: invalid varint", ErrInvalidEncoding)
+		return 0
+	}
+	r.n += n
+	return v
+}
@@ -0,0 +1,129 @@
+package chess_core
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPositionBinary(t *testing.T) {
+	tests := []struct {
+		variant Variant
+		fen     string
+	}{
+		{nil, StartingFEN},
+		{nil, "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1"},
+		{nil, "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3"},
+		{nil, "bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w HFhf - 2 9"},
+		{ThreeCheck{}, "rnbqkbnr/ppp1pppp/8/1B1p4/4P3/8/PPPP1PPP/RNBQK1NR b KQkq - 1 2 +1+0"},
+		{Crazyhouse{}, "r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB~R[Pn] w KQkq - 2 3"},
+	}
+
+	for _, tt := range tests {
+		pos, err := ParseVariantPosition(tt.variant, tt.fen)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, _ := pos.MarshalBinary()
+		var got Position
+		if err := got.UnmarshalBinary(data); err != nil {
+			t.Fatalf("UnmarshalBinary(%q): %v", tt.fen, err)
+		}
+		if got.FEN() != pos.FEN() || got.Hash() != pos.Hash() || got.IsChess960() != pos.IsChess960() {
+			t.Errorf("UnmarshalBinary(%q) = %q", tt.fen, got.FEN())
+		}
+	}
+
+	start, _ := ParsePosition(StartingFEN)
+	data, _ := start.MarshalBinary()
+	if len(data) > 32 {
+		t.Errorf("start position is %d bytes, want at most 32", len(data))
+	}
+
+	var pos Position
+	if err := pos.UnmarshalBinary(data[:len(data)-1]); !errors.Is(err, ErrInvalidEncoding) {
+		t.Errorf("UnmarshalBinary() of truncated data error = %v, want %v", err, ErrInvalidEncoding)
+	}
+	data[0] = BinaryVersion + 1
+	if err := pos.UnmarshalBinary(data); !errors.Is(err, ErrInvalidEncoding) {
+		t.Errorf("UnmarshalBinary() of an unknown version error = %v, want %v", err, ErrInvalidEncoding)
+	}
+}
+
+// the variant bytes are part of the encoding and must never change
+func TestBinaryVariantIDs(t *testing.T) {
+	want := map[string]byte{
+		"standard":      0,
+		"kingofthehill": 1,
+		"threecheck":    2,
+		"antichess":     3,
+		"atomic":        4,
+		"horde":         5,
+		"crazyhouse":    6,
+		"bughouse":      7,
+	}
+
+	for _, v := range Variants {
+		id, ok := binaryVariantID(v)
+		if !ok || id != want[v.Name()] {
+			t.Errorf("binaryVariantID(%s) = %d, %v, want %d", v.Name(), id, ok, want[v.Name()])
+		}
+
+		pos, err := ParseVariantPosition(v, v.StartingFEN())
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := pos.MarshalBinary()
+		if data[1]&binaryVariant == 0 { // standard chess
+			continue
+		}
+		if data[3] != want[v.Name()] {
+			t.Errorf("variant byte of %s = %d, want %d", v.Name(), data[3], want[v.Name()])
+		}
+
+		data[3] = byte(len(binaryVariants))
+		if err := pos.UnmarshalBinary(data); !errors.Is(err, ErrInvalidEncoding) {
+			t.Errorf("UnmarshalBinary() of an unknown variant error = %v, want %v", err, ErrInvalidEncoding)
+		}
+	}
+}
+
+func TestGameBinary(t *testing.T) {
+	tests := []struct {
+		variant Variant
+		fen     string
+		moves   []string
+	}{
+		{Standard{}, StartingFEN, []string{"e2e4", "e7e5", "g1f3", "b8c6", "f1c4", "g8f6", "e1g1"}},
+		{Standard{}, "4k3/1P6/8/8/8/8/8/R3K3 w Q - 0 1", []string{"b7b8n", "e8f7", "e1c1"}},
+		{Standard{}, "1r2k1r1/pppppppp/8/8/8/8/PPPPPPPP/1R2K1R1 w GBgb - 0 1", []string{"e1b1", "e8g8"}},
+		{Crazyhouse{}, dropStartingFEN, []string{"e2e4", "d7d5", "e4d5", "d8d5", "P@e4"}},
+	}
+
+	for _, tt := range tests {
+		gs, _ := playVariant(t, tt.variant, tt.fen, tt.moves...)
+
+		data, err := gs.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := NewGame()
+		if err := got.UnmarshalBinary(data); err != nil {
+			t.Fatalf("UnmarshalBinary(%q): %v", tt.fen, err)
+		}
+		if got.ToFEN() != gs.ToFEN() || len(got.History()) != len(tt.moves) {
+			t.Errorf("UnmarshalBinary(%q) = %q with %d moves, want %q", tt.fen, got.ToFEN(), len(got.History()), gs.ToFEN())
+		}
+		if err := got.Undo(len(tt.moves)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	gs, _ := playVariant(t, Standard{}, StartingFEN, "e2e4")
+	data, _ := gs.MarshalBinary()
+	data[len(data)-1] ^= 0x0F // the target square
+	if err := NewGame().UnmarshalBinary(data); !errors.Is(err, ErrInvalidMove) {
+		t.Errorf("UnmarshalBinary() of an illegal move error = %v, want %v", err, ErrInvalidMove)
+	}
+}
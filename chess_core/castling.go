@@ -0,0 +1,260 @@
+// castling squares for standard chess and Chess960 (Fischer Random Chess)
+//
+// references:
+// - https://www.chessprogramming.org/Castling
+// - https://www.chessprogramming.org/Chess960
+// - https://www.chessprogramming.org/Forsyth-Edwards_Notation#Shredder-FEN
+// - https://www.chessprogramming.org/X-FEN
+
+package chess_core
+
+import (
+	"strings"
+)
+
+// castlingInfo holds the start squares of the kings and the castling rooks.
+// they are E1/E8, H1/H8 and A1/A8 in standard chess but can be on any file in Chess960
+type castlingInfo struct {
+	king [2]Square // king start square [White, Black]
+	rook [4]Square // castling rook start square by castling right index (WK, WQ, BK, BQ)
+
+	// castling rights update mask by square
+	mask [64]int
+
+	chess960 bool
+}
+
+// standardCastling is the castling info of standard chess
+var standardCastling = newCastlingInfo(
+	[2]Square{SquareE1, SquareE8},
+	[4]Square{SquareH1, SquareA1, SquareH8, SquareA8},
+	false,
+)
+
+// castlingRights by castling right index
+var castlingRightByIndex = [4]int{WK, WQ, BK, BQ}
+
+func newCastlingInfo(king [2]Square, rook [4]Square, chess960 bool) *castlingInfo {
+	ci := &castlingInfo{king: king, rook: rook, chess960: chess960}
+
+	for sq := range ci.mask {
+		ci.mask[sq] = AllCastling
+	}
+
+	ci.mask[king[White]] &^= WK | WQ
+	ci.mask[king[Black]] &^= BK | BQ
+	for i, sq := range rook {
+		ci.mask[sq] &^= castlingRightByIndex[i]
+	}
+
+	return ci
+}
+
+// castlingRightIndex returns the index of castling right (WK, WQ, BK or BQ)
+func castlingRightIndex(right int) int {
+	switch right {
+	case WK:
+		return 0
+	case WQ:
+		return 1
+	case BK:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// castlingTargets returns the king and rook target squares of castling right
+func castlingTargets(right int) (kingTo, rookTo Square) {
+	switch right {
+	case WK:
+		return SquareG1, SquareF1
+	case WQ:
+		return SquareC1, SquareD1
+	case BK:
+		return SquareG8, SquareF8
+	default:
+		return SquareC8, SquareD8
+	}
+}
+
+// sideCastlingRights returns the king side and queen side castling rights of side
+func sideCastlingRights(side Color) (kingSide, queenSide int) {
+	if side == White {
+		return WK, WQ
+	}
+	return BK, BQ
+}
+
+// castlingRightOf returns the castling right of a castling move
+func castlingRightOf(move Move) int {
+	kingSide, queenSide := sideCastlingRights(move.Side())
+	if Square(move.To()).File() == SquareG1.File() {
+		return kingSide
+	}
+	return queenSide
+}
+
+// rookSquare returns the castling rook start square of castling right
+func (ci *castlingInfo) rookSquare(right int) Square {
+	return ci.rook[castlingRightIndex(right)]
+}
+
+// canCastle checks the castling right of side on the board, without checking the king safety on the target square
+func (ci *castlingInfo) canCastle(bb *BitBoards, side Color, castling int, right int) bool {
+	if castling&right == 0 {
+		return false
+	}
+
+	kingFrom := ci.king[side]
+	rookFrom := ci.rookSquare(right)
+	kingTo, rookTo := castlingTargets(right)
+
+	if bb.PiecesByType(side, King)&kingFrom.ToBB() == 0 || bb.PiecesByType(side, Rook)&rookFrom.ToBB() == 0 {
+		return false
+	}
+
+	// every square the king and the rook pass must be empty, except the king and the rook themselves
+	path := BetweenBits(kingFrom, kingTo) | kingTo.ToBB() | BetweenBits(rookFrom, rookTo) | rookTo.ToBB()
+	path &^= kingFrom.ToBB() | rookFrom.ToBB()
+	if bb.AllPieces&path != 0 {
+		return false
+	}
+
+	// the king must not be in check or pass an attacked square,
+	// the target square is checked after the move is made
+	kingPath := BetweenBits(kingFrom, kingTo) | kingFrom.ToBB()
+	for kingPath != 0 {
+		sq := popLSB(&kingPath)
+		if IsAttacked(sq, side, bb) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// generateCastlingMoves generates the castling moves of side, the king target square is not checked
+func generateCastlingMoves(bb *BitBoards, side Color, castling int, ci *castlingInfo, ml *MoveList) {
+	kingCode := uint32(encodedPieceIndex(getPieceBySide(King, side)))
+	kingSide, queenSide := sideCastlingRights(side)
+
+	for _, right := range [2]int{kingSide, queenSide} {
+		if ci.canCastle(bb, side, castling, right) {
+			kingTo, _ := castlingTargets(right)
+			ml.Add(NewMove(uint32(ci.king[side]), uint32(kingTo), kingCode, 0, 0, 0, 0, 1))
+		}
+	}
+}
+
+// parseCastling parses the castling field of a FEN string.
+// it accepts standard "KQkq", X-FEN and Shredder-FEN (rook files, ex: "HAha") castling fields
+func parseCastling(bb *BitBoards, field string) (int, *castlingInfo, error) {
+	if field == "-" {
+		return 0, standardCastling, nil
+	}
+
+	king := standardCastling.king
+	rook := standardCastling.rook
+
+	rights := 0
+	kingSet := [2]bool{}
+	for _, c := range field {
+		side := White
+		backRank := 0
+		if c >= 'a' && c <= 'z' {
+			side = Black
+			backRank = 7
+		}
+
+		kingBB := bb.PiecesByType(side, King) & rankMask(backRank)
+		if kingBB.Count() != 1 {
+			return 0, nil, ErrInvalidCastling
+		}
+		kingSq := Square(kingBB.LeastSignificantBit())
+		if kingSet[side] && king[side] != kingSq {
+			return 0, nil, ErrInvalidCastling
+		}
+		king[side] = kingSq
+		kingSet[side] = true
+
+		rooks := bb.PiecesByType(side, Rook) & rankMask(backRank)
+		kingSide, queenSide := sideCastlingRights(side)
+
+		var right int
+		var rookSq Square
+		switch upper := c &^ 0x20; {
+		case upper == 'K': // outermost rook on the king side
+			right = kingSide
+			rookSq = Square((rooks & ^(kingSq.ToBB()<<1 - 1)).MostSignificantBit())
+		case upper == 'Q': // outermost rook on the queen side
+			right = queenSide
+			rookSq = Square((rooks & (kingSq.ToBB() - 1)).LeastSignificantBit())
+		case upper >= 'A' && upper <= 'H': // rook file
+			rookSq = Square(backRank*8 + int(upper-'A'))
+			if rookSq > kingSq {
+				right = kingSide
+			} else {
+				right = queenSide
+			}
+		default:
+			return 0, nil, ErrInvalidCastling
+		}
+
+		if rookSq < 0 || rooks&rookSq.ToBB() == 0 || rights&right != 0 {
+			return 0, nil, ErrInvalidCastling
+		}
+
+		rights |= right
+		rook[castlingRightIndex(right)] = rookSq
+	}
+
+	if king == standardCastling.king && rook == standardCastling.rook {
+		return rights, standardCastling, nil
+	}
+
+	return rights, newCastlingInfo(king, rook, true), nil
+}
+
+// formatCastling returns the castling field of a FEN string,
+// X-FEN letters are used when the castling rook is the outermost rook, rook files otherwise
+func formatCastling(bb *BitBoards, castling int, ci *castlingInfo) string {
+	var sb strings.Builder
+
+	for i, right := range castlingRightByIndex {
+		if castling&right == 0 {
+			continue
+		}
+
+		side := White
+		if right == BK || right == BQ {
+			side = Black
+		}
+
+		rookSq := ci.rook[i]
+		kingSq := ci.king[side]
+		rooks := bb.PiecesByType(side, Rook) & rankMask(int(kingSq)/8)
+
+		var outermost Square
+		var letter byte
+		if rookSq > kingSq {
+			outermost = Square((rooks & ^(kingSq.ToBB()<<1 - 1)).MostSignificantBit())
+			letter = 'K'
+		} else {
+			outermost = Square((rooks & (kingSq.ToBB() - 1)).LeastSignificantBit())
+			letter = 'Q'
+		}
+		if rookSq != outermost {
+			letter = byte('A' + rookSq.File() - 1)
+		}
+		if side == Black {
+			letter |= 0x20
+		}
+		sb.WriteByte(letter)
+	}
+
+	if sb.Len() == 0 {
+		return "-"
+	}
+	return sb.String()
+}
@@ -0,0 +1,155 @@
+// Check, pin and attack introspection
+//
+// references:
+// - https://www.chessprogramming.org/Checks_and_Pinned_Pieces_(Bitboards)
+// - https://www.chessprogramming.org/Square_Attacked_By
+
+package chess_core
+
+// Pin is a piece pinned to its king
+type Pin struct {
+	Pinned Square // square of the pinned piece
+	Pinner Square // square of the enemy slider pinning it
+}
+
+// InCheck reports whether the side to move is in check
+func (p Position) InCheck() bool {
+	return IsKingAttacked(p.sideToMove, &p.bb)
+}
+
+// Checkers returns the pieces giving check to the side to move
+func (p Position) Checkers() BitBoard {
+	king := Square(p.bb.PiecesByType(p.sideToMove, King).LeastSignificantBit())
+	if king < 0 {
+		return 0
+	}
+
+	return AttackersTo(king, p.sideToMove, &p.bb)
+}
+
+// PinnedPieces returns the pieces of color pinned to their king with the squares of the pinners
+func (p Position) PinnedPieces(color Color) []Pin {
+	return pinnedPieces(&p.bb, color)
+}
+
+// AttackedSquares returns the squares attacked by the pieces of color
+func (p Position) AttackedSquares(color Color) BitBoard {
+	return attackedSquares(&p.bb, color, p.bb.AllPieces)
+}
+
+// Attackers returns the pieces of both colors attacking the square sq
+func (p Position) Attackers(sq Square) BitBoard {
+	if !sq.IsValid() {
+		return 0
+	}
+
+	return attackersOf(&p.bb, sq, p.bb.AllPieces)
+}
+
+// IsMoveCheck reports whether the move of the side to move gives check
+func (p Position) IsMoveCheck(move Move) bool {
+	makeUnsafeMove(&p.bb, move, p.castlingSquares())
+	return IsKingAttacked(move.Side().Opposite(), &p.bb)
+}
+
+// InCheck reports whether the side to move is in check
+func (gs *GameState) InCheck() bool {
+	return gs.Position().InCheck()
+}
+
+// Checkers returns the pieces giving check to the side to move
+func (gs *GameState) Checkers() BitBoard {
+	return gs.Position().Checkers()
+}
+
+// PinnedPieces returns the pieces of color pinned to their king with the squares of the pinners
+func (gs *GameState) PinnedPieces(color Color) []Pin {
+	return gs.Position().PinnedPieces(color)
+}
+
+// AttackedSquares returns the squares attacked by the pieces of color
+func (gs *GameState) AttackedSquares(color Color) BitBoard {
+	return gs.Position().AttackedSquares(color)
+}
+
+// Attackers returns the pieces of both colors attacking the square sq
+func (gs *GameState) Attackers(sq Square) BitBoard {
+	return gs.Position().Attackers(sq)
+}
+
+// IsMoveCheck reports whether the move of the side to move gives check
+func (gs *GameState) IsMoveCheck(move Move) bool {
+	return gs.Position().IsMoveCheck(move)
+}
+
+// attackersOf returns the pieces of both colors attacking the square sq with the occupancy
+func attackersOf(bb *BitBoards, sq Square, occupancy BitBoard) BitBoard {
+	bishops := bb.WhiteBishops | bb.BlackBishops | bb.WhiteQueens | bb.BlackQueens
+	rooks := bb.WhiteRooks | bb.BlackRooks | bb.WhiteQueens | bb.BlackQueens
+
+	return PawnAttacks(sq, Black)&bb.WhitePawns |
+		PawnAttacks(sq, White)&bb.BlackPawns |
+		KnightAttacks(sq)&(bb.WhiteKnights|bb.BlackKnights) |
+		KingAttacks(sq)&(bb.WhiteKing|bb.BlackKing) |
+		BishopAttacks(sq, occupancy)&bishops |
+		RookAttacks(sq, occupancy)&rooks
+}
+
+// attackedSquares returns the squares attacked by the pieces of color with the occupancy
+func attackedSquares(bb *BitBoards, color Color, occupancy BitBoard) BitBoard {
+	var attacks BitBoard
+
+	pieces := bb.PiecesByType(color, Pawn)
+	for pieces != 0 {
+		attacks |= PawnAttacks(popLSB(&pieces), color)
+	}
+
+	pieces = bb.PiecesByType(color, Knight)
+	for pieces != 0 {
+		attacks |= KnightAttacks(popLSB(&pieces))
+	}
+
+	pieces = bb.PiecesByType(color, Bishop) | bb.PiecesByType(color, Queen)
+	for pieces != 0 {
+		attacks |= BishopAttacks(popLSB(&pieces), occupancy)
+	}
+
+	pieces = bb.PiecesByType(color, Rook) | bb.PiecesByType(color, Queen)
+	for pieces != 0 {
+		attacks |= RookAttacks(popLSB(&pieces), occupancy)
+	}
+
+	pieces = bb.PiecesByType(color, King)
+	for pieces != 0 {
+		attacks |= KingAttacks(popLSB(&pieces))
+	}
+
+	return attacks
+}
+
+// pinnedPieces returns the pieces of color between their king and an enemy slider with nothing else between them
+func pinnedPieces(bb *BitBoards, color Color) []Pin {
+	king := Square(bb.PiecesByType(color, King).LeastSignificantBit())
+	if king < 0 {
+		return nil
+	}
+
+	enemy := color.Opposite()
+	queens := bb.PiecesByType(enemy, Queen)
+
+	// enemy sliders on a line with the king when only enemy pieces block
+	snipers := RookAttacks(king, bb.OccupiedBy(enemy))&(bb.PiecesByType(enemy, Rook)|queens) |
+		BishopAttacks(king, bb.OccupiedBy(enemy))&(bb.PiecesByType(enemy, Bishop)|queens)
+
+	var pins []Pin
+	for snipers != 0 {
+		sniper := popLSB(&snipers)
+
+		between := BetweenBits(king, sniper) & bb.AllPieces
+		if between.Count() == 1 && between&bb.OccupiedBy(color) != 0 {
+			pins = append(pins, Pin{Pinned: Square(between.LeastSignificantBit()), Pinner: sniper})
+		}
+	}
+
+	return pins
+}
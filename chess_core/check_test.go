@@ -0,0 +1,103 @@
+package chess_core
+
+import (
+	"slices"
+	"testing"
+)
+
+func newTestGame(t *testing.T, fen string) *GameState {
+	t.Helper()
+
+	gs := NewGame()
+	if err := gs.FromFEN(fen); err != nil {
+		t.Fatalf("FromFEN(%q): %v", fen, err)
+	}
+	return gs
+}
+
+func TestCheckers(t *testing.T) {
+	tests := []struct {
+		fen  string
+		want BitBoard
+	}{
+		{StartingFEN, 0},
+		// knight check, AttackersTo used to look for kings on knight squares
+		{"4k3/8/3N4/8/8/8/8/4K3 b - - 0 1", SquareD6.ToBB()},
+		{"4k3/8/8/8/8/5N2/8/K3R3 b - - 0 1", SquareE1.ToBB()},
+		{"4k3/8/3N4/8/8/8/8/K3R3 b - - 0 1", SquareD6.ToBB() | SquareE1.ToBB()},
+		{"4k3/3P4/8/8/8/8/8/4K3 b - - 0 1", SquareD7.ToBB()},
+	}
+
+	for _, tt := range tests {
+		gs := newTestGame(t, tt.fen)
+
+		if got := gs.Checkers(); got != tt.want {
+			t.Errorf("Checkers(%q) = %x, want %x", tt.fen, uint64(got), uint64(tt.want))
+		}
+		if got := gs.InCheck(); got != (tt.want != 0) {
+			t.Errorf("InCheck(%q) = %v", tt.fen, got)
+		}
+	}
+}
+
+func TestPinnedPieces(t *testing.T) {
+	// the e2 knight is pinned by the e8 rook, the d2 bishop by the a5 queen,
+	// the f2 pawn is not pinned because the g3 pawn blocks the h4 bishop
+	gs := newTestGame(t, "3kr3/8/8/q7/7b/6p1/3BNP2/4K3 w - - 0 1")
+
+	got := gs.PinnedPieces(White)
+	want := []Pin{{Pinned: SquareD2, Pinner: SquareA5}, {Pinned: SquareE2, Pinner: SquareE8}}
+	slices.SortFunc(got, func(a, b Pin) int { return int(a.Pinned - b.Pinned) })
+	if !slices.Equal(got, want) {
+		t.Errorf("PinnedPieces(White) = %v, want %v", got, want)
+	}
+
+	if got := gs.PinnedPieces(Black); len(got) != 0 {
+		t.Errorf("PinnedPieces(Black) = %v, want none", got)
+	}
+}
+
+func TestAttacks(t *testing.T) {
+	gs := newTestGame(t, StartingFEN)
+
+	attacked := gs.AttackedSquares(White)
+	if attacked.Count() != 22 || !attacked.IsSet(SquareE3) || attacked.IsSet(SquareA1) || attacked.IsSet(SquareE4) {
+		t.Errorf("AttackedSquares(White) = %x", uint64(attacked))
+	}
+
+	// d2 is defended by the b1 knight, c1 bishop, d1 queen and e1 king
+	want := SquareB1.ToBB() | SquareC1.ToBB() | SquareD1.ToBB() | SquareE1.ToBB()
+	if got := gs.Attackers(SquareD2); got != want {
+		t.Errorf("Attackers(d2) = %x, want %x", uint64(got), uint64(want))
+	}
+}
+
+func TestIsMoveCheck(t *testing.T) {
+	gs := newTestGame(t, "4k3/8/8/8/8/8/4N3/4R1K1 w - - 0 1")
+
+	tests := []struct {
+		uci  string
+		want bool
+	}{
+		{"e2c3", true},  // discovered check
+		{"e2d4", true},  // discovered check
+		{"g1h1", false}, // quiet
+		{"e1f1", false}, // the knight still blocks
+	}
+
+	for _, tt := range tests {
+		from, to, _, _ := ParseUCIMove(tt.uci)
+		move, err := gs.pos.createMove(from, to, 0)
+		if err != nil {
+			t.Fatalf("createMove(%s): %v", tt.uci, err)
+		}
+
+		if got := gs.IsMoveCheck(move); got != tt.want {
+			t.Errorf("IsMoveCheck(%s) = %v, want %v", tt.uci, got, tt.want)
+		}
+	}
+
+	if fen := gs.ToFEN(); fen != "4k3/8/8/8/8/8/4N3/4R1K1 w - - 0 1" {
+		t.Errorf("IsMoveCheck changed the position: %s", fen)
+	}
+}
@@ -0,0 +1,120 @@
+// Chess960 (Fischer Random Chess) start positions
+//
+// references:
+// - https://www.chessprogramming.org/Chess960
+// - https://en.wikipedia.org/wiki/Fischer_random_chess_numbering_scheme
+
+package chess_core
+
+import (
+	"math/rand/v2"
+	"strings"
+)
+
+// Chess960StandardIndex is the index of the standard chess start position
+const Chess960StandardIndex = 518
+
+// knight placements on the 5 empty squares by Scharnagl number
+var chess960Knights = [10][2]int{
+	{0, 1}, {0, 2}, {0, 3}, {0, 4}, {1, 2},
+	{1, 3}, {1, 4}, {2, 3}, {2, 4}, {3, 4},
+}
+
+// Chess960FEN returns the FEN of the Chess960 start position index (0-959) in Scharnagl numbering,
+// index 518 is the standard chess start position
+func Chess960FEN(index int) (string, error) {
+	if index < 0 || index >= 960 {
+		return "", ErrInvalidChess960Index
+	}
+
+	var rank [8]byte
+	n := index
+
+	// bishops on a light and a dark square
+	rank[2*(n%4)+1] = 'B'
+	n /= 4
+	rank[2*(n%4)] = 'B'
+	n /= 4
+
+	// queen on one of the 6 empty squares
+	placeOnEmpty(&rank, n%6, 'Q')
+	n /= 6
+
+	// knights on 2 of the 5 empty squares, the second index shifts after the first knight is placed
+	knights := chess960Knights[n]
+	placeOnEmpty(&rank, knights[0], 'N')
+	placeOnEmpty(&rank, knights[1]-1, 'N')
+
+	// rook, king and rook on the last 3 empty squares
+	placeOnEmpty(&rank, 0, 'R')
+	placeOnEmpty(&rank, 0, 'K')
+	placeOnEmpty(&rank, 0, 'R')
+
+	white := string(rank[:])
+	black := strings.ToLower(white)
+
+	return black + "/pppppppp/8/8/8/8/PPPPPPPP/" + white + " w KQkq - 0 1", nil
+}
+
+// placeOnEmpty places piece on the n-th empty square of rank
+func placeOnEmpty(rank *[8]byte, n int, piece byte) {
+	for i := range rank {
+		if rank[i] != 0 {
+			continue
+		}
+		if n == 0 {
+			rank[i] = piece
+			return
+		}
+		n--
+	}
+}
+
+// RandomChess960Index returns a random Chess960 start position index
+func RandomChess960Index() int {
+	return rand.IntN(960)
+}
+
+// NewChess960Game creates a Chess960 game from the start position index (0-959)
+func NewChess960Game(index int) (*GameState, error) {
+	fen, err := Chess960FEN(index)
+	if err != nil {
+		return nil, err
+	}
+
+	gs := NewGame()
+	if err := gs.FromFEN(fen); err != nil {
+		return nil, err
+	}
+
+	gs.SetChess960(true)
+
+	return gs, nil
+}
+
+// SetChess960 sets whether castling moves use the Chess960 notation (the king captures its own rook),
+// positions with non standard castling squares always use it. the positions of the game tree are updated too
+func (gs *GameState) SetChess960(enabled bool) {
+	gs.mx.Lock()
+	defer gs.mx.Unlock()
+
+	ci := gs.pos.castlingSquares()
+	if ci.king == standardCastling.king && ci.rook == standardCastling.rook {
+		gs.pos.castling = newCastlingInfo(ci.king, ci.rook, enabled)
+		gs.tree.root.setCastling(gs.pos.castling)
+	}
+}
+
+// setCastling sets the castling squares of the position of the node and of the positions after it
+func (n *TreeNode) setCastling(ci *castlingInfo) {
+	n.position.castling = ci
+	for _, child := range n.children {
+		child.setCastling(ci)
+	}
+}
+
+// IsChess960 reports whether the game uses Chess960 castling rules,
+// it is true for games created by NewChess960Game and for FEN strings with non standard castling squares
+func (gs *GameState) IsChess960() bool {
+	return gs.Position().IsChess960()
+}
@@ -0,0 +1,166 @@
+package chess_core
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestChess960FEN(t *testing.T) {
+	tests := []struct {
+		index int
+		want  string
+	}{
+		{0, "bbqnnrkr/pppppppp/8/8/8/8/PPPPPPPP/BBQNNRKR w KQkq - 0 1"},
+		{Chess960StandardIndex, StartingFEN},
+		{959, "rkrnnqbb/pppppppp/8/8/8/8/PPPPPPPP/RKRNNQBB w KQkq - 0 1"},
+	}
+
+	for _, tt := range tests {
+		got, err := Chess960FEN(tt.index)
+		if err != nil {
+			t.Fatalf("Chess960FEN(%d) error: %v", tt.index, err)
+		}
+		if got != tt.want {
+			t.Errorf("Chess960FEN(%d) = %q, want %q", tt.index, got, tt.want)
+		}
+	}
+
+	for _, index := range []int{-1, 960} {
+		if _, err := Chess960FEN(index); !errors.Is(err, ErrInvalidChess960Index) {
+			t.Errorf("Chess960FEN(%d) error = %v, want %v", index, err, ErrInvalidChess960Index)
+		}
+	}
+
+	// every start position is different and has the king between the rooks
+	seen := make(map[string]bool)
+	for index := range 960 {
+		fen, _ := Chess960FEN(index)
+		if seen[fen] {
+			t.Fatalf("Chess960FEN(%d) = %q is a duplicate", index, fen)
+		}
+		seen[fen] = true
+
+		gs := NewGame()
+		if err := gs.FromFEN(fen); err != nil {
+			t.Fatalf("FromFEN(%q) error: %v", fen, err)
+		}
+		if gs.pos.castlingRights != AllCastling {
+			t.Fatalf("FromFEN(%q) castling rights = %d, want %d", fen, gs.pos.castlingRights, AllCastling)
+		}
+	}
+}
+
+func TestChess960CastlingFEN(t *testing.T) {
+	tests := []struct {
+		fen  string
+		want string
+	}{
+		{"bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w HFhf - 2 9", "bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w KQkq - 2 9"},
+		{"rk2r3/8/8/8/8/8/8/RK2R2R w EAea - 0 1", "rk2r3/8/8/8/8/8/8/RK2R2R w EQkq - 0 1"},
+		{"rk2r3/8/8/8/8/8/8/RK2R2R w EQkq - 0 1", "rk2r3/8/8/8/8/8/8/RK2R2R w EQkq - 0 1"},
+		{StartingFEN, StartingFEN},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w HAha - 0 1", StartingFEN},
+	}
+
+	for _, tt := range tests {
+		gs := NewGame()
+		if err := gs.FromFEN(tt.fen); err != nil {
+			t.Fatalf("FromFEN(%q) error: %v", tt.fen, err)
+		}
+		if got := gs.ToFEN(); got != tt.want {
+			t.Errorf("ToFEN() = %q, want %q", got, tt.want)
+		}
+	}
+
+	for _, fen := range []string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkqK - 0 1", // duplicate right
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w B - 0 1",     // no rook on b1
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w X - 0 1",
+	} {
+		gs := NewGame()
+		if err := gs.FromFEN(fen); err == nil {
+			t.Errorf("FromFEN(%q) expected error", fen)
+		}
+	}
+}
+
+func TestChess960Castling(t *testing.T) {
+	gs := NewGame()
+	if err := gs.FromFEN("rk2r3/pppppppp/8/8/8/8/PPPPPPP1/RK2R2R w EQkq - 0 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// O-O with the e1 rook: the king goes to g1, the rook to f1
+	move, err := gs.ParseSAN("O-O")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := gs.MoveToUCI(move); got != "b1e1" {
+		t.Errorf("MoveToUCI(O-O) = %q, want %q", got, "b1e1")
+	}
+	if _, err := gs.MakeMoveUCI(White, "b1e1"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := gs.ToFEN(), "rk2r3/pppppppp/8/8/8/8/PPPPPPP1/R4RKR b kq - 1 1"; got != want {
+		t.Errorf("after O-O ToFEN() = %q, want %q", got, want)
+	}
+
+	// O-O-O next to the target square must be written as the king capturing its rook, b8c8 is a king move
+	if _, err := gs.MakeMoveUCI(Black, "b8a8"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := gs.ToFEN(), "2krr3/pppppppp/8/8/8/8/PPPPPPP1/R4RKR w - - 2 2"; got != want {
+		t.Errorf("after O-O-O ToFEN() = %q, want %q", got, want)
+	}
+
+	if err := gs.Undo(2); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := gs.ToFEN(), "rk2r3/pppppppp/8/8/8/8/PPPPPPP1/RK2R2R w EQkq - 0 1"; got != want {
+		t.Errorf("after Undo ToFEN() = %q, want %q", got, want)
+	}
+}
+
+func TestNewChess960Game(t *testing.T) {
+	gs, err := NewChess960Game(Chess960StandardIndex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !gs.IsChess960() {
+		t.Error("IsChess960() = false, want true")
+	}
+	if !gs.Tree().Root().Position().IsChess960() {
+		t.Error("IsChess960() of the tree start = false, want true")
+	}
+
+	move, err := gs.ParseSAN("Nf3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gs.PlayMove(move); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gs.PlayMove(move); !errors.Is(err, ErrMoveOutOfTurn) {
+		t.Errorf("PlayMove() error = %v, want %v", err, ErrMoveOutOfTurn)
+	}
+
+	// the tree replays the castling move written as the king capturing its rook
+	for _, uci := range []string{"b8c6", "g2g3", "g8f6", "f1g2", "e7e6", "e1h1"} {
+		if _, err := gs.MakeMoveUCI(gs.SideToMove(), uci); err != nil {
+			t.Fatalf("MakeMoveUCI(%s): %v", uci, err)
+		}
+	}
+	tree := gs.Tree()
+	pos := tree.Root().Position()
+	if !pos.IsChess960() {
+		t.Error("Tree().Root().Position().IsChess960() = false, want true")
+	}
+	for _, n := range tree.Mainline() {
+		if pos, err = pos.MakeMove(n.Move); err != nil {
+			t.Fatalf("replay of %s: %v", n.SAN, err)
+		}
+	}
+	if last := tree.Mainline()[6]; last.SAN != "O-O" || pos.FEN() != gs.ToFEN() {
+		t.Errorf("tree ends with %s in %q, want O-O in %q", last.SAN, pos.FEN(), gs.ToFEN())
+	}
+}
@@ -293,14 +293,14 @@ const AllCastling = WK | WQ | BK | BQ
 
 // castling rights update constants
 var CastlingRights = [64]int{
-	7, 15, 15, 15, 3, 15, 15, 11,
+	13, 15, 15, 15, 12, 15, 15, 14,
 	15, 15, 15, 15, 15, 15, 15, 15,
 	15, 15, 15, 15, 15, 15, 15, 15,
 	15, 15, 15, 15, 15, 15, 15, 15,
 	15, 15, 15, 15, 15, 15, 15, 15,
 	15, 15, 15, 15, 15, 15, 15, 15,
 	15, 15, 15, 15, 15, 15, 15, 15,
-	13, 15, 15, 15, 12, 15, 15, 14,
+	7, 15, 15, 15, 3, 15, 15, 11,
 }
 
 // board squares to coordinates
@@ -344,6 +344,10 @@ var PromotedPieces = map[Piece]byte{
 	WRook:   'r',
 	WBishop: 'b',
 	WKnight: 'n',
+
+	// antichess
+	BKing: 'k',
+	WKing: 'k',
 }
 
 var SquareToPosition = [64][2]int{
@@ -375,4 +379,10 @@ const (
 
 	ResultInsufficientMaterial GameStatus = "Result Insufficient Material"
 	ResultThreefoldRepetition  GameStatus = "Result Threefold Repetition"
+
+	// variant wins, see Variant
+	ResultKingOfTheHill GameStatus = "Result King Of The Hill"
+	ResultThreeCheck    GameStatus = "Result Three Check"
+	ResultKingExploded  GameStatus = "Result King Exploded"
+	ResultVariantWin    GameStatus = "Result Variant Win"
 )
@@ -0,0 +1,134 @@
+// Crazyhouse and Bughouse
+//
+// references:
+// - https://lichess.org/variant/crazyhouse
+// - https://en.wikipedia.org/wiki/Bughouse_chess
+// - https://github.com/lichess-org/scalachess/blob/master/core/src/main/scala/variant/Crazyhouse.scala
+
+package chess_core
+
+import "fmt"
+
+// dropStartingFEN is the start position of the drop variants with empty pockets
+const dropStartingFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR[] w KQkq - 0 1"
+
+// Crazyhouse is standard chess where the captured pieces go to the pocket of the capturing side,
+// a piece in hand can be dropped on an empty square instead of a move.
+// the pockets follow the piece placement of the FEN string, ex: "...RNBQKBNR[Qn] w ..."
+type Crazyhouse struct{}
+
+func (Crazyhouse) Name() string        { return "crazyhouse" }
+func (Crazyhouse) StartingFEN() string { return dropStartingFEN }
+
+func (Crazyhouse) Validate(b *Board) error {
+	return validateDropPosition(b)
+}
+
+func (Crazyhouse) GenerateMoves(b *Board, ml *MoveList) {
+	b.LegalMoves(ml)
+	generateDrops(b, ml)
+}
+
+func (Crazyhouse) MakeMove(b *Board, move Move) {
+	if captured := makePocketMove(b, move); captured != Empty {
+		b.Pockets[captured.Color().Opposite()][captured.Type()]++
+	}
+}
+
+// Result has no insufficient material draw, the captured pieces come back
+func (Crazyhouse) Result(b *Board) (GameStatus, Color) {
+	status, winner := mateResult(b)
+	if status == ResultOngoing || hasDrop(b) {
+		return ResultOngoing, None
+	}
+	return status, winner
+}
+
+// Bughouse is the rules of one board of a Bughouse game, it is Crazyhouse where the captured pieces
+// go to the partner on the other board, see CapturedPiece and AddToPocket
+type Bughouse struct{}
+
+func (Bughouse) Name() string        { return "bughouse" }
+func (Bughouse) StartingFEN() string { return dropStartingFEN }
+
+func (Bughouse) Validate(b *Board) error {
+	return validateDropPosition(b)
+}
+
+func (Bughouse) GenerateMoves(b *Board, ml *MoveList) {
+	b.LegalMoves(ml)
+	generateDrops(b, ml)
+}
+
+func (Bughouse) MakeMove(b *Board, move Move) {
+	makePocketMove(b, move)
+}
+
+// Result has no stalemate, a player without moves waits for the partner to hand over a piece.
+// it is only checkmate when no piece could be dropped between the king and the checker
+func (Bughouse) Result(b *Board) (GameStatus, Color) {
+	status, winner := mateResult(b)
+	if status != ResultCheckmate || dropTargets(b) != 0 {
+		return ResultOngoing, None
+	}
+	return status, winner
+}
+
+// makePocketMove makes the move and moves the promoted marks with the pieces,
+// it returns the captured piece as it goes to a pocket, promoted pieces are pawns
+func makePocketMove(b *Board, move Move) Piece {
+	from, to := Square(move.From()).ToBB(), Square(move.To()).ToBB()
+	promotedCapture := b.Promoted&to != 0
+
+	captured := b.MakeMove(move)
+	if move.IsDrop() {
+		return Empty
+	}
+
+	promoted := b.Promoted&from != 0 || move.IsPromotion()
+	b.Promoted &^= from | to
+	if promoted {
+		b.Promoted |= to
+	}
+
+	if captured != Empty && promotedCapture {
+		return getPieceBySide(Pawn, captured.Color())
+	}
+	return captured
+}
+
+// hasDrop reports whether the side to move can drop a piece
+func hasDrop(b *Board) bool {
+	ml := make(MoveList, 0, 64)
+	generateDrops(b, &ml)
+	return len(ml) > 0
+}
+
+// validateDropPosition is validatePosition without the material checks, the pieces in hand come back on the board
+func validateDropPosition(b *Board) error {
+	bb := b.BitBoards
+
+	for _, color := range [2]Color{White, Black} {
+		switch bb.PiecesByType(color, King).Count() {
+		case 0:
+			return fmt.Errorf("%w: %s", ErrNoKing, color)
+		case 1:
+		default:
+			return fmt.Errorf("%w: %s", ErrMultipleKings, color)
+		}
+	}
+
+	if (bb.WhitePawns|bb.BlackPawns)&(rankMask(0)|rankMask(7)) != 0 {
+		return ErrPawnOnFirstOrLast
+	}
+
+	if IsKingAttacked(b.SideToMove.Opposite(), bb) {
+		return fmt.Errorf("%w: %s", ErrOpponentInCheck, b.SideToMove.Opposite())
+	}
+
+	if err := validateCastling(bb, b.CastlingRights, b.castlingSquares()); err != nil {
+		return err
+	}
+
+	return validateEnPassant(bb, b.SideToMove, b.EnPassantSquare)
+}
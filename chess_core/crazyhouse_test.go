@@ -0,0 +1,120 @@
+package chess_core
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCrazyhousePerft(t *testing.T) {
+	gs, err := NewVariantGame(Crazyhouse{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first drop is at ply 5
+	for depth, want := range []uint64{20, 400, 8902, 197281, 4888832} {
+		if got := gs.Perft(depth + 1); got != want {
+			t.Errorf("Perft(%d) = %d, want %d", depth+1, got, want)
+		}
+	}
+}
+
+func TestCrazyhousePockets(t *testing.T) {
+	gs, _ := playVariant(t, Crazyhouse{}, dropStartingFEN, "e2e4", "d7d5", "e4d5", "d8d5", "P@e4")
+	if got, want := gs.ToFEN(), "rnb1kbnr/ppp1pppp/8/3q4/4P3/8/PPPP1PPP/RNBQKBNR[p] b KQkq - 0 3"; got != want {
+		t.Errorf("ToFEN() = %q, want %q", got, want)
+	}
+	if want := gs.pos.computeZobristHash(); gs.Hash() != want {
+		t.Errorf("Hash() = %x, want %x", gs.Hash(), want)
+	}
+
+	move, err := gs.ParseSAN("@e6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := gs.MoveToSAN(move); got != "P@e6" {
+		t.Errorf("MoveToSAN() = %q, want %q", got, "P@e6")
+	}
+	if _, err := gs.MakeDrop(Black, Knight, SquareE6); !errors.Is(err, ErrInvalidMove) {
+		t.Errorf("MakeDrop() of a piece not in the pocket error = %v, want %v", err, ErrInvalidMove)
+	}
+
+	if err := gs.Undo(3); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := gs.ToFEN(), "rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR[] w KQkq d6 0 2"; got != want {
+		t.Errorf("after Undo ToFEN() = %q, want %q", got, want)
+	}
+}
+
+func TestCrazyhousePromoted(t *testing.T) {
+	// the promoted queen goes to the pocket as a pawn, the other queen as a queen
+	gs, _ := playVariant(t, Crazyhouse{}, "3qk3/8/8/8/8/8/8/3Q~K3[] b - - 0 1", "d8d1", "e1d1")
+	if got, want := gs.ToFEN(), "4k3/8/8/8/8/8/8/3K4[Qp] b - - 0 2"; got != want {
+		t.Errorf("ToFEN() = %q, want %q", got, want)
+	}
+
+	gs, _ = playVariant(t, Crazyhouse{}, "4k3/1P6/8/8/8/8/8/4K3[] w - - 0 1", "b7b8q")
+	if got, want := gs.ToFEN(), "1Q~2k3/8/8/8/8/8/8/4K3[] b - - 0 1"; got != want {
+		t.Errorf("ToFEN() = %q, want %q", got, want)
+	}
+}
+
+func TestDropResults(t *testing.T) {
+	tests := []struct {
+		name    string
+		variant Variant
+		fen     string
+		drop    string
+		status  GameStatus
+	}{
+		{"crazyhouse mate", Crazyhouse{}, "7k/6pp/8/8/8/8/8/4K3[R] w - - 0 1", "R@a8", ResultCheckmate},
+		{"crazyhouse block", Crazyhouse{}, "7k/6pp/8/8/8/8/8/4K3[Rn] w - - 0 1", "R@a8", ResultOngoing},
+		{"bughouse wait", Bughouse{}, "7k/6pp/8/8/8/8/8/4K3[R] w - - 0 1", "R@a8", ResultOngoing},
+		{"bughouse smothered mate", Bughouse{}, "6rk/6pp/8/8/8/8/8/4K3[N] w - - 0 1", "N@f7", ResultCheckmate},
+	}
+
+	for _, tt := range tests {
+		if _, status := playVariant(t, tt.variant, tt.fen, tt.drop); status != tt.status {
+			t.Errorf("%s: status = %s, want %s", tt.name, status, tt.status)
+		}
+	}
+}
+
+func TestBughousePockets(t *testing.T) {
+	gs, _ := playVariant(t, Bughouse{}, "7k/6pp/8/8/8/8/8/4K3[R] w - - 0 1", "R@a8")
+	if moves := gs.LegalMoves(); len(moves) != 0 {
+		t.Fatalf("LegalMoves() = %v, want none while waiting for a piece", moves)
+	}
+
+	if err := gs.AddToPocket(BKnight); err != nil {
+		t.Fatal(err)
+	}
+	if moves := gs.LegalMoves(); len(moves) != 6 {
+		t.Errorf("LegalMoves() = %d moves, want 6 knight drops", len(moves))
+	}
+
+	// the captured piece goes to the partner, not to the pocket
+	gs, _ = playVariant(t, Bughouse{}, "4k3/8/8/8/8/8/3q4/3QK3[] w - - 0 1", "d1d2")
+	if got := gs.CapturedPiece(); got != BQueen {
+		t.Errorf("CapturedPiece() = %q, want %q", got, BQueen)
+	}
+
+	// the pieces handed over after the move are kept by Undo
+	if err := gs.AddToPocket(BKnight); err != nil {
+		t.Fatal(err)
+	}
+	if err := gs.Undo(1); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := gs.ToFEN(), "4k3/8/8/8/8/8/3q4/3QK3[n] w - - 0 1"; got != want {
+		t.Errorf("after Undo ToFEN() = %q, want %q", got, want)
+	}
+	if want := gs.pos.computeZobristHash(); gs.Hash() != want {
+		t.Errorf("after Undo Hash() = %x, want %x", gs.Hash(), want)
+	}
+
+	if err := NewGame().AddToPocket(WQueen); err == nil {
+		t.Error("AddToPocket() in a standard game expected error")
+	}
+}
@@ -0,0 +1,252 @@
+// engine - move search for chess_core games
+//
+// references:
+// - https://www.chessprogramming.org/Search
+// - https://www.chessprogramming.org/Time_Management
+
+package engine
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	chess "github.com/tommjj/chess_OG/chess_core"
+	"github.com/tommjj/chess_OG/chess_core/eval"
+)
+
+const (
+	MaxPly = 64 // maximum search depth in plies
+
+	MateScore = 32000              // score of a checkmate at the root, the score of a mate in n plies is MateScore - n
+	Infinity  = MateScore + 1      // bound of the search window
+	mateBound = MateScore - MaxPly // scores beyond it are mate scores
+
+	DefaultHashSize = 16 // default transposition table size in megabytes
+)
+
+// Limits are the limits of a search, a zero field means no limit.
+// a search without any limit runs until ctx is done or MaxPly is reached
+type Limits struct {
+	Depth    int           // maximum depth in plies
+	Nodes    uint64        // maximum searched nodes
+	MoveTime time.Duration // exact time to search
+
+	// clock of the side to move, used to budget the search time when MoveTime is 0
+	Time      time.Duration
+	Increment time.Duration
+	MovesToGo int // moves to the next time control, 0 if unknown
+
+	// moves searched at the root, all legal moves if empty
+	SearchMoves []chess.Move
+}
+
+// Info is the result of a completed iteration
+type Info struct {
+	Depth int
+	Score int // centipawns from the side to move point of view
+	Nodes uint64
+	Time  time.Duration
+	PV    []chess.Move
+}
+
+// Engine searches positions, it keeps its transposition table between searches.
+// an Engine must not run several searches at the same time
+type Engine struct {
+	// Info is called after every completed iteration, it may be nil
+	Info func(Info)
+
+	// Evaluator scores the leaf positions, nil uses the default weights
+	Evaluator *eval.Evaluator
+
+	tt *transpositionTable
+
+	// state of the current search
+	ctx      context.Context
+	pos      chess.Position
+	limits   Limits
+	start    time.Time
+	deadline time.Time
+	stopped  bool
+	nodes    uint64
+
+	hashes   []uint64 // hashes of the positions before the current one, for repetition detection
+	killers  [MaxPly + 1][2]chess.Move
+	history  [13][64]int // [piece][target square]
+	pv       [MaxPly + 1][MaxPly + 1]chess.Move
+	pvLength [MaxPly + 1]int
+}
+
+// New creates an engine with a transposition table of hashSize megabytes
+func New(hashSize int) *Engine {
+	return &Engine{
+		tt: newTranspositionTable(hashSize),
+	}
+}
+
+// Clear clears the transposition table, call it before searching a new game
+func (e *Engine) Clear() {
+	e.tt.clear()
+}
+
+// Search searches pos with a new engine, see Engine.Search
+func Search(ctx context.Context, pos *chess.GameState, limits Limits) (bestMove chess.Move, score int, pv []chess.Move) {
+	return New(DefaultHashSize).Search(ctx, pos, limits)
+}
+
+// Search runs an iterative deepening search on pos until a limit is reached or ctx is done.
+// pos is not modified.
+//
+//	returns the best move (0 if the game has ended), its score in centipawns from the side to move point of view and the principal variation
+func (e *Engine) Search(ctx context.Context, pos *chess.GameState, limits Limits) (bestMove chess.Move, score int, pv []chess.Move) {
+	e.prepare(ctx, pos, limits)
+
+	rootMoves := e.filterRootMoves(pos.LegalMoves())
+	if len(rootMoves) == 0 {
+		return 0, 0, nil
+	}
+	e.orderMoves(rootMoves, 0, 0)
+	bestMove = rootMoves[0]
+
+	maxDepth := limits.Depth
+	if maxDepth <= 0 || maxDepth > MaxPly {
+		maxDepth = MaxPly
+	}
+
+	for depth := 1; depth <= maxDepth; depth++ {
+		iterationScore := e.negamax(depth, 0, -Infinity, Infinity)
+
+		// an interrupted iteration is only used when there is no result yet
+		if e.stopped && (depth > 1 || e.pvLength[0] == 0) {
+			break
+		}
+
+		bestMove = e.pv[0][0]
+		score = iterationScore
+		pv = append(pv[:0], e.pv[0][:e.pvLength[0]]...)
+
+		if e.stopped {
+			break
+		}
+
+		if e.Info != nil {
+			e.Info(Info{
+				Depth: depth,
+				Score: score,
+				Nodes: e.nodes,
+				Time:  time.Since(e.start),
+				PV:    append([]chess.Move(nil), pv...),
+			})
+		}
+
+		// a single legal move or a found mate does not need a deeper search under a time budget
+		if !e.deadline.IsZero() && (len(rootMoves) == 1 || abs(score) > mateBound) {
+			break
+		}
+	}
+
+	return bestMove, score, pv
+}
+
+// filterRootMoves keeps the moves of limits.SearchMoves, all moves if it is empty
+func (e *Engine) filterRootMoves(moves chess.MoveList) chess.MoveList {
+	if len(e.limits.SearchMoves) == 0 {
+		return moves
+	}
+	return slices.DeleteFunc(moves, func(m chess.Move) bool {
+		return !slices.Contains(e.limits.SearchMoves, m)
+	})
+}
+
+// prepare resets the search state
+func (e *Engine) prepare(ctx context.Context, pos *chess.GameState, limits Limits) {
+	if e.tt == nil {
+		e.tt = newTranspositionTable(DefaultHashSize)
+	}
+
+	e.ctx = ctx
+	e.limits = limits
+	e.start = time.Now()
+	e.deadline = time.Time{}
+	if budget := searchTime(limits); budget > 0 {
+		e.deadline = e.start.Add(budget)
+	}
+	e.stopped = false
+	e.nodes = 0
+
+	history := pos.History()
+	e.pos = pos.Position()
+
+	e.hashes = e.hashes[:0]
+	for _, h := range history {
+		e.hashes = append(e.hashes, h.Hash)
+	}
+
+	e.killers = [MaxPly + 1][2]chess.Move{}
+	e.history = [13][64]int{}
+	e.pvLength = [MaxPly + 1]int{}
+}
+
+// searchTime returns the time budget of a search, 0 if the time is not limited
+func searchTime(limits Limits) time.Duration {
+	if limits.MoveTime > 0 {
+		return limits.MoveTime
+	}
+	if limits.Time <= 0 {
+		return 0
+	}
+
+	movesToGo := limits.MovesToGo
+	if movesToGo <= 0 {
+		movesToGo = 30
+	}
+
+	const overhead = 50 * time.Millisecond
+
+	budget := limits.Time/time.Duration(movesToGo) + limits.Increment*3/4
+	budget = min(budget, limits.Time-overhead)
+
+	return max(budget, 10*time.Millisecond)
+}
+
+// checkStop checks the limits and ctx, it sets the stopped flag when the search must stop
+func (e *Engine) checkStop() bool {
+	if e.stopped {
+		return true
+	}
+
+	if e.limits.Nodes > 0 && e.nodes >= e.limits.Nodes {
+		e.stopped = true
+	} else if e.nodes&2047 == 0 {
+		select {
+		case <-e.ctx.Done():
+			e.stopped = true
+		default:
+			if !e.deadline.IsZero() && time.Now().After(e.deadline) {
+				e.stopped = true
+			}
+		}
+	}
+
+	return e.stopped
+}
+
+// MateDistance returns the number of moves to the mate of a mate score,
+// it is negative when the side to move is mated
+func MateDistance(score int) (moves int, ok bool) {
+	switch {
+	case score > mateBound:
+		return (MateScore - score + 1) / 2, true
+	case score < -mateBound:
+		return -(MateScore + score) / 2, true
+	default:
+		return 0, false
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
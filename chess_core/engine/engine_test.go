@@ -0,0 +1,139 @@
+package engine
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	chess "github.com/tommjj/chess_OG/chess_core"
+)
+
+func TestSearch(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		want string
+	}{
+		{"back rank mate", "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", "a1a8"},
+		{"scholar's mate", "r1bqkb1r/pppp1ppp/2n2n2/4p2Q/2B1P3/8/PPPP1PPP/RNB1K1NR w KQkq - 4 4", "h5f7"},
+		{"hanging queen", "4k3/8/8/3q4/8/8/3R4/4K3 w - - 0 1", "d2d5"},
+		{"avoid losing the queen", "4k3/8/8/8/3Q4/2p5/8/4K3 w - - 0 1", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pos := chess.NewGame()
+			if err := pos.FromFEN(tt.fen); err != nil {
+				t.Fatal(err)
+			}
+			before := pos.ToFEN()
+
+			move, score, pv := Search(context.Background(), pos, Limits{Depth: 4})
+			if move == 0 || len(pv) == 0 || pv[0] != move {
+				t.Fatalf("Search() = %v, pv %v", move, pv)
+			}
+			if tt.want != "" && move.UCI() != tt.want {
+				t.Errorf("Search() = %s (score %d), want %s", move.UCI(), score, tt.want)
+			}
+			if score < 0 {
+				t.Errorf("Search() score = %d, want >= 0", score)
+			}
+			if got := pos.ToFEN(); got != before {
+				t.Errorf("Search() modified the position: %q, want %q", got, before)
+			}
+		})
+	}
+}
+
+func TestSearchMateScore(t *testing.T) {
+	pos := chess.NewGame()
+	if err := pos.FromFEN("6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, score, _ := Search(context.Background(), pos, Limits{Depth: 3})
+	if score != MateScore-1 {
+		t.Errorf("Search() score = %d, want %d", score, MateScore-1)
+	}
+	if moves, ok := MateDistance(score); !ok || moves != 1 {
+		t.Errorf("MateDistance(%d) = %d, %v, want 1, true", score, moves, ok)
+	}
+
+	// the side to move is mated in 1 after any move
+	pos = chess.NewGame()
+	if err := pos.FromFEN("k7/8/1K6/8/8/8/8/7R b - - 0 1"); err != nil {
+		t.Fatal(err)
+	}
+	_, score, _ = Search(context.Background(), pos, Limits{Depth: 3})
+	if moves, ok := MateDistance(score); !ok || moves != -1 {
+		t.Errorf("MateDistance(%d) = %d, %v, want -1, true", score, moves, ok)
+	}
+}
+
+func TestSearchLimits(t *testing.T) {
+	pos := chess.NewGame()
+	if err := pos.FromFEN(chess.StartingFEN); err != nil {
+		t.Fatal(err)
+	}
+
+	e := New(1)
+	var infos []Info
+	e.Info = func(info Info) { infos = append(infos, info) }
+
+	if move, _, _ := e.Search(context.Background(), pos, Limits{Depth: 3}); move == 0 {
+		t.Fatal("Search() returned no move")
+	}
+	if len(infos) != 3 || infos[2].Depth != 3 {
+		t.Errorf("Info called %d times, want 3", len(infos))
+	}
+
+	e.Info = nil
+	e.Search(context.Background(), pos, Limits{Nodes: 5000})
+	if e.nodes > 5000 {
+		t.Errorf("searched %d nodes, want at most %d", e.nodes, 5000)
+	}
+
+	start := time.Now()
+	if move, _, _ := e.Search(context.Background(), pos, Limits{MoveTime: 100 * time.Millisecond}); move == 0 {
+		t.Fatal("Search() returned no move")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Search() took %s with a 100ms move time", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	move, _, _ := e.Search(ctx, pos, Limits{})
+	if !slices.Contains(pos.LegalMoves(), move) {
+		t.Errorf("Search() with a done context = %v, want a legal move", move)
+	}
+}
+
+func TestSearchGameEnded(t *testing.T) {
+	pos := chess.NewGame()
+	if err := pos.FromFEN("rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3"); err != nil {
+		t.Fatal(err)
+	}
+
+	if move, _, pv := Search(context.Background(), pos, Limits{Depth: 2}); move != 0 || pv != nil {
+		t.Errorf("Search() in a checkmate position = %v, %v, want no move", move, pv)
+	}
+}
+
+func TestSearchMoves(t *testing.T) {
+	pos := chess.NewGame()
+	if err := pos.FromFEN("6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	rook, _ := pos.ParseSAN("Ra2")
+	king, _ := pos.ParseSAN("Kf1")
+	move, score, pv := Search(context.Background(), pos, Limits{Depth: 3, SearchMoves: []chess.Move{rook, king}})
+	if move != rook && move != king || pv[0] != move {
+		t.Errorf("Search() with search moves = %v, want one of %v", move, []chess.Move{rook, king})
+	}
+	if score > mateBound {
+		t.Errorf("Search() with search moves score = %d, want no mate", score)
+	}
+}
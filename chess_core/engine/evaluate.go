@@ -0,0 +1,21 @@
+package engine
+
+import (
+	chess "github.com/tommjj/chess_OG/chess_core"
+	"github.com/tommjj/chess_OG/chess_core/eval"
+)
+
+// evaluate returns the static evaluation of the current position from the side to move point of view
+func (e *Engine) evaluate() int {
+	evaluator := e.Evaluator
+	if evaluator == nil {
+		evaluator = eval.Default()
+	}
+
+	bb := e.pos.BitBoards()
+	score := evaluator.Evaluate(&bb)
+	if e.pos.SideToMove() == chess.Black {
+		return -score
+	}
+	return score
+}
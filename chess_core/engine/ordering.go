@@ -0,0 +1,78 @@
+// move ordering
+//
+// references:
+// - https://www.chessprogramming.org/Move_Ordering
+// - https://www.chessprogramming.org/MVV-LVA
+// - https://www.chessprogramming.org/Killer_Heuristic
+// - https://www.chessprogramming.org/History_Heuristic
+
+package engine
+
+import (
+	"slices"
+
+	chess "github.com/tommjj/chess_OG/chess_core"
+)
+
+// piece values by piece type for move ordering
+var orderValues = [7]int{0, 100, 320, 330, 500, 900, 2000}
+
+const (
+	ttMoveScore  = 1 << 30
+	captureScore = 1 << 24
+	killerScore  = 1 << 20
+)
+
+type scoredMove struct {
+	move  chess.Move
+	score int
+}
+
+// orderMoves sorts moves: transposition table move, captures and promotions by MVV-LVA, killers, then quiet moves by history
+func (e *Engine) orderMoves(moves chess.MoveList, ttMove chess.Move, ply int) {
+	scored := make([]scoredMove, len(moves))
+	for i, move := range moves {
+		scored[i] = scoredMove{move, e.moveScore(move, ttMove, ply)}
+	}
+
+	slices.SortStableFunc(scored, func(a, b scoredMove) int {
+		return b.score - a.score
+	})
+
+	for i := range scored {
+		moves[i] = scored[i].move
+	}
+}
+
+func (e *Engine) moveScore(move, ttMove chess.Move, ply int) int {
+	if move == ttMove {
+		return ttMoveScore
+	}
+
+	attacker := chess.ASCIIPieces[move.Piece()].Type()
+
+	switch {
+	case move.IsCapture():
+		victim := chess.Pawn
+		if !move.IsEnPassant() {
+			victim = e.pos.PieceAt(chess.Square(move.To())).Type()
+		}
+		return captureScore + orderValues[victim]*10 - orderValues[attacker] + orderValues[move.Promoted()]
+	case move.IsPromotion():
+		return captureScore + orderValues[move.Promoted()]
+	case move == e.killers[ply][0]:
+		return killerScore
+	case move == e.killers[ply][1]:
+		return killerScore - 1
+	default:
+		return e.history[move.Piece()][move.To()]
+	}
+}
+
+// storeKiller stores a quiet move that caused a beta cutoff at ply
+func (e *Engine) storeKiller(ply int, move chess.Move) {
+	if e.killers[ply][0] != move {
+		e.killers[ply][1] = e.killers[ply][0]
+		e.killers[ply][0] = move
+	}
+}
@@ -0,0 +1,253 @@
+// search - alpha-beta search
+//
+// references:
+// - https://www.chessprogramming.org/Iterative_Deepening
+// - https://www.chessprogramming.org/Principal_Variation_Search
+// - https://www.chessprogramming.org/Quiescence_Search
+// - https://www.chessprogramming.org/Triangular_PV-Table
+// - https://www.chessprogramming.org/Check_Extensions
+
+package engine
+
+import (
+	chess "github.com/tommjj/chess_OG/chess_core"
+)
+
+// negamax searches the current position with a principal variation search
+func (e *Engine) negamax(depth, ply, alpha, beta int) int {
+	e.pvLength[ply] = ply
+
+	if e.checkStop() {
+		return 0
+	}
+
+	pos := e.pos
+	inCheck := pos.InCheck()
+
+	if ply > 0 {
+		if e.isDraw() {
+			return 0
+		}
+		if ply >= MaxPly {
+			return e.evaluate()
+		}
+	}
+
+	if inCheck {
+		depth++
+	}
+	if depth <= 0 {
+		return e.quiescence(ply, alpha, beta)
+	}
+
+	e.nodes++
+
+	hash := pos.Hash()
+	var ttMove chess.Move
+	if entry, ok := e.tt.probe(hash); ok {
+		ttMove = entry.move
+		if ply > 0 && int(entry.depth) >= depth {
+			score := scoreFromTT(int(entry.score), ply)
+			switch {
+			case entry.flag == ttExact,
+				entry.flag == ttLower && score >= beta,
+				entry.flag == ttUpper && score <= alpha:
+				return score
+			}
+		}
+	}
+
+	moves := pos.LegalMoves()
+	if len(moves) == 0 {
+		if inCheck {
+			return -MateScore + ply
+		}
+		return 0 // stalemate
+	}
+	if ply == 0 {
+		moves = e.filterRootMoves(moves)
+	}
+	e.orderMoves(moves, ttMove, ply)
+
+	bestScore := -Infinity
+	bestMove := moves[0]
+	flag := ttUpper
+
+	for i, move := range moves {
+		e.makeMove(move)
+
+		var score int
+		if i == 0 {
+			score = -e.negamax(depth-1, ply+1, -beta, -alpha)
+		} else {
+			// null window search, re-search when the move may be better
+			score = -e.negamax(depth-1, ply+1, -alpha-1, -alpha)
+			if score > alpha && score < beta {
+				score = -e.negamax(depth-1, ply+1, -beta, -alpha)
+			}
+		}
+
+		e.unmakeMove(pos)
+
+		if e.stopped {
+			return 0
+		}
+
+		if score <= bestScore {
+			continue
+		}
+		bestScore = score
+		bestMove = move
+
+		if score <= alpha {
+			continue
+		}
+		alpha = score
+		flag = ttExact
+		e.updatePV(ply, move)
+
+		if score >= beta {
+			flag = ttLower
+			if !move.IsCapture() && !move.IsPromotion() {
+				e.storeKiller(ply, move)
+				e.history[move.Piece()][move.To()] += depth * depth
+			}
+			break
+		}
+	}
+
+	e.tt.store(hash, bestMove, scoreToTT(bestScore, ply), depth, flag)
+
+	return bestScore
+}
+
+// quiescence searches captures and promotions until the position is quiet,
+// all moves are searched when the side to move is in check
+func (e *Engine) quiescence(ply, alpha, beta int) int {
+	e.pvLength[ply] = ply
+
+	if e.checkStop() {
+		return 0
+	}
+	e.nodes++
+
+	pos := e.pos
+	if ply >= MaxPly {
+		return e.evaluate()
+	}
+
+	inCheck := pos.InCheck()
+
+	bestScore := -Infinity
+	if !inCheck {
+		bestScore = e.evaluate()
+		if bestScore >= beta {
+			return bestScore
+		}
+		alpha = max(alpha, bestScore)
+	}
+
+	moves := pos.LegalMoves()
+	if len(moves) == 0 {
+		if inCheck {
+			return -MateScore + ply
+		}
+		return 0 // stalemate
+	}
+
+	// captures losing material are skipped
+	if !inCheck {
+		n := 0
+		for _, move := range moves {
+			if move.IsPromotion() || move.IsCapture() && pos.SEE(move) >= 0 {
+				moves[n] = move
+				n++
+			}
+		}
+		moves = moves[:n]
+	}
+	e.orderMoves(moves, 0, ply)
+
+	for _, move := range moves {
+		e.makeMove(move)
+		score := -e.quiescence(ply+1, -beta, -alpha)
+		e.unmakeMove(pos)
+
+		if e.stopped {
+			return 0
+		}
+
+		if score > bestScore {
+			bestScore = score
+			if score > alpha {
+				alpha = score
+				e.updatePV(ply, move)
+				if score >= beta {
+					break
+				}
+			}
+		}
+	}
+
+	return bestScore
+}
+
+// makeMove moves to the position after a legal move
+func (e *Engine) makeMove(move chess.Move) {
+	e.hashes = append(e.hashes, e.pos.Hash())
+	e.pos, _ = e.pos.MakeUnsafeMove(move)
+}
+
+// unmakeMove goes back to the position before the last move
+func (e *Engine) unmakeMove(prev chess.Position) {
+	e.hashes = e.hashes[:len(e.hashes)-1]
+	e.pos = prev
+}
+
+// isDraw reports a draw by the fifty-move rule or a repetition of a position since the last irreversible move
+func (e *Engine) isDraw() bool {
+	pos := e.pos
+	if pos.HalfmoveClock() >= 100 {
+		return true
+	}
+
+	hash := pos.Hash()
+	limit := max(len(e.hashes)-pos.HalfmoveClock(), 0)
+	for i := len(e.hashes) - 2; i >= limit; i -= 2 {
+		if e.hashes[i] == hash {
+			return true
+		}
+	}
+	return false
+}
+
+// updatePV sets the principal variation of ply to move followed by the principal variation of the next ply
+func (e *Engine) updatePV(ply int, move chess.Move) {
+	e.pv[ply][ply] = move
+	copy(e.pv[ply][ply+1:], e.pv[ply+1][ply+1:e.pvLength[ply+1]])
+	e.pvLength[ply] = max(e.pvLength[ply+1], ply+1)
+}
+
+// scoreToTT converts a mate score relative to the root to a score relative to the node
+func scoreToTT(score, ply int) int {
+	switch {
+	case score > mateBound:
+		return score + ply
+	case score < -mateBound:
+		return score - ply
+	default:
+		return score
+	}
+}
+
+// scoreFromTT converts a mate score relative to the node to a score relative to the root
+func scoreFromTT(score, ply int) int {
+	switch {
+	case score > mateBound:
+		return score - ply
+	case score < -mateBound:
+		return score + ply
+	default:
+		return score
+	}
+}
@@ -0,0 +1,69 @@
+// transposition table
+//
+// references:
+// - https://www.chessprogramming.org/Transposition_Table
+
+package engine
+
+import (
+	"unsafe"
+
+	chess "github.com/tommjj/chess_OG/chess_core"
+)
+
+type ttFlag uint8
+
+const (
+	ttExact ttFlag = iota // exact score
+	ttLower               // the score is a lower bound (fail high)
+	ttUpper               // the score is an upper bound (fail low)
+)
+
+type ttEntry struct {
+	key   uint64
+	move  chess.Move
+	score int32
+	depth int16
+	flag  ttFlag
+}
+
+// transpositionTable is a hash table of search results keyed by the zobrist hash,
+// entries are always replaced
+type transpositionTable struct {
+	entries []ttEntry
+	mask    uint64
+}
+
+// newTranspositionTable creates a table of at most sizeMB megabytes, the entry count is a power of two
+func newTranspositionTable(sizeMB int) *transpositionTable {
+	size := uint64(max(sizeMB, 1)) << 20 / uint64(unsafe.Sizeof(ttEntry{}))
+
+	n := uint64(1)
+	for n*2 <= size {
+		n *= 2
+	}
+
+	return &transpositionTable{
+		entries: make([]ttEntry, n),
+		mask:    n - 1,
+	}
+}
+
+func (tt *transpositionTable) probe(key uint64) (ttEntry, bool) {
+	entry := tt.entries[key&tt.mask]
+	return entry, entry.key == key && entry.move != 0
+}
+
+func (tt *transpositionTable) store(key uint64, move chess.Move, score, depth int, flag ttFlag) {
+	tt.entries[key&tt.mask] = ttEntry{
+		key:   key,
+		move:  move,
+		score: int32(score),
+		depth: int16(depth),
+		flag:  flag,
+	}
+}
+
+func (tt *transpositionTable) clear() {
+	clear(tt.entries)
+}
@@ -0,0 +1,257 @@
+// Package epd reads and writes chess positions in Extended Position Description (EPD),
+// the format of the test suites like WAC and STS
+//
+// references:
+// - https://www.chessprogramming.org/Extended_Position_Description
+// - http://www.saremba.de/chessgml/standards/pgn/pgn-complete.htm#c16.2
+
+package epd
+
+import (
+	"bufio"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	chess "github.com/tommjj/chess_OG/chess_core"
+)
+
+var (
+	ErrInvalidEPD       = errors.New("invalid EPD")
+	ErrInvalidOperation = errors.New("invalid EPD operation")
+	ErrIllegalMove      = errors.New("illegal move in EPD operation")
+)
+
+// opcodes
+const (
+	BestMove  = "bm"
+	AvoidMove = "am"
+	ID        = "id"
+	Comment   = "c0"
+	Halfmove  = "hmvc"
+	Fullmove  = "fmvn"
+)
+
+// Operation is an EPD operation, ex: bm Nf3 Qd4;
+type Operation struct {
+	Opcode   string
+	Operands []string
+}
+
+// Record is a position of an EPD file with its operations
+type Record struct {
+	Position chess.Position
+
+	// operations in their order
+	Operations []Operation
+
+	// moves of the bm and am operations
+	BestMoves  []chess.Move
+	AvoidMoves []chess.Move
+}
+
+// Parse reads all records of an EPD file, one per line. empty lines and lines starting with '%' or '#' are skipped
+func Parse(r io.Reader) ([]*Record, error) {
+	var records []*Record
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || text[0] == '%' || text[0] == '#' {
+			continue
+		}
+
+		rec, err := ParseLine(text)
+		if err != nil {
+			return records, fmt.Errorf("line %d: %w", line, err)
+		}
+		records = append(records, rec)
+	}
+
+	return records, scanner.Err()
+}
+
+// ParseString is like Parse but reads from a string
+func ParseString(s string) ([]*Record, error) {
+	return Parse(strings.NewReader(s))
+}
+
+// ParseLine parses a record, ex: `r1b1k2r/ppppnppp/2n2q2/2b5/3NP3/2P1B3/PP3PPP/RN1QKB1R w KQkq - bm Nxc6; id "WAC.x";`
+func ParseLine(line string) (*Record, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 4 {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidEPD, line)
+	}
+
+	// the operations start after the fourth field
+	rest := strings.TrimSpace(line)
+	for range 4 {
+		if i := strings.IndexAny(rest, " \t"); i >= 0 {
+			rest = strings.TrimLeft(rest[i:], " \t")
+		} else {
+			rest = ""
+		}
+	}
+
+	ops, err := parseOperations(rest)
+	if err != nil {
+		return nil, err
+	}
+	rec := &Record{Operations: ops}
+
+	// the clocks of the hmvc and fmvn operations, the FEN clocks are optional
+	fen := strings.Join(fields[:4], " ")
+	if halfmove, fullmove := rec.first(Halfmove), rec.first(Fullmove); halfmove != "" || fullmove != "" {
+		fen += " " + cmp.Or(halfmove, "0") + " " + cmp.Or(fullmove, "1")
+	}
+
+	if rec.Position, err = chess.ParsePosition(fen); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidEPD, err)
+	}
+
+	if rec.BestMoves, err = rec.moves(BestMove); err != nil {
+		return nil, err
+	}
+	if rec.AvoidMoves, err = rec.moves(AvoidMove); err != nil {
+		return nil, err
+	}
+
+	return rec, nil
+}
+
+// parseOperations parses the operations, every operation ends with a semicolon.
+// operands in double quotes may hold spaces and semicolons
+func parseOperations(s string) ([]Operation, error) {
+	var ops []Operation
+
+	var op *Operation
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == ' ' || c == '\t':
+			i++
+
+		case c == ';':
+			if op == nil {
+				return nil, fmt.Errorf("%w: empty operation", ErrInvalidOperation)
+			}
+			ops = append(ops, *op)
+			op = nil
+			i++
+
+		case c == '"':
+			end := strings.IndexByte(s[i+1:], '"')
+			if end < 0 || op == nil {
+				return nil, fmt.Errorf("%w: %s", ErrInvalidOperation, s[i:])
+			}
+			op.Operands = append(op.Operands, s[i+1:i+1+end])
+			i += end + 2
+
+		default:
+			end := strings.IndexAny(s[i:], " \t;")
+			if end < 0 {
+				end = len(s) - i
+			}
+			token := s[i : i+end]
+			i += end
+
+			if op == nil {
+				op = &Operation{Opcode: token}
+			} else {
+				op.Operands = append(op.Operands, token)
+			}
+		}
+	}
+
+	if op != nil {
+		return nil, fmt.Errorf("%w: %q without semicolon", ErrInvalidOperation, op.Opcode)
+	}
+	return ops, nil
+}
+
+// moves returns the moves of the SAN operands of the opcode
+func (r *Record) moves(opcode string) ([]chess.Move, error) {
+	sans, _ := r.Get(opcode)
+
+	moves := make([]chess.Move, 0, len(sans))
+	for _, san := range sans {
+		move, err := r.Position.ParseSAN(san)
+		if err != nil {
+			return nil, fmt.Errorf("%w %s %q: %w", ErrIllegalMove, opcode, san, err)
+		}
+		moves = append(moves, move)
+	}
+	return moves, nil
+}
+
+// Get returns the operands of the first operation with the opcode
+func (r *Record) Get(opcode string) ([]string, bool) {
+	for _, op := range r.Operations {
+		if op.Opcode == opcode {
+			return op.Operands, true
+		}
+	}
+	return nil, false
+}
+
+// ID returns the id operand, "" if none
+func (r *Record) ID() string {
+	return r.first(ID)
+}
+
+// Comment returns the c0 operand, "" if none
+func (r *Record) Comment() string {
+	return r.first(Comment)
+}
+
+func (r *Record) first(opcode string) string {
+	if operands, ok := r.Get(opcode); ok && len(operands) > 0 {
+		return operands[0]
+	}
+	return ""
+}
+
+// IsSolved reports whether the move is one of the best moves and none of the moves to avoid
+func (r *Record) IsSolved(move chess.Move) bool {
+	if len(r.BestMoves) > 0 && !slices.Contains(r.BestMoves, move) {
+		return false
+	}
+	return !slices.Contains(r.AvoidMoves, move)
+}
+
+// GameState returns a game from the position of the record
+func (r *Record) GameState() (*chess.GameState, error) {
+	gs := chess.NewGame()
+	if err := gs.FromFEN(r.Position.FEN()); err != nil {
+		return nil, err
+	}
+	return gs, nil
+}
+
+// String returns the record in EPD, the position is written without clocks
+func (r *Record) String() string {
+	var sb strings.Builder
+
+	fields := strings.Fields(r.Position.FEN())
+	sb.WriteString(strings.Join(fields[:4], " "))
+
+	for _, op := range r.Operations {
+		sb.WriteString(" " + op.Opcode)
+		for _, operand := range op.Operands {
+			if quoted(op.Opcode) || strings.ContainsAny(operand, " \t;") || operand == "" {
+				operand = `"` + operand + `"`
+			}
+			sb.WriteString(" " + operand)
+		}
+		sb.WriteByte(';')
+	}
+
+	return sb.String()
+}
+
+// quoted reports whether the operands of the opcode are strings, ex: id, c0...c9
+func quoted(opcode string) bool {
+	return opcode == ID || len(opcode) == 2 && opcode[0] == 'c' && opcode[1] >= '0' && opcode[1] <= '9'
+}
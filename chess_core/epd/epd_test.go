@@ -0,0 +1,72 @@
+package epd
+
+import (
+	"errors"
+	"testing"
+)
+
+const wac = `% Win At Chess, first positions
+2rr3k/pp3pp1/1nnqbN1p/3pN3/2pP4/2P3Q1/PPB4P/R4RK1 w - - bm Qg6; id "WAC.001";
+8/7p/5k2/5p2/p1p2P2/Pr1pPK2/1P1R3P/8 b - - bm Rxb2; id "WAC.002"; c0 "Black wins; the pawns fall";
+
+5rk1/1ppb3p/p1pb4/6q1/3P1p1r/2P1R2P/PP1BQ1P1/5RKB w - - am Qh5; hmvc 3; fmvn 27; id "WAC.x";
+`
+
+func TestParse(t *testing.T) {
+	records, err := ParseString(wac)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("Parse() = %d records, want 3", len(records))
+	}
+
+	first := records[0]
+	if first.ID() != "WAC.001" || len(first.BestMoves) != 1 || first.BestMoves[0].UCI() != "g3g6" {
+		t.Errorf("record 1 = %q with best moves %v", first.ID(), first.BestMoves)
+	}
+	if !first.IsSolved(first.BestMoves[0]) {
+		t.Errorf("IsSolved(%v) = false, want true", first.BestMoves[0])
+	}
+
+	second := records[1]
+	if got, want := second.Comment(), "Black wins; the pawns fall"; got != want {
+		t.Errorf("Comment() = %q, want %q", got, want)
+	}
+
+	third := records[2]
+	if third.Position.HalfmoveClock() != 3 || third.Position.FullmoveNumber() != 27 {
+		t.Errorf("clocks = %d %d, want 3 27", third.Position.HalfmoveClock(), third.Position.FullmoveNumber())
+	}
+	if len(third.AvoidMoves) != 1 || third.IsSolved(third.AvoidMoves[0]) {
+		t.Errorf("IsSolved(%v) of an avoid move = true, want false", third.AvoidMoves)
+	}
+
+	for _, rec := range records {
+		again, err := ParseLine(rec.String())
+		if err != nil {
+			t.Fatalf("ParseLine(%q): %v", rec.String(), err)
+		}
+		if again.String() != rec.String() || again.Position.FEN() != rec.Position.FEN() {
+			t.Errorf("ParseLine(String()) = %q, want %q", again.String(), rec.String())
+		}
+	}
+}
+
+func TestParseLineErrors(t *testing.T) {
+	tests := []struct {
+		line string
+		want error
+	}{
+		{"8/8/8/8 w", ErrInvalidEPD},
+		{"4k3/8/8/8/8/8/8/4K3 w - - bm Ke2", ErrInvalidOperation},
+		{`4k3/8/8/8/8/8/8/4K3 w - - id "WAC;`, ErrInvalidOperation},
+		{"4k3/8/8/8/8/8/8/4K3 w - - bm Ke3;", ErrIllegalMove},
+	}
+
+	for _, tt := range tests {
+		if _, err := ParseLine(tt.line); !errors.Is(err, tt.want) {
+			t.Errorf("ParseLine(%q) error = %v, want %v", tt.line, err, tt.want)
+		}
+	}
+}
@@ -19,6 +19,8 @@ var (
 	ErrTooManyPieces     = errors.New("too many pieces on the board")
 	ErrNegativeHalfmove  = errors.New("negative halfmove clock")
 	ErrNegativeFullmove  = errors.New("negative fullmove number")
+	ErrOpponentInCheck   = errors.New("side not to move is in check")
+	ErrImpossibleCheck   = errors.New("impossible check")
 
 	ErrNoMovesAvailable = errors.New("no moves available")
 
@@ -30,8 +32,22 @@ var (
 	ErrMoveOutOfTurn    = fmt.Errorf("%w: move out of turn", ErrInvalidMove)
 
 	ErrInvalidUndoMoves = errors.New("invalid undo moves")
+
+	ErrInvalidSAN   = errors.New("invalid SAN string")
+	ErrAmbiguousSAN = fmt.Errorf("%w: ambiguous move", ErrInvalidSAN)
+
+	ErrInvalidUCIMove = errors.New("invalid UCI move string")
+
+	ErrInvalidChess960Index = errors.New("invalid Chess960 start position index")
+
+	ErrUnknownVariant         = errors.New("unknown chess variant")
+	ErrInvalidVariantPosition = errors.New("invalid position for the variant")
+
+	ErrInvalidEncoding = errors.New("invalid binary encoding")
+
+	ErrNodeNotInTree = errors.New("node of another game tree")
 )
 
 func wrapError(err error, message string) error {
-	return errors.New(message + ": " + err.Error())
+	return fmt.Errorf("%s: %w", message, err)
 }
@@ -0,0 +1,234 @@
+// eval - static evaluation of chess_core positions
+//
+// references:
+// - https://www.chessprogramming.org/Evaluation
+// - https://www.chessprogramming.org/Tapered_Eval
+// - https://www.chessprogramming.org/Simplified_Evaluation_Function
+// - https://www.chessprogramming.org/PeSTO%27s_Evaluation_Function
+// - https://www.chessprogramming.org/Pawn_Structure
+// - https://www.chessprogramming.org/Mobility
+// - https://www.chessprogramming.org/King_Safety
+
+package eval
+
+import (
+	chess "github.com/tommjj/chess_OG/chess_core"
+)
+
+// Evaluator scores positions with a set of weights, it is safe for concurrent use
+type Evaluator struct {
+	weights *Weights
+
+	pieces   [7]*PieceWeights      // by piece type
+	pst      [2][7][64]Score       // material and piece-square value [side][piece type][square]
+	maxPhase int                   // phase of the start position
+	passed   [2][64]chess.BitBoard // squares that must be free of enemy pawns for a passed pawn [side][square]
+	shield   [2][64]chess.BitBoard // pawn shield squares in front of the king [side][square]
+}
+
+var defaultEvaluator = New(DefaultWeights())
+
+// Default returns the evaluator with the default weights
+func Default() *Evaluator {
+	return defaultEvaluator
+}
+
+// Evaluate scores the position with the default weights, see Evaluator.Evaluate
+func Evaluate(bb *chess.BitBoards) int {
+	return defaultEvaluator.Evaluate(bb)
+}
+
+// New creates an evaluator from weights, the weights must not be changed after
+func New(w *Weights) *Evaluator {
+	e := &Evaluator{weights: w}
+
+	e.pieces = [7]*PieceWeights{
+		nil,
+		&w.Pieces.Pawn,
+		&w.Pieces.Knight,
+		&w.Pieces.Bishop,
+		&w.Pieces.Rook,
+		&w.Pieces.Queen,
+		&w.Pieces.King,
+	}
+
+	for pt := chess.Pawn; pt <= chess.King; pt++ {
+		pw := e.pieces[pt]
+		for sq := range 64 {
+			// tables are written with a8 first from White's point of view
+			white := sq ^ 56
+			black := sq
+
+			e.pst[chess.White][pt][sq] = Score{pw.Value.MG + pw.PST.MG[white], pw.Value.EG + pw.PST.EG[white]}
+			e.pst[chess.Black][pt][sq] = Score{pw.Value.MG + pw.PST.MG[black], pw.Value.EG + pw.PST.EG[black]}
+		}
+	}
+
+	e.maxPhase = 16*w.Pieces.Pawn.Phase + 4*(w.Pieces.Knight.Phase+w.Pieces.Bishop.Phase+w.Pieces.Rook.Phase) + 2*w.Pieces.Queen.Phase
+	e.maxPhase = max(e.maxPhase, 1)
+
+	for sq := range 64 {
+		file, rank := sq%8, sq/8
+		for r := range 8 {
+			for f := max(file-1, 0); f <= min(file+1, 7); f++ {
+				bit := chess.BitBoard(1) << (r*8 + f)
+				if r > rank {
+					e.passed[chess.White][sq] |= bit
+				}
+				if r < rank {
+					e.passed[chess.Black][sq] |= bit
+				}
+				if r == rank+1 || r == rank+2 {
+					e.shield[chess.White][sq] |= bit
+				}
+				if r == rank-1 || r == rank-2 {
+					e.shield[chess.Black][sq] |= bit
+				}
+			}
+		}
+	}
+
+	return e
+}
+
+// Weights returns the weights of the evaluator
+func (e *Evaluator) Weights() *Weights {
+	return e.weights
+}
+
+// Evaluate scores the position in centipawns from White's point of view
+func (e *Evaluator) Evaluate(bb *chess.BitBoards) int {
+	var score Score
+	phase := 0
+
+	for _, side := range [2]chess.Color{chess.White, chess.Black} {
+		s, p := e.evaluateSide(bb, side)
+		phase += p
+
+		if side == chess.White {
+			score.MG += s.MG
+			score.EG += s.EG
+		} else {
+			score.MG -= s.MG
+			score.EG -= s.EG
+		}
+	}
+
+	phase = min(phase, e.maxPhase)
+	return (score.MG*phase + score.EG*(e.maxPhase-phase)) / e.maxPhase
+}
+
+// evaluateSide returns the score of the pieces of side and their phase weight
+func (e *Evaluator) evaluateSide(bb *chess.BitBoards, side chess.Color) (Score, int) {
+	var score Score
+	phase := 0
+
+	w := e.weights
+	enemy := side.Opposite()
+	own := bb.OccupiedBy(side)
+
+	enemyPawnAttacks := chess.BitBoard(0)
+	for pawns := bb.PiecesByType(enemy, chess.Pawn); pawns != 0; pawns &= pawns - 1 {
+		enemyPawnAttacks |= chess.PawnAttacks(chess.Square(pawns.LeastSignificantBit()), enemy)
+	}
+
+	enemyKing := chess.Square(bb.PiecesByType(enemy, chess.King).LeastSignificantBit())
+	kingZone := chess.BitBoard(0)
+	if enemyKing.IsValid() {
+		kingZone = chess.KingAttacks(enemyKing) | enemyKing.ToBB()
+	}
+
+	for pt := chess.Pawn; pt <= chess.King; pt++ {
+		pw := e.pieces[pt]
+
+		for pieces := bb.PiecesByType(side, pt); pieces != 0; pieces &= pieces - 1 {
+			sq := chess.Square(pieces.LeastSignificantBit())
+
+			score.add(e.pst[side][pt][sq], 1)
+			phase += pw.Phase
+
+			var attacks chess.BitBoard
+			switch pt {
+			case chess.Knight:
+				attacks = chess.KnightAttacks(sq)
+			case chess.Bishop:
+				attacks = chess.BishopAttacks(sq, bb.AllPieces)
+			case chess.Rook:
+				attacks = chess.RookAttacks(sq, bb.AllPieces)
+			case chess.Queen:
+				attacks = chess.QueenAttacks(sq, bb.AllPieces)
+			default:
+				continue
+			}
+
+			score.add(pw.Mobility, (attacks &^ own &^ enemyPawnAttacks).Count())
+			score.MG += pw.KingAttack * (attacks & kingZone).Count()
+		}
+	}
+
+	if bb.PiecesByType(side, chess.Bishop).Count() >= 2 {
+		score.add(w.BishopPair, 1)
+	}
+
+	score.add(e.evaluatePawns(bb, side), 1)
+
+	if king := chess.Square(bb.PiecesByType(side, chess.King).LeastSignificantBit()); king.IsValid() {
+		score.add(w.KingShield, (e.shield[side][king] & bb.PiecesByType(side, chess.Pawn)).Count())
+	}
+
+	return score, phase
+}
+
+// evaluatePawns scores the doubled, isolated and passed pawns of side
+func (e *Evaluator) evaluatePawns(bb *chess.BitBoards, side chess.Color) Score {
+	var score Score
+
+	w := e.weights
+	pawns := bb.PiecesByType(side, chess.Pawn)
+	enemyPawns := bb.PiecesByType(side.Opposite(), chess.Pawn)
+
+	for file := range 8 {
+		count := (pawns & fileMask(file)).Count()
+		if count == 0 {
+			continue
+		}
+
+		score.add(w.DoubledPawn, count-1)
+
+		adjacent := chess.BitBoard(0)
+		if file > 0 {
+			adjacent |= fileMask(file - 1)
+		}
+		if file < 7 {
+			adjacent |= fileMask(file + 1)
+		}
+		if pawns&adjacent == 0 {
+			score.add(w.IsolatedPawn, count)
+		}
+	}
+
+	for p := pawns; p != 0; p &= p - 1 {
+		sq := p.LeastSignificantBit()
+		if e.passed[side][sq]&enemyPawns != 0 {
+			continue
+		}
+
+		rank := sq / 8
+		if side == chess.Black {
+			rank = 7 - rank
+		}
+		score.MG += w.PassedPawn.MG[rank]
+		score.EG += w.PassedPawn.EG[rank]
+	}
+
+	return score
+}
+
+func (s *Score) add(o Score, n int) {
+	s.MG += o.MG * n
+	s.EG += o.EG * n
+}
+
+func fileMask(file int) chess.BitBoard {
+	return chess.BitBoard(0x0101010101010101) << file
+}
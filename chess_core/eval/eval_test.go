@@ -0,0 +1,126 @@
+package eval
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	chess "github.com/tommjj/chess_OG/chess_core"
+)
+
+// mirrorFEN flips the board vertically and swaps the piece colors
+func mirrorFEN(fen string) string {
+	board, _, _ := strings.Cut(fen, " ")
+	ranks := strings.Split(board, "/")
+
+	mirrored := make([]string, len(ranks))
+	for i, rank := range ranks {
+		var sb strings.Builder
+		for _, c := range rank {
+			switch {
+			case c >= 'a' && c <= 'z':
+				sb.WriteRune(c - 'a' + 'A')
+			case c >= 'A' && c <= 'Z':
+				sb.WriteRune(c - 'A' + 'a')
+			default:
+				sb.WriteRune(c)
+			}
+		}
+		mirrored[len(ranks)-1-i] = sb.String()
+	}
+
+	return strings.Join(mirrored, "/") + " w - - 0 1"
+}
+
+func TestEvaluate(t *testing.T) {
+	const (
+		knightUp = "r1bqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+		pawnA7   = "4k3/P7/8/8/8/8/8/4K3 w - - 0 1"
+		pawnA2   = "4k3/8/8/8/8/8/P7/4K3 w - - 0 1"
+	)
+
+	scores := map[string]int{}
+	for _, fen := range []string{chess.StartingFEN, knightUp, pawnA7, pawnA2} {
+		bb := &chess.BitBoards{}
+		if err := bb.FromFEN(fen); err != nil {
+			t.Fatal(err)
+		}
+		scores[fen] = Evaluate(bb)
+	}
+
+	if got := scores[chess.StartingFEN]; got != 0 {
+		t.Errorf("Evaluate(start position) = %d, want 0", got)
+	}
+
+	// White is a knight up
+	if got := scores[knightUp]; got < 200 {
+		t.Errorf("Evaluate(knight up) = %d, want > 200", got)
+	}
+
+	// a passed pawn on the 7th rank in an endgame is worth more than on the 2nd rank
+	if advanced, home := scores[pawnA7], scores[pawnA2]; advanced <= home {
+		t.Errorf("Evaluate(pawn on a7) = %d, want more than Evaluate(pawn on a2) = %d", advanced, home)
+	}
+}
+
+func TestEvaluateSymmetry(t *testing.T) {
+	fens := []string{
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8",
+		"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+		"r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10",
+	}
+
+	for _, fen := range fens {
+		bb, mirroredBB := &chess.BitBoards{}, &chess.BitBoards{}
+		if err := bb.FromFEN(fen); err != nil {
+			t.Fatal(err)
+		}
+		if err := mirroredBB.FromFEN(mirrorFEN(fen)); err != nil {
+			t.Fatal(err)
+		}
+
+		score, mirrored := Evaluate(bb), Evaluate(mirroredBB)
+		if score != -mirrored {
+			t.Errorf("Evaluate(%q) = %d, mirrored = %d, want opposite scores", fen, score, mirrored)
+		}
+	}
+}
+
+func TestLoadWeights(t *testing.T) {
+	w := DefaultWeights()
+
+	var buf bytes.Buffer
+	if err := w.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadWeights(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *loaded != *w {
+		t.Error("LoadWeights(Save(w)) differs from w")
+	}
+
+	// the weights change the evaluation
+	loaded.Pieces.Knight.Value = Score{MG: 1000, EG: 1000}
+	bb := &chess.BitBoards{}
+	if err := bb.FromFEN("4k3/8/8/8/8/8/8/1N2K3 w - - 0 1"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := New(loaded).Evaluate(bb), Evaluate(bb); got <= want {
+		t.Errorf("Evaluate() with a heavier knight = %d, want more than %d", got, want)
+	}
+
+	for _, data := range []string{
+		`{"pieces": {"pawn": {"pst": {"mg": [1, 2, 3]}}}}`,
+		`{"unknown": 1}`,
+		`{"passed_pawn": {"mg": [0, 1, 2, 3, 4, 5, 6, 7, 8]}}`,
+		`not json`,
+	} {
+		if _, err := LoadWeights(strings.NewReader(data)); !errors.Is(err, ErrInvalidWeights) {
+			t.Errorf("LoadWeights(%q) error = %v, want %v", data, err, ErrInvalidWeights)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// evaluation weights loaded from a JSON data file
+//
+// the default weights are embedded from weights.json, the piece-square tables are the
+// Simplified Evaluation Function tables with PeSTO material values and are meant to be tuned
+
+package eval
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+var ErrInvalidWeights = errors.New("invalid evaluation weights")
+
+//go:embed weights.json
+var defaultWeightsJSON []byte
+
+// Score is a middlegame and endgame value pair, the evaluation interpolates between them by the game phase
+type Score struct {
+	MG int `json:"mg"`
+	EG int `json:"eg"`
+}
+
+// SquareTable is a piece-square table from White's point of view,
+// written as the board is printed: a8 first and h1 last
+type SquareTable [64]int
+
+// RankTable is a table by rank from the side's point of view, the first rank first
+type RankTable [8]int
+
+// PieceWeights are the weights of one piece type
+type PieceWeights struct {
+	Value    Score `json:"value"`
+	Phase    int   `json:"phase"`    // game phase weight, the phase is the sum over the pieces on the board
+	Mobility Score `json:"mobility"` // per reachable square not attacked by enemy pawns
+
+	KingAttack int `json:"king_attack"` // middlegame penalty per attacked square next to the enemy king
+
+	PST struct {
+		MG SquareTable `json:"mg"`
+		EG SquareTable `json:"eg"`
+	} `json:"pst"`
+}
+
+// Weights are the tunable weights of the evaluation, from White's point of view
+type Weights struct {
+	Pieces struct {
+		Pawn   PieceWeights `json:"pawn"`
+		Knight PieceWeights `json:"knight"`
+		Bishop PieceWeights `json:"bishop"`
+		Rook   PieceWeights `json:"rook"`
+		Queen  PieceWeights `json:"queen"`
+		King   PieceWeights `json:"king"`
+	} `json:"pieces"`
+
+	BishopPair   Score `json:"bishop_pair"`
+	DoubledPawn  Score `json:"doubled_pawn"`  // per pawn behind another pawn of the same side
+	IsolatedPawn Score `json:"isolated_pawn"` // per pawn without pawns of the same side on the adjacent files
+	PassedPawn   struct {
+		MG RankTable `json:"mg"`
+		EG RankTable `json:"eg"`
+	} `json:"passed_pawn"`
+	KingShield Score `json:"king_shield"` // per pawn in front of the king
+}
+
+// DefaultWeights returns a copy of the embedded default weights
+func DefaultWeights() *Weights {
+	w, err := LoadWeights(bytes.NewReader(defaultWeightsJSON))
+	if err != nil {
+		panic(err)
+	}
+	return w
+}
+
+// LoadWeights reads weights in JSON format, unknown fields are an error and missing fields are zero
+func LoadWeights(r io.Reader) (*Weights, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	w := &Weights{}
+	if err := dec.Decode(w); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidWeights, err)
+	}
+	return w, nil
+}
+
+// LoadWeightsFile reads weights from a JSON file
+func LoadWeightsFile(path string) (*Weights, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return LoadWeights(f)
+}
+
+// Save writes the weights in JSON format
+func (w *Weights) Save(out io.Writer) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(w)
+}
+
+func (t *SquareTable) UnmarshalJSON(data []byte) error {
+	return unmarshalTable(data, t[:])
+}
+
+func (t *RankTable) UnmarshalJSON(data []byte) error {
+	return unmarshalTable(data, t[:])
+}
+
+// unmarshalTable decodes a JSON array of exactly len(table) numbers
+func unmarshalTable(data []byte, table []int) error {
+	var values []int
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	if len(values) != len(table) {
+		return fmt.Errorf("table has %d values, want %d", len(values), len(table))
+	}
+	copy(table, values)
+	return nil
+}
@@ -4,403 +4,146 @@ package chess_core
 
 import (
 	"fmt"
-	"strings"
+	"slices"
 	"sync"
 )
 
-// GameState represents the current state of the chess game
+// GameState is a game of chess, it holds the current Position, the moves played and the result.
+// it is safe for concurrent use, the positions it returns can be used without locking
 type GameState struct {
-	BitBoards  *BitBoards
-	SideToMove Color
-
-	CastlingRights  int    // bitmask for castling rights (1=White kingside, 2=White queenside, 4=Black kingside, 8=Black queenside)
-	EnPassantSquare Square // square index for en passant target square, 0-63, or 64 if none
-	HalfmoveClock   int    // for fifty-move rule
-	FullmoveNumber  int    // starts at 1, increments after Black
+	pos Position
 
 	startFen string
-	history  History
+	tree     *GameTree // the moves played, with the variations of the analysis
+	node     *TreeNode // the last move played, the root at the start position
 
-	state GameStatus
+	state  GameStatus
+	winner Color // winner of the variant wins, see Variant.Result
 
 	mx sync.Mutex
 }
 
 func NewGame() *GameState {
+	tree := NewGameTree(Position{})
 	return &GameState{
-		BitBoards: &BitBoards{},
-		state:     ResultOngoing,
-		history:   make(History, 50),
+		tree:   tree,
+		node:   tree.root,
+		state:  ResultOngoing,
+		winner: None,
 	}
 }
 
 func (gs *GameState) String() string {
-	return gs.BitBoards.String()
+	return gs.Position().String()
 }
 
+// Copy returns a copy of the game with its tree
 func (gs *GameState) Copy() *GameState {
-	newGS := &GameState{
-		BitBoards:       gs.BitBoards.Copy(),
-		SideToMove:      gs.SideToMove,
-		CastlingRights:  gs.CastlingRights,
-		EnPassantSquare: gs.EnPassantSquare,
-		HalfmoveClock:   gs.HalfmoveClock,
-		FullmoveNumber:  gs.FullmoveNumber,
+	gs.mx.Lock()
+	defer gs.mx.Unlock()
 
-		state: gs.state,
+	tree, node := gs.tree.clone(gs.node)
+	return &GameState{
+		pos:      gs.pos,
+		startFen: gs.startFen,
+		tree:     tree,
+		node:     node,
+		state:    gs.state,
+		winner:   gs.winner,
 	}
-	return newGS
 }
 
-func (gs *GameState) History() History {
+// Position returns the current position
+func (gs *GameState) Position() Position {
 	gs.mx.Lock()
 	defer gs.mx.Unlock()
 
-	return gs.history.Copy()
+	return gs.pos
 }
 
-func (gs *GameState) FromFEN(fen string) error {
+// SideToMove returns the color of the side to move
+func (gs *GameState) SideToMove() Color {
 	gs.mx.Lock()
 	defer gs.mx.Unlock()
 
-	if gs.BitBoards == nil {
-		gs.BitBoards = &BitBoards{}
-	}
-
-	if gs.history == nil {
-		gs.history = make(History, 0, 50)
-	}
-	gs.history.Clear()
-
-	if err := gs.BitBoards.FromFEN(fen); err != nil {
-		return wrapError(err, "bitboards FromFEN")
-	}
+	return gs.pos.sideToMove
+}
 
-	// Parse FEN string to set other fields
-	var sideToMove string
-	var castling string
-	var enPassant string
-	var halfmove int
-	var fullmove int
+// History returns the moves from the start position to the current position
+func (gs *GameState) History() History {
+	gs.mx.Lock()
+	defer gs.mx.Unlock()
 
-	_, lastFen, _ := strings.Cut(fen, " ")
+	history := make(History, gs.node.Ply())
+	for n, i := gs.node, len(history)-1; n.parent != nil; n, i = n.parent, i-1 {
+		history[i] = MoveHistory{
+			Move: n.Move,
+			Hash: n.parent.position.hash,
 
-	_, err := fmt.Sscanf(lastFen, "%s %s %s %d %d", &sideToMove, &castling, &enPassant, &halfmove, &fullmove)
-	if err != nil {
-		return wrapError(ErrInvalidFEN, "arguments parsing in FromFEN")
-	}
-
-	switch sideToMove {
-	case "w":
-		gs.SideToMove = White
-	case "b":
-		gs.SideToMove = Black
-	default:
-		return wrapError(ErrInvalidSideToMove, "side to move in FromFEN")
-	}
-
-	gs.CastlingRights = 0
-	for _, c := range castling {
-		switch c {
-		case 'K':
-			gs.CastlingRights |= 1
-		case 'Q':
-			gs.CastlingRights |= 2
-		case 'k':
-			gs.CastlingRights |= 4
-		case 'q':
-			gs.CastlingRights |= 8
-		case '-':
-			// no castling rights
-		default:
-			return wrapError(ErrInvalidCastling, "castling rights in FromFEN")
+			position:    n.parent.position,
+			pocketDelta: n.pocketDelta,
+			state:       n.status,
 		}
 	}
+	return history
+}
 
-	if enPassant == "-" {
-		gs.EnPassantSquare = Square(NoEnPassant)
-	} else {
-		file := enPassant[0] - 'a'
-		rank := enPassant[1] - '1'
-		if file > 7 || rank > 7 {
-			return wrapError(ErrInvalidEnPassant, "en passant square in FromFEN")
-		}
-		gs.EnPassantSquare = Square(rank*8 + file)
-	}
-
-	if halfmove < 0 {
-		return wrapError(ErrNegativeHalfmove, "halfmove in FromFEN")
-	}
-	gs.HalfmoveClock = halfmove
+func (gs *GameState) FromFEN(fen string) error {
+	gs.mx.Lock()
+	defer gs.mx.Unlock()
 
-	if fullmove <= 0 {
-		return wrapError(ErrNegativeFullmove, "fullmove in FromFEN")
+	pos := Position{variant: gs.pos.variant}
+	if err := pos.setFEN(fen); err != nil {
+		return err
 	}
-	gs.FullmoveNumber = fullmove
-
-	// Locate kings
-	whiteKingBB := gs.BitBoards.WhiteKing.LeastSignificantBit()
-	blackKingBB := gs.BitBoards.BlackKing.LeastSignificantBit()
 
-	if whiteKingBB == -1 {
-		return wrapError(ErrNoKing, "white king in FromFEN")
-	}
-	if blackKingBB == -1 {
-		return wrapError(ErrNoKing, "black king in FromFEN")
-	}
-	if gs.BitBoards.WhiteKing.Count() > 1 {
-		return wrapError(ErrMultipleKings, "white king in FromFEN")
-	}
-	if gs.BitBoards.BlackKing.Count() > 1 {
-		return wrapError(ErrMultipleKings, "black king in FromFEN")
-	}
+	gs.tree = NewGameTree(pos)
+	gs.node = gs.tree.root
 
+	gs.pos = pos
 	gs.startFen = fen
+	gs.state = ResultOngoing
+	gs.winner = None
 
 	return nil
 }
 
 func (gs *GameState) ToFEN() string {
-	gs.mx.Lock()
-	defer gs.mx.Unlock()
-
-	bb := gs.BitBoards
-
-	var fenBoard strings.Builder
-	for rank := 7; rank >= 0; rank-- {
-		emptyCount := 0
-		for file := range 8 {
-			sq := rank*8 + file
-			piece := bb.GetPieceAt(Square(sq))
-
-			if piece == Empty {
-				emptyCount++
-			} else {
-				if emptyCount > 0 {
-					fenBoard.WriteString(fmt.Sprintf("%d", emptyCount))
-					emptyCount = 0
-				}
-				fenBoard.WriteByte(byte(piece))
-			}
-		}
-		if emptyCount > 0 {
-			fenBoard.WriteString(fmt.Sprintf("%d", emptyCount))
-		}
-		if rank > 0 {
-			fenBoard.WriteByte('/')
-		}
-	}
-
-	side := "w"
-	if gs.SideToMove == Black {
-		side = "b"
-	}
-
-	// Castling rights
-	castle := ""
-	if gs.CastlingRights&1 != 0 {
-		castle += "K"
-	}
-	if gs.CastlingRights&2 != 0 {
-		castle += "Q"
-	}
-	if gs.CastlingRights&4 != 0 {
-		castle += "k"
-	}
-	if gs.CastlingRights&8 != 0 {
-		castle += "q"
-	}
-	if castle == "" {
-		castle = "-"
-	}
-
-	//  En passant
-	enpassant := "-"
-	if gs.EnPassantSquare != NoEnPassant {
-		enpassant = SquareToCoordinates[gs.EnPassantSquare]
-	}
-
-	// Combine everything
-	return fmt.Sprintf("%s %s %s %s %d %d",
-		fenBoard.String(),
-		side,
-		castle,
-		enpassant,
-		gs.HalfmoveClock,
-		gs.FullmoveNumber,
-	)
+	return gs.Position().FEN()
 }
 
 func (gs *GameState) StartFen() string {
+	gs.mx.Lock()
+	defer gs.mx.Unlock()
+
 	return gs.startFen
 }
 
-func (gs *GameState) createMove(from, to Square, promo PieceType) (Move, error) {
-	pieceToMove := gs.BitBoards.GetPieceAt(from)
-	if pieceToMove == Empty {
-		return 0, ErrInvalidMove
-	}
-
-	side := pieceToMove.Color()
-	allyPieces := gs.BitBoards.OccupiedBy(side)
-	enemyPieces := gs.BitBoards.OccupiedBy(side.Opposite())
-	allPieces := gs.BitBoards.AllPieces
-
-	switch pieceToMove.Type() {
-	case Pawn:
-		var promotionRank, forwardStep, doublePushRank int
-		var isSingle, isDouble, isCapture bool
-
-		if side == White {
-			forwardStep = 8
-			promotionRank = 8
-			doublePushRank = 2
-		} else {
-			forwardStep = -8
-			promotionRank = 1
-			doublePushRank = 7
-		}
-
-		isSingle = from+Square(forwardStep) == to && to.ToBB()&allPieces == 0
-		if isSingle {
-			isPromotion := to.Rank() == promotionRank
-			if isPromotion {
-				switch promo {
-				case Queen:
-					return NewMove(uint32(from), uint32(to), uint32(encodedPieceIndex(pieceToMove)), uint32(Queen), 0, 0, 0, 0), nil
-				case Rook:
-					return NewMove(uint32(from), uint32(to), uint32(encodedPieceIndex(pieceToMove)), uint32(Rook), 0, 0, 0, 0), nil
-				case Bishop:
-					return NewMove(uint32(from), uint32(to), uint32(encodedPieceIndex(pieceToMove)), uint32(Bishop), 0, 0, 0, 0), nil
-				case Knight:
-					return NewMove(uint32(from), uint32(to), uint32(encodedPieceIndex(pieceToMove)), uint32(Knight), 0, 0, 0, 0), nil
-				default:
-					return 0, ErrInvalidPromotion
-				}
-			}
-			return NewMove(uint32(from), uint32(to), uint32(encodedPieceIndex(pieceToMove)), 0, 0, 0, 0, 0), nil
-		}
-
-		midSquare := from + Square(forwardStep)
-		isDouble = from+Square(forwardStep*2) == to &&
-			to.ToBB()&allPieces == 0 &&
-			midSquare.ToBB()&allPieces == 0 &&
-			from.Rank() == doublePushRank
-		if isDouble {
-			return NewMove(uint32(from), uint32(to), uint32(encodedPieceIndex(pieceToMove)), 0, 0, 1, 0, 0), nil
-		}
-
-		isCapture = PawnAttacks(from, side)&to.ToBB() != 0 &&
-			((to.ToBB()&enemyPieces) != 0 || to == gs.EnPassantSquare)
-		if isCapture {
-			isPromotion := to.Rank() == promotionRank
-			if isPromotion {
-				switch promo {
-				case Queen:
-					return NewMove(uint32(from), uint32(to), uint32(encodedPieceIndex(pieceToMove)), uint32(Queen), 1, 0, 0, 0), nil
-				case Rook:
-					return NewMove(uint32(from), uint32(to), uint32(encodedPieceIndex(pieceToMove)), uint32(Rook), 1, 0, 0, 0), nil
-				case Bishop:
-					return NewMove(uint32(from), uint32(to), uint32(encodedPieceIndex(pieceToMove)), uint32(Bishop), 1, 0, 0, 0), nil
-				case Knight:
-					return NewMove(uint32(from), uint32(to), uint32(encodedPieceIndex(pieceToMove)), uint32(Knight), 1, 0, 0, 0), nil
-				default:
-					return 0, ErrInvalidPromotion
-				}
-			}
-
-			isEnPassant := to == gs.EnPassantSquare
-			if isEnPassant {
-				return NewMove(uint32(from), uint32(to), uint32(encodedPieceIndex(pieceToMove)), 0, 1, 0, 1, 0), nil
-			}
-
-			return NewMove(uint32(from), uint32(to), uint32(encodedPieceIndex(pieceToMove)), 0, 1, 0, 0, 0), nil
-		}
+// LegalMoves returns all legal moves for the side to move.
+// it returns an empty list when the game has ended.
+func (gs *GameState) LegalMoves() MoveList {
+	gs.mx.Lock()
+	defer gs.mx.Unlock()
 
-		return 0, ErrInvalidMove
-	case Rook:
-		attacks := RookAttacks(from, allPieces) & ^allyPieces
-		if to.ToBB()&attacks == 0 {
-			return 0, ErrInvalidMove
-		}
-		isCapture := (enemyPieces >> to) & 1
-		return NewMove(uint32(from), uint32(to), uint32(encodedPieceIndex(pieceToMove)), 0, uint32(isCapture), 0, 0, 0), nil
-	case Bishop:
-		attacks := BishopAttacks(from, allPieces) & ^allyPieces
-		if to.ToBB()&attacks == 0 {
-			return 0, ErrInvalidMove
-		}
-		isCapture := (enemyPieces >> to) & 1
-		return NewMove(uint32(from), uint32(to), uint32(encodedPieceIndex(pieceToMove)), 0, uint32(isCapture), 0, 0, 0), nil
-	case Knight:
-		attacks := KnightAttacks(from) & ^allyPieces
-		if to.ToBB()&attacks == 0 {
-			return 0, ErrInvalidMove
-		}
-		isCapture := (enemyPieces >> to) & 1
-		return NewMove(uint32(from), uint32(to), uint32(encodedPieceIndex(pieceToMove)), 0, uint32(isCapture), 0, 0, 0), nil
-	case Queen:
-		attacks := QueenAttacks(from, allPieces) & ^allyPieces
-		if to.ToBB()&attacks == 0 {
-			return 0, ErrInvalidMove
-		}
-		isCapture := (enemyPieces >> to) & 1
-		return NewMove(uint32(from), uint32(to), uint32(encodedPieceIndex(pieceToMove)), 0, uint32(isCapture), 0, 0, 0), nil
-	case King:
-		attacks := KingAttacks(from) & ^allyPieces
-		if to.ToBB()&attacks != 0 && !IsAttacked(to, side, gs.BitBoards) {
-			isCapture := (enemyPieces >> to) & 1
-			return NewMove(uint32(from), uint32(to), uint32(encodedPieceIndex(pieceToMove)), 0, uint32(isCapture), 0, 0, 0), nil
-		}
+	if gs.state != ResultOngoing {
+		return make(MoveList, 0, 64)
+	}
 
-		// castling
-
-		if side == White && from == SquareE1 {
-			// Kingside castling (O-O): e1 -> e1, rook h1 -> f1
-			if to == SquareG1 && (gs.CastlingRights&WK) != 0 {
-				if allPieces&(SquareF1.ToBB()|SquareG1.ToBB()) == 0 &&
-					!IsAttacked(SquareE1, White, gs.BitBoards) &&
-					!IsAttacked(SquareF1, White, gs.BitBoards) &&
-					!IsAttacked(SquareG1, White, gs.BitBoards) {
-					return NewMove(uint32(from), uint32(to), uint32(encodedPieceIndex(pieceToMove)), 0, 0, 0, 0, 1), nil
-				}
-			}
+	return gs.pos.LegalMoves()
+}
 
-			if to == SquareC1 && (gs.CastlingRights&WQ) != 0 {
-				if allPieces&(SquareB1.ToBB()|SquareC1.ToBB()|SquareD1.ToBB()) == 0 &&
-					!IsAttacked(SquareE1, White, gs.BitBoards) &&
-					!IsAttacked(SquareD1, White, gs.BitBoards) &&
-					!IsAttacked(SquareC1, White, gs.BitBoards) {
-					return NewMove(uint32(from), uint32(to), uint32(encodedPieceIndex(pieceToMove)), 0, 0, 0, 0, 1), nil
-				}
-			}
-		}
+// LegalMovesFrom returns the legal moves of the piece on square sq.
+// it returns an empty list if the square is empty or holds a piece of the side not to move.
+func (gs *GameState) LegalMovesFrom(sq Square) MoveList {
+	moves := gs.LegalMoves()
 
-		if side == Black && from == SquareE8 {
-			// Kingside castling (O-O): e8 -> g8, rook h8 -> f8
-			if to == SquareG8 && (gs.CastlingRights&BK) != 0 {
-				if allPieces&(SquareF8.ToBB()|SquareG8.ToBB()) == 0 &&
-					!IsAttacked(SquareE8, Black, gs.BitBoards) &&
-					!IsAttacked(SquareF8, Black, gs.BitBoards) &&
-					!IsAttacked(SquareG8, Black, gs.BitBoards) {
-					return NewMove(uint32(from), uint32(to), uint32(encodedPieceIndex(pieceToMove)), 0, 0, 0, 0, 1), nil
-				}
-			}
-			// Queenside castling (O-O-O): e8 -> c8, rook a8 -> d8
-			if to == SquareC8 && (gs.CastlingRights&BQ) != 0 {
-				if allPieces&(SquareB8.ToBB()|SquareC8.ToBB()|SquareD8.ToBB()) == 0 &&
-					!IsAttacked(SquareE8, Black, gs.BitBoards) &&
-					!IsAttacked(SquareD8, Black, gs.BitBoards) &&
-					!IsAttacked(SquareC8, Black, gs.BitBoards) {
-					return NewMove(uint32(from), uint32(to), uint32(encodedPieceIndex(pieceToMove)), 0, 0, 0, 0, 1), nil
-				}
-			}
+	ml := make(MoveList, 0, 8)
+	for _, move := range moves {
+		if Square(move.From()) == sq && !move.IsDrop() {
+			ml.Add(move)
 		}
-
-		return 0, ErrInvalidMove
-	default:
-		return 0, ErrInvalidMove
 	}
+	return ml
 }
 
 func (gs *GameState) MakeMove(side Color, from, to Square, promo PieceType) (GameStatus, error) {
@@ -412,92 +155,117 @@ func (gs *GameState) MakeMove(side Color, from, to Square, promo PieceType) (Gam
 	}
 
 	// handle validate move
-	if gs.SideToMove != side {
+	if gs.pos.sideToMove != side {
 		return "", ErrMoveOutOfTurn
 	}
 
-	move, err := gs.createMove(from, to, promo)
+	move, err := gs.pos.findMove(from, to, promo)
 	if err != nil {
 		return "", err
 	}
 
-	bbs := gs.BitBoards.Copy()
+	return gs.makeMove(move)
+}
 
-	makeUnsafeMove(bbs, move)
+// PlayMove plays a move of the side to move, the move must be one of the legal moves, ex: from LegalMoves or ParseSAN
+func (gs *GameState) PlayMove(move Move) (GameStatus, error) {
+	gs.mx.Lock()
+	defer gs.mx.Unlock()
 
-	if IsKingAttacked(side, bbs) {
-		return "", ErrMoveIntoCheck
+	if gs.state != ResultOngoing {
+		return gs.state, ErrMatchEnd
 	}
 
-	// Set new state
+	next, err := gs.pos.MakeMove(move)
+	if err != nil {
+		return "", err
+	}
 
-	// push history
-	currentHash := gs.computeZobristHash()
-	gs.history.Push(move, currentHash)
+	gs.push(move, next)
+	return gs.result(), nil
+}
 
-	// set BitBoards
-	gs.BitBoards = bbs
+// MakeUnsafeMove makes a pseudo legal move of the side to move without validation and game end detection,
+// the move is taken back with Undo. the search should use Position.MakeUnsafeMove
+//
+//	returns false and keeps the position if the move leaves the king in check
+func (gs *GameState) MakeUnsafeMove(move Move) bool {
+	gs.mx.Lock()
+	defer gs.mx.Unlock()
 
-	if side == Black {
-		gs.FullmoveNumber += 1
+	next, ok := gs.pos.MakeUnsafeMove(move)
+	if !ok {
+		return false
 	}
-	gs.SideToMove = side.Opposite()
 
-	// CastlingRights update
-	gs.CastlingRights &= CastlingRights[from]
-	gs.CastlingRights &= CastlingRights[to]
+	gs.push(move, next)
+	return true
+}
 
-	// HalfmoveClock update
-	if move.IsCapture() || ASCIIPieces[move.Piece()].Type() == Pawn {
-		gs.HalfmoveClock = 0
-	} else {
-		gs.HalfmoveClock++
-	}
+// Hash returns the zobrist hash of the current position,
+// it only depends on the position and the zobrist seed so it can be stored and compared between processes
+func (gs *GameState) Hash() uint64 {
+	gs.mx.Lock()
+	defer gs.mx.Unlock()
 
-	// EnPassantSquare update
-	if move.IsDoublePush() {
-		if side == White {
-			gs.EnPassantSquare = Square(move.To()) - 8
-		} else {
-			gs.EnPassantSquare = Square(move.To()) + 8
-		}
-	} else {
-		gs.EnPassantSquare = NoEnPassant
+	return gs.pos.hash
+}
+
+// makeMove makes a pseudo legal move of the side to move and updates the game state
+func (gs *GameState) makeMove(move Move) (GameStatus, error) {
+	next := gs.pos
+	if !next.doMove(move) {
+		return "", ErrMoveIntoCheck
 	}
 
-	if IsKingAttacked(side.Opposite(), bbs) {
-		if !hasAnyLegalMove(bbs, side.Opposite(), gs.CastlingRights, gs.EnPassantSquare) {
-			gs.state = ResultCheckmate
-		}
-	} else {
-		if !hasAnyLegalMove(bbs, side.Opposite(), gs.CastlingRights, gs.EnPassantSquare) {
-			gs.state = ResultStalemate
-		}
+	gs.push(move, next)
+	return gs.result(), nil
+}
+
+// push adds the move to the tree, a move already played from the current node is played again,
+// and moves to the next position
+func (gs *GameState) push(move Move, next Position) {
+	gs.node.position = gs.pos // with the pieces added by AddToPocket
+
+	child := gs.node.child(move)
+	pushed := child == nil
+	if pushed {
+		child = gs.node.add(move, next)
 	}
+	child.pushed = pushed
+	child.position = next
+	child.pocketDelta = next.pockets.sub(&gs.pos.pockets)
+	child.status = gs.state
+
+	gs.node = child
+	gs.pos = next
+}
 
-	if gs.isInsufficientMaterial() {
-		gs.state = ResultInsufficientMaterial
-		return gs.state, nil
+// result updates and returns the state of the game after a move
+func (gs *GameState) result() GameStatus {
+	if status, winner := gs.pos.Result(); status != ResultOngoing {
+		gs.state, gs.winner = status, winner
+		return gs.state
 	}
 
 	if gs.isThreefoldRepetition() {
 		gs.state = ResultThreefoldRepetition
-		return gs.state, nil
+		return gs.state
 	}
 
-	if gs.HalfmoveClock >= 150 { // 75 moves per side = 150 ply
+	if gs.pos.halfmoveClock >= 150 { // 75 moves per side = 150 ply
 		gs.state = ResultDrawBy75Move
-		return gs.state, nil
+		return gs.state
 	}
 
-	return gs.state, nil
+	return gs.state
 }
 
 func (gs *GameState) CanDrawBy50Move() bool {
 	gs.mx.Lock()
 	defer gs.mx.Unlock()
 
-	return gs.HalfmoveClock >= 100
+	return gs.pos.halfmoveClock >= 100
 }
 
 func (gs *GameState) MakeDrawBy50Move() error {
@@ -505,13 +273,12 @@ func (gs *GameState) MakeDrawBy50Move() error {
 	defer gs.mx.Unlock()
 
 	// chỉ được yêu cầu hòa khi đã đủ 50 nước (100 ply)
-	if gs.HalfmoveClock < 100 {
-		return fmt.Errorf("cannot claim draw yet: only %d halfmoves", gs.HalfmoveClock)
+	if gs.pos.halfmoveClock < 100 {
+		return fmt.Errorf("cannot claim draw yet: only %d halfmoves", gs.pos.halfmoveClock)
 	}
 
 	// chỉ có thể yêu cầu hòa khi ván chưa kết thúc
-	switch gs.state {
-	case ResultCheckmate, ResultStalemate, ResultDrawBy75Move, ResultDrawBy50Move, ResultInsufficientMaterial:
+	if gs.state != ResultOngoing {
 		return ErrMatchEnd
 	}
 
@@ -524,12 +291,14 @@ func (gs *GameState) CanMakeDrawBy50Move() bool {
 	gs.mx.Lock()
 	defer gs.mx.Unlock()
 
-	return gs.HalfmoveClock > 100
+	return gs.pos.halfmoveClock > 100
 }
 
 func (gs *GameState) isInsufficientMaterial() bool {
-	bb := gs.BitBoards
+	return isInsufficientMaterial(&gs.pos.bb)
+}
 
+func isInsufficientMaterial(bb *BitBoards) bool {
 	// Đếm quân mỗi bên
 	whitePieces := bb.WhitePieces
 	blackPieces := bb.BlackPieces
@@ -565,14 +334,14 @@ func (gs *GameState) isInsufficientMaterial() bool {
 }
 
 func (gs *GameState) isThreefoldRepetition() bool {
-	currentHash := computeZobristHash(gs.BitBoards, gs.SideToMove, gs.EnPassantSquare, gs.CastlingRights)
+	currentHash := gs.pos.hash
 	count := 0
 
 	// chỉ cần xét các thế trong phạm vi halfmoveClock nước gần nhất
-	limit := max(len(gs.history)-gs.HalfmoveClock, 0)
-
-	for i := len(gs.history) - 1; i >= limit; i-- {
-		if gs.history[i].Hash == currentHash {
+	n := gs.node
+	for i := 0; i < gs.pos.halfmoveClock && n.parent != nil; i++ {
+		n = n.parent
+		if n.position.hash == currentHash {
 			count++
 			if count >= 2 {
 				return true
@@ -583,7 +352,7 @@ func (gs *GameState) isThreefoldRepetition() bool {
 }
 
 func (gs *GameState) canForceCheckmate(playerColor Color) bool {
-	bb := gs.BitBoards
+	bb := &gs.pos.bb
 
 	var checkingPieces BitBoard
 	var targetPieces BitBoard
@@ -664,113 +433,62 @@ func (gs *GameState) CanForceCheckmate(playerColor Color) bool {
 
 // get winner player
 func (gs *GameState) Winner() (Color, bool) {
-	if gs.state == ResultCheckmate {
-		return gs.SideToMove.Opposite(), true
+	gs.mx.Lock()
+	defer gs.mx.Unlock()
+
+	switch gs.state {
+	case ResultCheckmate:
+		return gs.pos.sideToMove.Opposite(), true
+	case ResultKingOfTheHill, ResultThreeCheck, ResultKingExploded, ResultVariantWin:
+		return gs.winner, true
 	}
 	return None, false
 }
 
 // get current game state
 func (gs *GameState) State() GameStatus {
-	return gs.state
-}
+	gs.mx.Lock()
+	defer gs.mx.Unlock()
 
-func (gs *GameState) computeZobristHash() uint64 {
-	return computeZobristHash(gs.BitBoards, gs.SideToMove, gs.EnPassantSquare, gs.CastlingRights)
+	return gs.state
 }
 
-// resetBoardToInitialState this function resets the board state and does not reset the history
-func (gs *GameState) resetBoardToInitialState() {
-	var sideToMove string
-	var castling string
-	var enPassant string
-	var halfmove int
-	var fullmove int
+// unmakeMove takes back the current move, it is removed from the tree if it was added by its push
+// and no move follows it
+func (gs *GameState) unmakeMove() {
+	undo := gs.node
+	parent := undo.parent
 
-	gs.BitBoards.FromFEN(gs.startFen)
-	_, lastFen, _ := strings.Cut(gs.startFen, " ")
+	// the pieces added with AddToPocket after the move stay in the pockets
+	pockets := gs.pos.pockets.sub(&undo.pocketDelta)
 
-	fmt.Sscanf(lastFen, "%s %s %s %d %d", &sideToMove, &castling, &enPassant, &halfmove, &fullmove)
+	gs.pos = parent.position
+	gs.state = undo.status
+	gs.node = parent
 
-	switch sideToMove {
-	case "w":
-		gs.SideToMove = White
-	case "b":
-		gs.SideToMove = Black
+	if undo.pushed && len(undo.children) == 0 {
+		parent.children = slices.DeleteFunc(parent.children, func(n *TreeNode) bool { return n == undo })
+		undo.parent = nil
 	}
 
-	gs.CastlingRights = 0
-	for _, c := range castling {
-		switch c {
-		case 'K':
-			gs.CastlingRights |= 1
-		case 'Q':
-			gs.CastlingRights |= 2
-		case 'k':
-			gs.CastlingRights |= 4
-		case 'q':
-			gs.CastlingRights |= 8
-		case '-':
-			// no castling rights
-		}
-	}
-
-	if enPassant == "-" {
-		gs.EnPassantSquare = Square(NoEnPassant)
-	} else {
-		file := enPassant[0] - 'a'
-		rank := enPassant[1] - '1'
-		gs.EnPassantSquare = Square(rank*8 + file)
+	if pockets != gs.pos.pockets {
+		gs.pos.pockets = pockets
+		gs.pos.hash = gs.pos.computeZobristHash()
 	}
-
-	gs.HalfmoveClock = halfmove
-	gs.FullmoveNumber = fullmove
 }
 
-// Undo
+// Undo takes back the last moves, the moves added to the tree by the game are removed
+// unless other moves follow them
 func (gs *GameState) Undo(moves int) error {
 	gs.mx.Lock()
 	defer gs.mx.Unlock()
 
-	if moves > len(gs.history) || moves <= 0 {
+	if moves > gs.node.Ply() || moves <= 0 {
 		return ErrInvalidUndoMoves
 	}
 
-	gs.history = gs.history[:len(gs.history)-moves]
-	gs.resetBoardToInitialState()
-
-	for _, history := range gs.history {
-		move := history.Move
-		side := move.Side()
-
-		makeUnsafeMove(gs.BitBoards, move)
-		// set BitBoards
-		if side == Black {
-			gs.FullmoveNumber += 1
-		}
-		gs.SideToMove = side.Opposite()
-
-		// CastlingRights update
-		gs.CastlingRights &= CastlingRights[move.From()]
-		gs.CastlingRights &= CastlingRights[move.To()]
-
-		// HalfmoveClock update
-		if move.IsCapture() || ASCIIPieces[move.Piece()].Type() == Pawn {
-			gs.HalfmoveClock = 0
-		} else {
-			gs.HalfmoveClock++
-		}
-
-		// EnPassantSquare update
-		if move.IsDoublePush() {
-			if side == White {
-				gs.EnPassantSquare = Square(move.To()) - 8
-			} else {
-				gs.EnPassantSquare = Square(move.To()) + 8
-			}
-		} else {
-			gs.EnPassantSquare = NoEnPassant
-		}
+	for range moves {
+		gs.unmakeMove()
 	}
 
 	return nil
@@ -791,9 +509,14 @@ func sameBishopColor(whiteBishop, blackBishop BitBoard) bool {
 	return isLight1 == isLight2
 }
 
+// MoveHistory is an entry of the undo stack, it holds the move and the position before it
 type MoveHistory struct {
 	Move Move
-	Hash uint64
+	Hash uint64 // zobrist hash of the position before the move
+
+	position    Position
+	pocketDelta Pockets // pieces added to the pockets by the move, negative for drops
+	state       GameStatus
 }
 
 type History []MoveHistory
@@ -0,0 +1,77 @@
+package chess_core
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestMakeUnmakeMove(t *testing.T) {
+	fens := []string{
+		StartingFEN,
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8",
+		"bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w HFhf - 2 9",
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for _, fen := range fens {
+		gs := NewGame()
+		if err := gs.FromFEN(fen); err != nil {
+			t.Fatal(err)
+		}
+
+		fenStack := []string{gs.ToFEN()}
+		for range 200 {
+			moves := gs.LegalMoves()
+			if len(moves) == 0 {
+				break
+			}
+
+			move := moves[rng.Intn(len(moves))]
+			if _, err := gs.PlayMove(move); err != nil {
+				t.Fatalf("PlayMove(%s) in %q: %v", move.UCI(), gs.ToFEN(), err)
+			}
+
+			if want := gs.pos.computeZobristHash(); gs.pos.hash != want {
+				t.Fatalf("after %s hash = %x, want %x", move.UCI(), gs.pos.hash, want)
+			}
+			fenStack = append(fenStack, gs.ToFEN())
+		}
+
+		for len(fenStack) > 1 {
+			if err := gs.Undo(1); err != nil {
+				t.Fatal(err)
+			}
+			fenStack = fenStack[:len(fenStack)-1]
+
+			if got, want := gs.ToFEN(), fenStack[len(fenStack)-1]; got != want {
+				t.Fatalf("after Undo ToFEN() = %q, want %q", got, want)
+			}
+			if want := gs.pos.computeZobristHash(); gs.pos.hash != want {
+				t.Fatalf("after Undo hash = %x, want %x", gs.pos.hash, want)
+			}
+			if gs.State() != ResultOngoing {
+				t.Fatalf("after Undo State() = %s, want %s", gs.State(), ResultOngoing)
+			}
+		}
+
+		if err := gs.Undo(1); err != ErrInvalidUndoMoves {
+			t.Errorf("Undo() on an empty history error = %v, want %v", err, ErrInvalidUndoMoves)
+		}
+	}
+}
+
+func TestMakeMoveIntoCheck(t *testing.T) {
+	gs := NewGame()
+	if err := gs.FromFEN("4k3/4r3/8/8/8/8/4N3/4K3 w - - 0 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	before := gs.ToFEN()
+	if _, err := gs.MakeMove(White, SquareE2, SquareC3, 0); err != ErrMoveIntoCheck {
+		t.Fatalf("MakeMove() error = %v, want %v", err, ErrMoveIntoCheck)
+	}
+	if got := gs.ToFEN(); got != before {
+		t.Errorf("ToFEN() = %q after an illegal move, want %q", got, before)
+	}
+}
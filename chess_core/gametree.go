@@ -0,0 +1,374 @@
+// Game tree of the analysis board and studies, the moves with their annotations and variations
+//
+// references:
+// - https://www.chessprogramming.org/Game_Record
+// - http://www.saremba.de/chessgml/standards/pgn/pgn-complete.htm#c10
+// - https://www.enpassant.dk/chess/palview/enhancedpgn.htm
+
+package chess_core
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// numeric annotation glyphs of the move suffix annotations
+const (
+	NAGGood        = 1 // !
+	NAGMistake     = 2 // ?
+	NAGBrilliant   = 3 // !!
+	NAGBlunder     = 4 // ??
+	NAGInteresting = 5 // !?
+	NAGDubious     = 6 // ?!
+)
+
+// TreeNode is a move of a GameTree with its annotations, the root node has no move and holds the start position
+type TreeNode struct {
+	Move Move
+	SAN  string
+
+	// numeric annotation glyphs, ex: NAGGood
+	NAGs []int
+
+	// comments written before the move, at the start of the game or of a variation
+	CommentsBefore []string
+	// comments written after the move, the comments of the root are the game comments
+	Comments []string
+
+	// remaining time of the player after the move, the [%clk] annotation
+	Clock    time.Duration
+	HasClock bool
+
+	position Position // position after the move
+	parent   *TreeNode
+
+	// to take back the move of a GameState
+	pocketDelta Pockets    // pieces added to the pockets by the move, negative for drops
+	status      GameStatus // status of the game before the move
+	pushed      bool       // added to the tree by the move, it is removed when the move is taken back
+
+	children []*TreeNode // children[0] continues the line, the others are variations
+}
+
+// Position returns the position after the move
+func (n *TreeNode) Position() Position {
+	return n.position
+}
+
+// Parent returns the node before the move, nil for the root
+func (n *TreeNode) Parent() *TreeNode {
+	return n.parent
+}
+
+// Next returns the move continuing the line, nil at the end of the line
+func (n *TreeNode) Next() *TreeNode {
+	if len(n.children) == 0 {
+		return nil
+	}
+	return n.children[0]
+}
+
+// Children returns the moves played from the node, the first one continues the line
+func (n *TreeNode) Children() []*TreeNode {
+	return slices.Clone(n.children)
+}
+
+// Variations returns the alternatives to the move, the other children of its parent
+func (n *TreeNode) Variations() []*TreeNode {
+	if n.parent == nil {
+		return nil
+	}
+	return slices.DeleteFunc(slices.Clone(n.parent.children), func(c *TreeNode) bool { return c == n })
+}
+
+// IsRoot reports whether the node is the start position
+func (n *TreeNode) IsRoot() bool {
+	return n.parent == nil
+}
+
+// IsMainline reports whether the node is on the main line of the tree
+func (n *TreeNode) IsMainline() bool {
+	for ; n.parent != nil; n = n.parent {
+		if n.parent.children[0] != n {
+			return false
+		}
+	}
+	return true
+}
+
+// Ply returns the number of moves from the start position
+func (n *TreeNode) Ply() int {
+	ply := 0
+	for ; n.parent != nil; n = n.parent {
+		ply++
+	}
+	return ply
+}
+
+// Path returns the moves from the start position to the node
+func (n *TreeNode) Path() []Move {
+	var moves []Move
+	for ; n.parent != nil; n = n.parent {
+		moves = append(moves, n.Move)
+	}
+	slices.Reverse(moves)
+	return moves
+}
+
+// child returns the child of the move, nil if the move has not been played from the node
+func (n *TreeNode) child(move Move) *TreeNode {
+	for _, c := range n.children {
+		if c.Move == move {
+			return c
+		}
+	}
+	return nil
+}
+
+// GameTree is a game with variations, use a Cursor to navigate and edit it.
+// it is not safe for concurrent use
+type GameTree struct {
+	root *TreeNode
+}
+
+// NewGameTree creates a tree starting from pos
+func NewGameTree(pos Position) *GameTree {
+	return &GameTree{root: &TreeNode{position: pos}}
+}
+
+// Tree returns a copy of the tree of the game, the moves played with their variations
+func (gs *GameState) Tree() *GameTree {
+	gs.mx.Lock()
+	defer gs.mx.Unlock()
+
+	tree, _ := gs.tree.clone(gs.node)
+	return tree
+}
+
+// clone returns a deep copy of the tree and the copy of its node
+func (t *GameTree) clone(node *TreeNode) (*GameTree, *TreeNode) {
+	var path []int // index of each move of the node in the children of its parent
+	for n := node; n.parent != nil; n = n.parent {
+		path = append(path, slices.Index(n.parent.children, n))
+	}
+
+	tree := &GameTree{root: t.root.clone(nil)}
+	node = tree.root
+	for i := len(path) - 1; i >= 0; i-- {
+		node = node.children[path[i]]
+	}
+	return tree, node
+}
+
+func (n *TreeNode) clone(parent *TreeNode) *TreeNode {
+	c := new(TreeNode)
+	*c = *n
+	c.NAGs = slices.Clone(n.NAGs)
+	c.CommentsBefore = slices.Clone(n.CommentsBefore)
+	c.Comments = slices.Clone(n.Comments)
+	c.parent = parent
+
+	c.children = make([]*TreeNode, len(n.children))
+	for i, child := range n.children {
+		c.children[i] = child.clone(c)
+	}
+	return c
+}
+
+// Root returns the node of the start position
+func (t *GameTree) Root() *TreeNode {
+	return t.root
+}
+
+// Mainline returns the moves of the main line
+func (t *GameTree) Mainline() []*TreeNode {
+	var nodes []*TreeNode
+	for n := t.root.Next(); n != nil; n = n.Next() {
+		nodes = append(nodes, n)
+	}
+	return nodes
+}
+
+// Cursor returns a cursor on the start position
+func (t *GameTree) Cursor() *Cursor {
+	return &Cursor{tree: t, node: t.root}
+}
+
+// add adds the move played from n, pos is the position after the move
+func (n *TreeNode) add(move Move, pos Position) *TreeNode {
+	child := &TreeNode{
+		Move:     move,
+		SAN:      n.position.MoveToSAN(move),
+		position: pos,
+		parent:   n,
+	}
+	n.children = append(n.children, child)
+	return child
+}
+
+// Cursor is a position in a GameTree
+type Cursor struct {
+	tree *GameTree
+	node *TreeNode
+}
+
+// Node returns the current node
+func (c *Cursor) Node() *TreeNode {
+	return c.node
+}
+
+// Position returns the current position
+func (c *Cursor) Position() Position {
+	return c.node.position
+}
+
+// Forward moves to the next move of the line, false at the end of the line
+func (c *Cursor) Forward() bool {
+	next := c.node.Next()
+	if next == nil {
+		return false
+	}
+	c.node = next
+	return true
+}
+
+// Back moves to the previous move, false at the start position
+func (c *Cursor) Back() bool {
+	if c.node.parent == nil {
+		return false
+	}
+	c.node = c.node.parent
+	return true
+}
+
+// ToStart moves to the start position
+func (c *Cursor) ToStart() {
+	c.node = c.tree.root
+}
+
+// ToEnd moves to the end of the current line
+func (c *Cursor) ToEnd() {
+	for c.Forward() {
+	}
+}
+
+// Goto moves to a node of the tree
+func (c *Cursor) Goto(node *TreeNode) error {
+	root := node
+	for root.parent != nil {
+		root = root.parent
+	}
+	if root != c.tree.root {
+		return ErrNodeNotInTree
+	}
+
+	c.node = node
+	return nil
+}
+
+// Enter moves to the child i of the current node, 0 continues the line and the others are variations
+func (c *Cursor) Enter(i int) bool {
+	if i < 0 || i >= len(c.node.children) {
+		return false
+	}
+	c.node = c.node.children[i]
+	return true
+}
+
+// Play plays the move from the current position and moves to it.
+// a move that is not the continuation of the line starts a new variation
+func (c *Cursor) Play(move Move) (*TreeNode, error) {
+	if child := c.node.child(move); child != nil {
+		c.node = child
+		return child, nil
+	}
+
+	next, err := c.node.position.MakeMove(move)
+	if err != nil {
+		return nil, err
+	}
+
+	c.node = c.node.add(move, next)
+	return c.node, nil
+}
+
+// PlaySAN is like Play with a move in SAN, ex: "Nf3"
+func (c *Cursor) PlaySAN(san string) (*TreeNode, error) {
+	move, err := c.node.position.ParseSAN(san)
+	if err != nil {
+		return nil, err
+	}
+	return c.Play(move)
+}
+
+// AddToPocket puts the piece in the pocket of its color in the current position, ex: a piece handed over
+// from the other board of a Bughouse game. the moves after the current node are not played again
+func (c *Cursor) AddToPocket(piece Piece) error {
+	pos, err := c.node.position.AddToPocket(piece)
+	if err != nil {
+		return err
+	}
+
+	c.node.position = pos
+	return nil
+}
+
+// Promote makes the current move the continuation of the line of its parent,
+// the previous continuation becomes the first variation
+func (c *Cursor) Promote() {
+	promote(c.node)
+}
+
+// PromoteToMainline promotes the current move and all the moves before it, the line becomes the main line
+func (c *Cursor) PromoteToMainline() {
+	for n := c.node; n.parent != nil; n = n.parent {
+		promote(n)
+	}
+}
+
+func promote(n *TreeNode) {
+	if n.parent == nil {
+		return
+	}
+
+	siblings := n.parent.children
+	i := slices.Index(siblings, n)
+	copy(siblings[1:i+1], siblings[:i])
+	siblings[0] = n
+}
+
+// Delete removes the current move with the moves after it and moves to the previous move
+func (c *Cursor) Delete() bool {
+	parent := c.node.parent
+	if parent == nil {
+		return false
+	}
+
+	parent.children = slices.DeleteFunc(parent.children, func(n *TreeNode) bool { return n == c.node })
+	c.node.parent = nil // detached from the tree
+	c.node = parent
+	return true
+}
+
+// GameState replays the moves from the start position to the current node,
+// the game holds a copy of the tree with the moves after the node and the variations
+func (c *Cursor) GameState() (*GameState, error) {
+	tree, _ := c.tree.clone(c.tree.root)
+	gs := &GameState{
+		pos:      tree.root.position,
+		startFen: tree.root.position.FEN(),
+		tree:     tree,
+		node:     tree.root,
+		state:    ResultOngoing,
+		winner:   None,
+	}
+
+	for i, move := range c.node.Path() {
+		if _, err := gs.PlayMove(move); err != nil {
+			return nil, fmt.Errorf("move %d of the tree: %w", i+1, err)
+		}
+	}
+
+	return gs, nil
+}
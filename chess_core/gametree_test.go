@@ -0,0 +1,124 @@
+package chess_core
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGameTreeCursor(t *testing.T) {
+	start, _ := ParsePosition(StartingFEN)
+	tree := NewGameTree(start)
+	c := tree.Cursor()
+
+	for _, san := range []string{"e4", "e5", "Nf3", "Nc6"} {
+		if _, err := c.PlaySAN(san); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 2... d6 and 1... c5 2. Nf3 branch from the main line
+	c.Back()
+	d6, _ := c.PlaySAN("d6")
+	c.ToStart()
+	c.Forward()
+	c5, _ := c.PlaySAN("c5")
+	if _, err := c.PlaySAN("Nf3"); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(tree.Mainline()); n != 4 {
+		t.Errorf("Mainline() has %d moves, want 4", n)
+	}
+	if c5.IsMainline() || d6.IsMainline() || c5.Ply() != 2 {
+		t.Errorf("variations on the main line")
+	}
+	if v := c5.Variations(); len(v) != 1 || v[0].SAN != "e5" {
+		t.Errorf("1... c5 Variations() = %v, want 1... e5", v)
+	}
+
+	// replaying a move follows the existing node
+	c.ToStart()
+	if n, _ := c.PlaySAN("e4"); n != tree.Mainline()[0] || len(tree.Root().Children()) != 1 {
+		t.Errorf("PlaySAN() of an existing move added a node")
+	}
+
+	if err := c.Goto(d6); err != nil {
+		t.Fatal(err)
+	}
+	c.PromoteToMainline()
+	if !d6.IsMainline() || tree.Mainline()[3] != d6 || d6.Variations()[0].SAN != "Nc6" {
+		t.Errorf("PromoteToMainline() did not promote 2... d6")
+	}
+
+	gs, err := c.GameState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := gs.ToFEN(), d6.Position().FEN(); got != want || len(gs.History()) != 4 {
+		t.Errorf("GameState() = %q with %d moves, want %q", got, len(gs.History()), want)
+	}
+
+	if err := c.Goto(c5); err != nil {
+		t.Fatal(err)
+	}
+	if !c.Delete() || c.Node() != tree.Mainline()[0] || len(c.Node().Children()) != 1 {
+		t.Errorf("Delete() did not remove 1... c5")
+	}
+	if err := c.Goto(c5); !errors.Is(err, ErrNodeNotInTree) {
+		t.Errorf("Goto() of a deleted node error = %v, want %v", err, ErrNodeNotInTree)
+	}
+}
+
+func TestGameStateTree(t *testing.T) {
+	gs, _ := playVariant(t, Standard{}, StartingFEN, "e2e4", "e7e5", "g1f3")
+
+	tree := gs.Tree()
+	mainline := tree.Mainline()
+	if len(mainline) != 3 || mainline[2].SAN != "Nf3" || mainline[2].Position().FEN() != gs.ToFEN() {
+		t.Errorf("Tree() main line = %v", mainline)
+	}
+	if tree.Root().Position().FEN() != StartingFEN {
+		t.Errorf("Tree() root = %q, want %q", tree.Root().Position().FEN(), StartingFEN)
+	}
+
+	// a game from the tree keeps the main line, a new move starts a variation
+	c := tree.Cursor()
+	c.Forward()
+	branch, err := c.GameState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := branch.MakeMoveUCI(Black, "d7d5"); err != nil {
+		t.Fatal(err)
+	}
+	if e4 := branch.Tree().Mainline()[0]; len(e4.Children()) != 2 || e4.Next().SAN != "e5" || e4.Children()[1].SAN != "d5" {
+		t.Errorf("1. e4 of the branch has %v, want e5 and d5", e4.Children())
+	}
+	if len(tree.Mainline()[0].Children()) != 1 {
+		t.Errorf("the game changed the tree it was made from")
+	}
+
+	// Undo removes the last move of the line
+	if err := branch.Undo(1); err != nil {
+		t.Fatal(err)
+	}
+	if e4 := branch.Tree().Mainline()[0]; len(e4.Children()) != 1 || len(branch.History()) != 1 {
+		t.Errorf("Undo() kept 1... d5: %v", e4.Children())
+	}
+
+	// the moves of the tree the game was made from are kept
+	d5, err := c.PlaySAN("d5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	variation, err := c.GameState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := variation.Undo(2); err != nil {
+		t.Fatal(err)
+	}
+	if e4 := variation.Tree().Mainline()[0]; len(e4.Children()) != 2 || e4.Children()[1].SAN != d5.SAN {
+		t.Errorf("Undo() removed the variation 1... d5: %v", e4.Children())
+	}
+}
@@ -18,6 +18,9 @@ import (
    0010 0000 0000 0000 0000 0000    double push flag    0x200000
    0100 0000 0000 0000 0000 0000    enpassant flag      0x400000
    1000 0000 0000 0000 0000 0000    castling flag       0x800000
+ 1 0000 0000 0000 0000 0000 0000    drop flag           0x1000000
+
+	drops have the same source and target square, the piece is the dropped piece
 
          piece encoding PNBRQKpnbrqk
 
@@ -61,6 +64,13 @@ func NewMove(src, dst, piece, promo, capture, dbl, ep, castle uint32) Move {
 	)
 }
 
+// NewDropMove create a new encoding drop of piece on square sq, see Crazyhouse
+func NewDropMove(piece Piece, sq Square) Move {
+	return NewMove(uint32(sq), uint32(sq), uint32(encodedPieceIndex(piece)), 0, 0, 0, 0, 0) | dropFlag
+}
+
+const dropFlag Move = 0x1000000
+
 func (m Move) From() uint32     { return uint32(m) & 0x3F }
 func (m Move) To() uint32       { return (uint32(m) >> 6) & 0x3F }
 func (m Move) Piece() uint32    { return (uint32(m) >> 12) & 0xF }
@@ -71,12 +81,20 @@ func (m Move) IsCapture() bool    { return (uint32(m) & 0x100000) != 0 }
 func (m Move) IsDoublePush() bool { return (uint32(m) & 0x200000) != 0 }
 func (m Move) IsEnPassant() bool  { return (uint32(m) & 0x400000) != 0 }
 func (m Move) IsCastle() bool     { return (uint32(m) & 0x800000) != 0 }
+func (m Move) IsDrop() bool       { return m&dropFlag != 0 }
 
 func (m Move) Side() Color {
 	return getSideByPiece(ASCIIPieces[m.Piece()])
 }
 
 func (m Move) String() string {
+	if m.IsDrop() {
+		return fmt.Sprintf(
+			"drop=%s, to=%s",
+			PieceToASCII[ASCIIPieces[m.Piece()]],
+			SquareToCoordinates[m.To()],
+		)
+	}
 	if m.IsPromotion() {
 		return fmt.Sprintf(
 			"from=%s, to=%s, promoted=%s, capture=%v",
@@ -108,12 +126,13 @@ func (ml *MoveList) Copy() MoveList {
 	return moves
 }
 
-func generatePseudoLegalMoves(bb *BitBoards, side Color, castling int, enPassantSquare Square, ml *MoveList) {
+func generatePseudoLegalMoves(bb *BitBoards, side Color, castling int, enPassantSquare Square, ci *castlingInfo, ml *MoveList) {
 	generatePawnMoves(bb, side, enPassantSquare, ml)
+	generateKnightMoves(bb, side, ml)
 	generateBishopMoves(bb, side, ml)
 	generateRookMoves(bb, side, ml)
 	generateQueenMoves(bb, side, ml)
-	generateKingMoves(bb, side, castling, ml)
+	generateKingMoves(bb, side, castling, ci, ml)
 }
 
 // generatePawnMoves
@@ -191,10 +210,10 @@ func generatePawnMoves(bb *BitBoards, side Color, enPassantSquare Square, ml *Mo
 		fromSquare := Square(int(toSquare) - forwardStep + leftOffset)
 		// Check for promotion
 		if toSquare.Rank() == promotionRank {
-			ml.Add(NewMove(uint32(fromSquare), uint32(toSquare), pawnTypeCode, uint32(Queen), 0, 0, 0, 0))
-			ml.Add(NewMove(uint32(fromSquare), uint32(toSquare), pawnTypeCode, uint32(Rook), 0, 0, 0, 0))
-			ml.Add(NewMove(uint32(fromSquare), uint32(toSquare), pawnTypeCode, uint32(Bishop), 0, 0, 0, 0))
-			ml.Add(NewMove(uint32(fromSquare), uint32(toSquare), pawnTypeCode, uint32(Knight), 0, 0, 0, 0))
+			ml.Add(NewMove(uint32(fromSquare), uint32(toSquare), pawnTypeCode, uint32(Queen), 1, 0, 0, 0))
+			ml.Add(NewMove(uint32(fromSquare), uint32(toSquare), pawnTypeCode, uint32(Rook), 1, 0, 0, 0))
+			ml.Add(NewMove(uint32(fromSquare), uint32(toSquare), pawnTypeCode, uint32(Bishop), 1, 0, 0, 0))
+			ml.Add(NewMove(uint32(fromSquare), uint32(toSquare), pawnTypeCode, uint32(Knight), 1, 0, 0, 0))
 		} else {
 			ml.Add(NewMove(uint32(fromSquare), uint32(toSquare), pawnTypeCode, 0, 1, 0, 0, 0))
 		}
@@ -205,10 +224,10 @@ func generatePawnMoves(bb *BitBoards, side Color, enPassantSquare Square, ml *Mo
 		fromSquare := Square(int(toSquare) - forwardStep + rightOffset)
 		// Check for promotion
 		if toSquare.Rank() == promotionRank {
-			ml.Add(NewMove(uint32(fromSquare), uint32(toSquare), pawnTypeCode, uint32(Queen), 0, 0, 0, 0))
-			ml.Add(NewMove(uint32(fromSquare), uint32(toSquare), pawnTypeCode, uint32(Rook), 0, 0, 0, 0))
-			ml.Add(NewMove(uint32(fromSquare), uint32(toSquare), pawnTypeCode, uint32(Bishop), 0, 0, 0, 0))
-			ml.Add(NewMove(uint32(fromSquare), uint32(toSquare), pawnTypeCode, uint32(Knight), 0, 0, 0, 0))
+			ml.Add(NewMove(uint32(fromSquare), uint32(toSquare), pawnTypeCode, uint32(Queen), 1, 0, 0, 0))
+			ml.Add(NewMove(uint32(fromSquare), uint32(toSquare), pawnTypeCode, uint32(Rook), 1, 0, 0, 0))
+			ml.Add(NewMove(uint32(fromSquare), uint32(toSquare), pawnTypeCode, uint32(Bishop), 1, 0, 0, 0))
+			ml.Add(NewMove(uint32(fromSquare), uint32(toSquare), pawnTypeCode, uint32(Knight), 1, 0, 0, 0))
 		} else {
 			ml.Add(NewMove(uint32(fromSquare), uint32(toSquare), pawnTypeCode, 0, 1, 0, 0, 0))
 		}
@@ -239,6 +258,37 @@ func generatePawnMoves(bb *BitBoards, side Color, enPassantSquare Square, ml *Mo
 	}
 }
 
+// generateKnightMoves
+func generateKnightMoves(bb *BitBoards, side Color, ml *MoveList) {
+	var knightPieces, enemyPieces, allyPieces BitBoard
+	var pawnTypeCode uint32
+
+	if side == White {
+		pawnTypeCode = uint32(encodedPieceIndex(WKnight))
+		knightPieces = bb.WhiteKnights
+		enemyPieces = bb.BlackPieces
+		allyPieces = bb.WhitePieces
+	} else {
+		pawnTypeCode = uint32(encodedPieceIndex(BKnight))
+		knightPieces = bb.BlackKnights
+		enemyPieces = bb.WhitePieces
+		allyPieces = bb.BlackPieces
+	}
+
+	for rp := knightPieces; rp != 0; {
+		fromSq := Square(popLSB(&rp))
+
+		attacks := KnightAttacks(fromSq) & ^allyPieces
+
+		for p := attacks; p != 0; {
+			toSq := popLSB(&p)
+
+			isCapture := (enemyPieces >> toSq) & 1
+			ml.Add(NewMove(uint32(fromSq), uint32(toSq), pawnTypeCode, 0, uint32(isCapture), 0, 0, 0))
+		}
+	}
+}
+
 // generateRookMoves
 func generateRookMoves(bb *BitBoards, side Color, ml *MoveList) {
 	var rookPieces, enemyPieces, allyPieces BitBoard
@@ -333,7 +383,7 @@ func generateQueenMoves(bb *BitBoards, side Color, ml *MoveList) {
 }
 
 // generateKingMoves
-func generateKingMoves(bb *BitBoards, side Color, castling int, ml *MoveList) {
+func generateKingMoves(bb *BitBoards, side Color, castling int, ci *castlingInfo, ml *MoveList) {
 	var kingPieces, enemyPieces, allyPieces BitBoard
 	var pawnTypeCode uint32
 
@@ -360,86 +410,111 @@ func generateKingMoves(bb *BitBoards, side Color, castling int, ml *MoveList) {
 		ml.Add(NewMove(uint32(fromSq), uint32(toSq), pawnTypeCode, 0, uint32(isCapture), 0, 0, 0))
 	}
 
-	// castling
-	if side == White {
-		// short castle (O-O)
-		if castling&WK == WK &&
-			(bb.AllPieces&(SquareF1.ToBB()|SquareG1.ToBB())) == 0 && // đường trống
-			!IsAttacked(SquareE1, White, bb) &&
-			!IsAttacked(SquareF1, White, bb) &&
-			!IsAttacked(SquareG1, White, bb) {
-			ml.Add(NewMove(uint32(fromSq), uint32(SquareG1), pawnTypeCode, 0, 0, 0, 0, 1))
-		}
+	generateCastlingMoves(bb, side, castling, ci, ml)
+}
 
-		// long castle (O-O-O)
-		if castling&WQ == WQ &&
-			(bb.AllPieces&(SquareB1.ToBB()|SquareC1.ToBB()|SquareD1.ToBB())) == 0 &&
-			!IsAttacked(SquareE1, White, bb) &&
-			!IsAttacked(SquareD1, White, bb) &&
-			!IsAttacked(SquareC1, White, bb) {
-			ml.Add(NewMove(uint32(fromSq), uint32(SquareC1), pawnTypeCode, 0, 0, 0, 0, 1))
-		}
-	} else {
-		// short castle (O-O)
-		if castling&BK == BK &&
-			(bb.AllPieces&(SquareF8.ToBB()|SquareG8.ToBB())) == 0 && // đường trống
-			!IsAttacked(SquareE8, Black, bb) &&
-			!IsAttacked(SquareF8, Black, bb) &&
-			!IsAttacked(SquareG8, Black, bb) {
-			ml.Add(NewMove(uint32(fromSq), uint32(SquareG8), pawnTypeCode, 0, 0, 0, 0, 1))
-		}
+// generateLegalMoves generates pseudo legal moves and drops the ones that leave the king of side in check
+func generateLegalMoves(bb *BitBoards, side Color, castling int, enPassantSquare Square, ci *castlingInfo, ml *MoveList) {
+	var pseudo MoveList = make(MoveList, 0, 64)
+	generatePseudoLegalMoves(bb, side, castling, enPassantSquare, ci, &pseudo)
 
-		// long castle (O-O-O)
-		if castling&BQ == BQ &&
-			(bb.AllPieces&(SquareB8.ToBB()|SquareC8.ToBB()|SquareD8.ToBB())) == 0 &&
-			!IsAttacked(SquareE8, Black, bb) &&
-			!IsAttacked(SquareD8, Black, bb) &&
-			!IsAttacked(SquareC8, Black, bb) {
-			ml.Add(NewMove(uint32(fromSq), uint32(SquareC8), pawnTypeCode, 0, 0, 0, 0, 1))
+	for _, move := range pseudo {
+		captured, _ := makeUnsafeMove(bb, move, ci)
+		if !IsKingAttacked(side, bb) {
+			ml.Add(move)
 		}
+		unmakeUnsafeMove(bb, move, captured, ci)
 	}
 }
 
 // makeUnsafeMove don't check any rule when do move
-func makeUnsafeMove(bbs *BitBoards, move Move) {
+//
+//	returns the captured piece (Empty if none) and the zobrist key of the changed piece squares
+func makeUnsafeMove(bbs *BitBoards, move Move, ci *castlingInfo) (captured Piece, key uint64) {
 	pieceToMove := ASCIIPieces[move.Piece()]
 	side := getSideByPiece(pieceToMove)
 	from := Square(move.From())
 	to := Square(move.To())
 
+	captured = Empty
+	key = pieceKey(pieceToMove, from)
+
 	switch {
-	case move.IsCastle(): //
-		switch to {
-		case SquareG1: // white short castle
-			bbs.moveUnsafePiece(WRook, SquareH1, SquareF1)
-		case SquareC1: // white long castle
-			bbs.moveUnsafePiece(WRook, SquareA1, SquareD1)
-		case SquareG8: // black short castle
-			bbs.moveUnsafePiece(BRook, SquareH8, SquareF8)
-		case SquareC8: // black long castle
-			bbs.moveUnsafePiece(BRook, SquareA8, SquareD8)
-		}
+	case move.IsCastle(): // the rook can stand on the king target square in Chess960, the pieces are moved on their own bitboards
+		right := castlingRightOf(move)
+		rook := getPieceBySide(Rook, side)
+		rookFrom := ci.rookSquare(right)
+		_, rookTo := castlingTargets(right)
+
+		bbs.moveUnsafePiece(rook, rookFrom, rookTo)
+		key ^= pieceKey(rook, rookFrom) ^ pieceKey(rook, rookTo)
 	case move.IsPromotion():
 		if move.IsCapture() {
+			captured = bbs.GetPieceAt(to)
 			bbs.clearSquare(to)
+			key ^= pieceKey(captured, to)
 		}
 		pieceToMove = getPieceBySide(PieceType(move.Promoted()), side)
 		bbs.setPieceAt(from, pieceToMove)
 	case move.IsEnPassant():
-		if side == White {
-			bbs.clearSquare(to - 8)
-		} else {
-			bbs.clearSquare(to + 8)
-		}
+		captureSq := enPassantCaptureSquare(to, side)
+		captured = getPieceBySide(Pawn, side.Opposite())
+		bbs.clearSquare(captureSq)
+		key ^= pieceKey(captured, captureSq)
 	case move.IsCapture():
+		captured = bbs.GetPieceAt(to)
 		bbs.clearSquare(to)
+		key ^= pieceKey(captured, to)
 	}
 
 	bbs.moveUnsafePiece(pieceToMove, from, to)
 	bbs.UpdateAggregate()
+
+	key ^= pieceKey(pieceToMove, to)
+	return captured, key
 }
 
-func hasAnyLegalMove(bb *BitBoards, side Color, castlingRights int, enPassant Square) bool {
+// unmakeUnsafeMove takes back a move made by makeUnsafeMove, captured is the piece it returned
+func unmakeUnsafeMove(bbs *BitBoards, move Move, captured Piece, ci *castlingInfo) {
+	piece := ASCIIPieces[move.Piece()]
+	side := getSideByPiece(piece)
+	from := Square(move.From())
+	to := Square(move.To())
+
+	switch {
+	case move.IsCastle():
+		right := castlingRightOf(move)
+		_, rookTo := castlingTargets(right)
+		bbs.moveUnsafePiece(getPieceBySide(Rook, side), rookTo, ci.rookSquare(right))
+		bbs.moveUnsafePiece(piece, to, from)
+	case move.IsPromotion():
+		promoted := getPieceBySide(PieceType(move.Promoted()), side)
+		bbs.setPieces(promoted, bbs.Pieces(promoted)&^to.ToBB())
+		bbs.setPieces(piece, bbs.Pieces(piece)|from.ToBB())
+	default:
+		bbs.moveUnsafePiece(piece, to, from)
+	}
+
+	if captured != Empty {
+		captureSq := to
+		if move.IsEnPassant() {
+			captureSq = enPassantCaptureSquare(to, side)
+		}
+		bbs.setPieces(captured, bbs.Pieces(captured)|captureSq.ToBB())
+	}
+
+	bbs.UpdateAggregate()
+}
+
+// enPassantCaptureSquare returns the square of the pawn captured by an en passant move of side to the square to
+func enPassantCaptureSquare(to Square, side Color) Square {
+	if side == White {
+		return to - 8
+	}
+	return to + 8
+}
+
+func hasAnyLegalMove(bb *BitBoards, side Color, castlingRights int, enPassant Square, ci *castlingInfo) bool {
 	enemy := side.Opposite()
 	allyPieces := bb.OccupiedBy(side)
 	enemyPieces := bb.OccupiedBy(enemy)
@@ -459,7 +534,7 @@ func hasAnyLegalMove(bb *BitBoards, side Color, castlingRights int, enPassant Sq
 			to := popLSB(&kingMoves)
 			bbCopy := bb.Copy()
 			move := NewMove(uint32(kingSq), uint32(to), uint32(encodedPieceIndex(getPieceBySide(King, side))), 0, 0, 0, 0, 0)
-			makeUnsafeMove(bbCopy, move)
+			makeUnsafeMove(bbCopy, move, ci)
 			if !IsKingAttacked(side, bbCopy) {
 				return true
 			}
@@ -511,45 +586,6 @@ func hasAnyLegalMove(bb *BitBoards, side Color, castlingRights int, enPassant Sq
 				moves = QueenAttacks(from, allPieces) & ^allyPieces
 			case King:
 				moves = KingAttacks(from) & ^allyPieces
-				// kiểm tra nhập thành
-				// castling
-				if side == White {
-					// short castle (O-O)
-					if castlingRights&WK == WK &&
-						(bb.AllPieces&(SquareF1.ToBB()|SquareG1.ToBB())) == 0 && // đường trống
-						!IsAttacked(SquareE1, White, bb) &&
-						!IsAttacked(SquareF1, White, bb) &&
-						!IsAttacked(SquareG1, White, bb) {
-						moves |= SquareG1.ToBB()
-					}
-
-					// long castle (O-O-O)
-					if castlingRights&WQ == WQ &&
-						(bb.AllPieces&(SquareB1.ToBB()|SquareC1.ToBB()|SquareD1.ToBB())) == 0 &&
-						!IsAttacked(SquareE1, White, bb) &&
-						!IsAttacked(SquareD1, White, bb) &&
-						!IsAttacked(SquareC1, White, bb) {
-						moves |= SquareD1.ToBB()
-					}
-				} else {
-					// short castle (O-O)
-					if castlingRights&BK == BK &&
-						(bb.AllPieces&(SquareF8.ToBB()|SquareG8.ToBB())) == 0 && // đường trống
-						!IsAttacked(SquareE8, Black, bb) &&
-						!IsAttacked(SquareF8, Black, bb) &&
-						!IsAttacked(SquareG8, Black, bb) {
-						moves |= SquareG8.ToBB()
-					}
-
-					// long castle (O-O-O)
-					if castlingRights&BQ == BQ &&
-						(bb.AllPieces&(SquareB8.ToBB()|SquareC8.ToBB()|SquareD8.ToBB())) == 0 &&
-						!IsAttacked(SquareE8, Black, bb) &&
-						!IsAttacked(SquareD8, Black, bb) &&
-						!IsAttacked(SquareC8, Black, bb) {
-						moves |= SquareD8.ToBB()
-					}
-				}
 			}
 
 			for moves != 0 {
@@ -558,7 +594,6 @@ func hasAnyLegalMove(bb *BitBoards, side Color, castlingRights int, enPassant Sq
 				isCapture := (enemyPieces>>to)&1 == 1
 				isDoublePush := false
 				isEnPassant := false
-				isCastle := false
 
 				switch pieceType {
 				case Pawn:
@@ -575,12 +610,6 @@ func hasAnyLegalMove(bb *BitBoards, side Color, castlingRights int, enPassant Sq
 						isEnPassant = true
 						isCapture = true
 					}
-
-				case King:
-					// castling
-					if abs(int(to)-int(from)) == 2 {
-						isCastle = true
-					}
 				}
 
 				move := NewMove(
@@ -591,11 +620,11 @@ func hasAnyLegalMove(bb *BitBoards, side Color, castlingRights int, enPassant Sq
 					boolToUint32(isCapture),
 					boolToUint32(isDoublePush),
 					boolToUint32(isEnPassant),
-					boolToUint32(isCastle),
+					0, // castle
 				)
 
 				bbCopy := bb.Copy()
-				makeUnsafeMove(bbCopy, move)
+				makeUnsafeMove(bbCopy, move, ci)
 
 				if !IsKingAttacked(side, bbCopy) {
 					return true
@@ -604,5 +633,17 @@ func hasAnyLegalMove(bb *BitBoards, side Color, castlingRights int, enPassant Sq
 		}
 	}
 
+	// castling
+	var castlingMoves MoveList
+	generateCastlingMoves(bb, side, castlingRights, ci, &castlingMoves)
+	for _, move := range castlingMoves {
+		bbCopy := bb.Copy()
+		makeUnsafeMove(bbCopy, move, ci)
+
+		if !IsKingAttacked(side, bbCopy) {
+			return true
+		}
+	}
+
 	return false
 }
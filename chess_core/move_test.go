@@ -5,3 +5,48 @@ import "testing"
 func TestGeneratePawnMoves(t *testing.T) {
 
 }
+
+func TestLegalMoves(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		want int
+	}{
+		{"initial position", StartingFEN, 20},
+		{"knights only", "4k3/8/8/8/3N4/8/8/4K3 w - - 0 1", 13},
+		{"pinned knight", "4k3/4r3/8/8/8/8/4N3/4K3 w - - 0 1", 4},
+		{"promotion capture", "1r2k3/P7/8/8/8/8/8/4K3 w - - 0 1", 13},
+		{"checkmate", "rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs := NewGame()
+			if err := gs.FromFEN(tt.fen); err != nil {
+				t.Fatal(err)
+			}
+
+			moves := gs.LegalMoves()
+			if moves.Len() != tt.want {
+				t.Errorf("LegalMoves() = %d moves, want %d", moves.Len(), tt.want)
+			}
+		})
+	}
+}
+
+func TestLegalMovesFrom(t *testing.T) {
+	gs := NewGame()
+	if err := gs.FromFEN(StartingFEN); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(gs.LegalMovesFrom(SquareG1)); n != 2 {
+		t.Errorf("LegalMovesFrom(g1) = %d moves, want 2", n)
+	}
+	if n := len(gs.LegalMovesFrom(SquareE2)); n != 2 {
+		t.Errorf("LegalMovesFrom(e2) = %d moves, want 2", n)
+	}
+	if n := len(gs.LegalMovesFrom(SquareE7)); n != 0 {
+		t.Errorf("LegalMovesFrom(e7) = %d moves, want 0", n)
+	}
+}
@@ -0,0 +1,130 @@
+// perft - performance test, move path enumeration
+//
+// references:
+// - https://www.chessprogramming.org/Perft
+// - https://www.chessprogramming.org/Perft_Results
+
+package chess_core
+
+// Perft counts the leaf nodes of the legal move tree of the given depth from the position
+func (p Position) Perft(depth int) uint64 {
+	if p.variant != nil {
+		b := p.board()
+		return variantPerft(p.variant, &b, depth)
+	}
+
+	return perft(&p.bb, p.sideToMove, p.castlingRights, p.enPassantSquare, p.castlingSquares(), depth)
+}
+
+// PerftDivide is like Perft but returns the leaf node count below every root move
+func (p Position) PerftDivide(depth int) map[Move]uint64 {
+	result := make(map[Move]uint64)
+	if depth <= 0 {
+		return result
+	}
+
+	if p.variant != nil {
+		b := p.board()
+
+		ml := make(MoveList, 0, 64)
+		p.variant.GenerateMoves(&b, &ml)
+		for _, move := range ml {
+			next := b.Copy()
+			p.variant.MakeMove(&next, move)
+			result[move] = variantPerft(p.variant, &next, depth-1)
+		}
+
+		return result
+	}
+
+	ci := p.castlingSquares()
+
+	ml := make(MoveList, 0, 64)
+	generateLegalMoves(&p.bb, p.sideToMove, p.castlingRights, p.enPassantSquare, ci, &ml)
+
+	for _, move := range ml {
+		captured, _ := makeUnsafeMove(&p.bb, move, ci)
+
+		castling, enPassant := nextCastlingAndEnPassant(move, p.castlingRights, ci)
+		result[move] = perft(&p.bb, p.sideToMove.Opposite(), castling, enPassant, ci, depth-1)
+
+		unmakeUnsafeMove(&p.bb, move, captured, ci)
+	}
+
+	return result
+}
+
+// Perft counts the leaf nodes of the legal move tree of the given depth from the current position
+func (gs *GameState) Perft(depth int) uint64 {
+	return gs.Position().Perft(depth)
+}
+
+// PerftDivide is like Perft but returns the leaf node count below every root move
+func (gs *GameState) PerftDivide(depth int) map[Move]uint64 {
+	return gs.Position().PerftDivide(depth)
+}
+
+func perft(bb *BitBoards, side Color, castling int, enPassantSquare Square, ci *castlingInfo, depth int) uint64 {
+	if depth <= 0 {
+		return 1
+	}
+
+	ml := make(MoveList, 0, 64)
+	generateLegalMoves(bb, side, castling, enPassantSquare, ci, &ml)
+
+	if depth == 1 {
+		return uint64(len(ml))
+	}
+
+	var nodes uint64
+	for _, move := range ml {
+		captured, _ := makeUnsafeMove(bb, move, ci)
+
+		nextCastling, nextEnPassant := nextCastlingAndEnPassant(move, castling, ci)
+		nodes += perft(bb, side.Opposite(), nextCastling, nextEnPassant, ci, depth-1)
+
+		unmakeUnsafeMove(bb, move, captured, ci)
+	}
+
+	return nodes
+}
+
+// nextCastlingAndEnPassant returns the castling rights and en passant square after the move
+func nextCastlingAndEnPassant(move Move, castling int, ci *castlingInfo) (int, Square) {
+	castling &= ci.mask[move.From()]
+	castling &= ci.mask[move.To()]
+
+	enPassant := NoEnPassant
+	if move.IsDoublePush() {
+		if move.Side() == White {
+			enPassant = Square(move.To()) - 8
+		} else {
+			enPassant = Square(move.To()) + 8
+		}
+	}
+
+	return castling, enPassant
+}
+
+// variantPerft is like perft with the rules of the variant, the boards are copied for every move
+func variantPerft(v Variant, b *Board, depth int) uint64 {
+	if depth <= 0 {
+		return 1
+	}
+
+	ml := make(MoveList, 0, 64)
+	v.GenerateMoves(b, &ml)
+
+	if depth == 1 {
+		return uint64(len(ml))
+	}
+
+	var nodes uint64
+	for _, move := range ml {
+		next := b.Copy()
+		v.MakeMove(&next, move)
+		nodes += variantPerft(v, &next, depth-1)
+	}
+
+	return nodes
+}
@@ -0,0 +1,121 @@
+package chess_core
+
+import "testing"
+
+var perftPositions = []struct {
+	name  string
+	fen   string
+	nodes []uint64 // nodes[i] is the node count at depth i+1
+}{
+	{
+		name:  "initial",
+		fen:   StartingFEN,
+		nodes: []uint64{20, 400, 8902, 197281},
+	},
+	{
+		name:  "kiwipete",
+		fen:   "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		nodes: []uint64{48, 2039, 97862, 4085603},
+	},
+	{
+		name:  "position 3",
+		fen:   "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+		nodes: []uint64{14, 191, 2812, 43238, 674624},
+	},
+	{
+		name:  "position 4",
+		fen:   "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+		nodes: []uint64{6, 264, 9467, 422333},
+	},
+	{
+		name:  "position 4 mirrored",
+		fen:   "r2q1rk1/pP1p2pp/Q4n2/bbp1p3/Np6/1B3NBn/pPPP1PPP/R3K2R b KQ - 0 1",
+		nodes: []uint64{6, 264, 9467, 422333},
+	},
+	{
+		name:  "position 5",
+		fen:   "rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8",
+		nodes: []uint64{44, 1486, 62379, 2103487},
+	},
+	{
+		name:  "position 6",
+		fen:   "r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10",
+		nodes: []uint64{46, 2079, 89890, 3894594},
+	},
+	{
+		name:  "chess960 1",
+		fen:   "bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w HFhf - 2 9",
+		nodes: []uint64{21, 528, 12189, 326672},
+	},
+	{
+		name:  "chess960 2",
+		fen:   "2nnrbkr/p1qppppp/8/1ppb4/6PP/3PP3/PPP2P2/BQNNRBKR w HEhe - 1 9",
+		nodes: []uint64{21, 807, 18002, 667366},
+	},
+	{
+		name:  "chess960 3",
+		fen:   "b1q1rrkb/pppppppp/3nn3/8/P7/1PPP4/4PPPP/BQNNRKRB w GE - 1 9",
+		nodes: []uint64{20, 479, 10471, 273318},
+	},
+	{
+		name:  "chess960 4",
+		fen:   "qbbnnrkr/2pp2pp/p7/1p2pp2/8/P3PP2/1PPP1KPP/QBBNNR1R w hf - 0 9",
+		nodes: []uint64{22, 593, 13440, 382958},
+	},
+	{
+		name:  "chess960 5",
+		fen:   "1nbbnrkr/p1p1ppp1/3p4/1p3P1p/3Pq2P/8/PPP1P1P1/QNBBNRKR w HFhf - 0 9",
+		nodes: []uint64{28, 1120, 31058, 1171749},
+	},
+}
+
+func TestPerft(t *testing.T) {
+	for _, pos := range perftPositions {
+		t.Run(pos.name, func(t *testing.T) {
+			gs := NewGame()
+			if err := gs.FromFEN(pos.fen); err != nil {
+				t.Fatal(err)
+			}
+
+			for i, want := range pos.nodes {
+				depth := i + 1
+				if testing.Short() && want > 100000 {
+					break
+				}
+
+				if got := gs.Perft(depth); got != want {
+					t.Errorf("Perft(%d) = %d, want %d", depth, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPerftDivide(t *testing.T) {
+	gs := NewGame()
+	if err := gs.FromFEN(StartingFEN); err != nil {
+		t.Fatal(err)
+	}
+
+	divide := gs.PerftDivide(3)
+	if len(divide) != 20 {
+		t.Fatalf("PerftDivide(3) returned %d root moves, want 20", len(divide))
+	}
+
+	var total uint64
+	for _, nodes := range divide {
+		total += nodes
+	}
+	if total != 8902 {
+		t.Errorf("PerftDivide(3) sums to %d, want 8902", total)
+	}
+}
+
+func BenchmarkPerft(b *testing.B) {
+	gs := NewGame()
+	gs.FromFEN(StartingFEN)
+
+	for b.Loop() {
+		gs.Perft(3)
+	}
+}
@@ -0,0 +1,374 @@
+package pgn
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	chess "github.com/tommjj/chess_OG/chess_core"
+)
+
+// suffix annotations and their NAG values
+var suffixNAGs = map[string]int{
+	"!":  chess.NAGGood,
+	"?":  chess.NAGMistake,
+	"!!": chess.NAGBrilliant,
+	"??": chess.NAGBlunder,
+	"!?": chess.NAGInteresting,
+	"?!": chess.NAGDubious,
+}
+
+// Parse reads all games of a PGN file
+func Parse(r io.Reader) ([]*Game, error) {
+	data, err := io.ReadAll(bufio.NewReader(r))
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{src: []rune(string(data)), line: 1}
+
+	var games []*Game
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return games, nil
+		}
+
+		g, err := p.parseGame()
+		if err != nil {
+			return games, fmt.Errorf("game %d line %d: %w", len(games)+1, p.line, err)
+		}
+		games = append(games, g)
+	}
+}
+
+// ParseString is like Parse but reads from a string
+func ParseString(s string) ([]*Game, error) {
+	return Parse(strings.NewReader(s))
+}
+
+type parser struct {
+	src  []rune
+	pos  int
+	line int
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *parser) peek() rune {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *parser) next() rune {
+	r := p.src[p.pos]
+	p.pos++
+	if r == '\n' {
+		p.line++
+	}
+	return r
+}
+
+// skipSpace skips white spaces and escape lines, lines starting with '%'
+func (p *parser) skipSpace() {
+	for !p.eof() {
+		r := p.peek()
+		switch {
+		case r == '%' && (p.pos == 0 || p.src[p.pos-1] == '\n'):
+			for !p.eof() && p.peek() != '\n' {
+				p.next()
+			}
+		case r == ' ' || r == '\t' || r == '\r' || r == '\n' || r == '\uFEFF':
+			p.next()
+		default:
+			return
+		}
+	}
+}
+
+func (p *parser) parseGame() (*Game, error) {
+	g := &Game{Result: Unknown}
+
+	// tag pair section
+	for p.skipSpace(); p.peek() == '['; p.skipSpace() {
+		tag, err := p.parseTag()
+		if err != nil {
+			return nil, err
+		}
+		g.Tags = append(g.Tags, tag)
+	}
+
+	gs, err := g.newGameState()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.parseMovetext(g, gs); err != nil {
+		return nil, err
+	}
+
+	if _, ok := g.Tags.Get("Result"); !ok {
+		g.Tags.Set("Result", g.Result)
+	}
+
+	return g, nil
+}
+
+// parseTag parses a tag pair, ex: [Event "F/S Return Match"]
+func (p *parser) parseTag() (Tag, error) {
+	p.next() // '['
+	p.skipSpace()
+
+	var name strings.Builder
+	for !p.eof() {
+		r := p.peek()
+		if r == ' ' || r == '\t' || r == '"' || r == ']' {
+			break
+		}
+		name.WriteRune(p.next())
+	}
+	if name.Len() == 0 {
+		return Tag{}, ErrInvalidTag
+	}
+
+	p.skipSpace()
+	if p.eof() || p.next() != '"' {
+		return Tag{}, ErrInvalidTag
+	}
+
+	var value strings.Builder
+	for {
+		if p.eof() {
+			return Tag{}, ErrInvalidTag
+		}
+		r := p.next()
+		if r == '"' {
+			break
+		}
+		if r == '\\' && !p.eof() {
+			r = p.next()
+		}
+		value.WriteRune(r)
+	}
+
+	p.skipSpace()
+	if p.eof() || p.next() != ']' {
+		return Tag{}, ErrInvalidTag
+	}
+
+	return Tag{Name: name.String(), Value: value.String()}, nil
+}
+
+// lineState is the state of the line being parsed, the main line or a variation
+type lineState struct {
+	line *Line
+
+	gs   *chess.GameState // position after the last move of the line
+	prev *chess.GameState // position before the last move of the line
+
+	// comments waiting for the next move of the line
+	pending []string
+}
+
+func (p *parser) parseMovetext(g *Game, gs *chess.GameState) error {
+	stack := []*lineState{{line: &g.Moves, gs: gs}}
+
+	for {
+		p.skipSpace()
+		if p.eof() || p.peek() == '[' {
+			if len(stack) > 1 {
+				return ErrUnclosedPart
+			}
+			// a game without termination marker
+			g.Comments = append(g.Comments, stack[0].pending...)
+			return nil
+		}
+
+		cur := stack[len(stack)-1]
+
+		switch r := p.peek(); {
+		case r == '{':
+			comment, err := p.parseComment()
+			if err != nil {
+				return err
+			}
+			cur.addComment(comment)
+
+		case r == ';':
+			p.next()
+			var comment strings.Builder
+			for !p.eof() && p.peek() != '\n' {
+				comment.WriteRune(p.next())
+			}
+			cur.addComment(strings.TrimSpace(comment.String()))
+
+		case r == '(':
+			p.next()
+			if len(*cur.line) == 0 {
+				return fmt.Errorf("%w: variation without a move", ErrInvalidPGN)
+			}
+
+			last := (*cur.line)[len(*cur.line)-1]
+			last.Variations = append(last.Variations, nil)
+			stack = append(stack, &lineState{
+				line: &last.Variations[len(last.Variations)-1],
+				gs:   cur.prev.Copy(),
+			})
+
+		case r == ')':
+			p.next()
+			if len(stack) == 1 {
+				return fmt.Errorf("%w: unexpected ')'", ErrInvalidPGN)
+			}
+			if len(*cur.line) == 0 {
+				return fmt.Errorf("%w: empty variation", ErrInvalidPGN)
+			}
+			if len(cur.pending) > 0 {
+				last := (*cur.line)[len(*cur.line)-1]
+				last.Comments = append(last.Comments, cur.pending...)
+			}
+			stack = stack[:len(stack)-1]
+
+		case r == '$':
+			p.next()
+			nag, err := strconv.Atoi(p.readSymbol())
+			if err != nil || len(*cur.line) == 0 {
+				return fmt.Errorf("%w: invalid NAG", ErrInvalidPGN)
+			}
+			last := (*cur.line)[len(*cur.line)-1]
+			last.NAGs = append(last.NAGs, nag)
+
+		default:
+			symbol := p.readSymbol()
+			if symbol == "" {
+				return fmt.Errorf("%w: unexpected character %q", ErrInvalidPGN, p.next())
+			}
+
+			switch symbol {
+			case WhiteWins, BlackWins, Draw, Unknown:
+				if len(stack) > 1 {
+					return ErrUnclosedPart
+				}
+				g.Result = symbol
+				g.Comments = append(g.Comments, cur.pending...)
+				return nil
+			}
+
+			if nag, ok := suffixNAGs[symbol]; ok {
+				if len(*cur.line) == 0 {
+					return fmt.Errorf("%w: annotation without a move", ErrInvalidPGN)
+				}
+				last := (*cur.line)[len(*cur.line)-1]
+				last.NAGs = append(last.NAGs, nag)
+				continue
+			}
+
+			if isMoveNumber(symbol) {
+				continue
+			}
+
+			if err := cur.addMove(symbol); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// parseComment parses a brace comment, ex: {the best move}
+func (p *parser) parseComment() (string, error) {
+	p.next() // '{'
+
+	var comment strings.Builder
+	for {
+		if p.eof() {
+			return "", ErrUnclosedPart
+		}
+		r := p.next()
+		if r == '}' {
+			break
+		}
+		comment.WriteRune(r)
+	}
+
+	return strings.Join(strings.Fields(comment.String()), " "), nil
+}
+
+// readSymbol reads a symbol token, move numbers, SAN moves and results are symbols
+func (p *parser) readSymbol() string {
+	var symbol strings.Builder
+	for !p.eof() {
+		r := p.peek()
+		if r == ' ' || r == '\t' || r == '\r' || r == '\n' ||
+			r == '{' || r == '}' || r == '(' || r == ')' || r == ';' || r == '$' || r == '[' {
+			break
+		}
+		symbol.WriteRune(p.next())
+	}
+	return symbol.String()
+}
+
+// isMoveNumber checks move number indications, ex: "12." or "12..."
+func isMoveNumber(s string) bool {
+	digits := strings.TrimRight(s, ".")
+	if digits == "" {
+		return s != "" // "..." alone
+	}
+	_, err := strconv.Atoi(digits)
+	return err == nil
+}
+
+func (l *lineState) addComment(comment string) {
+	if comment == "" {
+		return
+	}
+	if len(*l.line) == 0 {
+		l.pending = append(l.pending, comment)
+		return
+	}
+	last := (*l.line)[len(*l.line)-1]
+	last.Comments = append(last.Comments, comment)
+}
+
+// addMove parses a SAN move with its optional suffix annotation and plays it on the line
+func (l *lineState) addMove(symbol string) error {
+	// a move number glued to the move, ex: "1.e4"
+	if i := strings.LastIndexByte(symbol, '.'); i >= 0 && isMoveNumber(symbol[:i+1]) {
+		symbol = symbol[i+1:]
+	}
+
+	san := strings.TrimRight(symbol, "!?")
+	node := &Node{CommentsBefore: l.pending}
+	l.pending = nil
+
+	if suffix := symbol[len(san):]; suffix != "" {
+		nag, ok := suffixNAGs[suffix]
+		if !ok {
+			return fmt.Errorf("%w: invalid annotation %q", ErrInvalidPGN, suffix)
+		}
+		node.NAGs = append(node.NAGs, nag)
+	}
+
+	move, err := l.gs.ParseSAN(san)
+	if err != nil && handOverSAN(l.gs, san) {
+		move, err = l.gs.ParseSAN(san)
+	}
+	if err != nil {
+		return fmt.Errorf("%w %q: %w", ErrIllegalMove, san, err)
+	}
+	node.Move = move
+	node.SAN = l.gs.MoveToSAN(move)
+
+	l.prev = l.gs.Copy()
+	if err := playMove(l.gs, move); err != nil {
+		return err
+	}
+
+	*l.line = append(*l.line, node)
+	return nil
+}
@@ -0,0 +1,283 @@
+// Package pgn reads and writes chess games in Portable Game Notation (PGN)
+//
+// references:
+// - https://www.chessprogramming.org/Portable_Game_Notation
+// - http://www.saremba.de/chessgml/standards/pgn/pgn-complete.htm
+
+package pgn
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	chess "github.com/tommjj/chess_OG/chess_core"
+)
+
+var (
+	ErrInvalidPGN   = errors.New("invalid PGN")
+	ErrInvalidTag   = errors.New("invalid PGN tag pair")
+	ErrIllegalMove  = errors.New("illegal move in PGN movetext")
+	ErrUnclosedPart = errors.New("unclosed comment or variation in PGN movetext")
+)
+
+// game results
+const (
+	WhiteWins = "1-0"
+	BlackWins = "0-1"
+	Draw      = "1/2-1/2"
+	Unknown   = "*"
+)
+
+// Seven Tag Roster, the tags every exported game must have, in export order
+var SevenTagRoster = []string{"Event", "Site", "Date", "Round", "White", "Black", "Result"}
+
+// values of the Variant tag by variant name, standard games have no Variant tag
+var variantTags = map[string]string{
+	"kingofthehill": "King of the Hill",
+	"threecheck":    "Three-check",
+	"antichess":     "Antichess",
+	"atomic":        "Atomic",
+	"horde":         "Horde",
+	"crazyhouse":    "Crazyhouse",
+	"bughouse":      "Bughouse",
+}
+
+// Tag is a PGN tag pair, ex: [Event "F/S Return Match"]
+type Tag struct {
+	Name  string
+	Value string
+}
+
+// Tags is an ordered list of tag pairs
+type Tags []Tag
+
+// Get returns the value of the tag name
+func (t Tags) Get(name string) (string, bool) {
+	for _, tag := range t {
+		if tag.Name == name {
+			return tag.Value, true
+		}
+	}
+	return "", false
+}
+
+// Set sets the value of the tag name, adding the tag if it does not exist
+func (t *Tags) Set(name, value string) {
+	for i := range *t {
+		if (*t)[i].Name == name {
+			(*t)[i].Value = value
+			return
+		}
+	}
+	*t = append(*t, Tag{Name: name, Value: value})
+}
+
+// Del removes the tag name
+func (t *Tags) Del(name string) {
+	for i := range *t {
+		if (*t)[i].Name == name {
+			*t = append((*t)[:i], (*t)[i+1:]...)
+			return
+		}
+	}
+}
+
+// Node is a move of the game with its annotations
+type Node struct {
+	Move chess.Move
+	SAN  string
+
+	// numeric annotation glyphs, ex: 1 for "!", 2 for "?"
+	NAGs []int
+
+	// comments written before the move, only found at the start of a game or a variation
+	CommentsBefore []string
+	// comments written after the move
+	Comments []string
+
+	// alternatives to this move, each line starts from the position before Move
+	Variations []Line
+}
+
+// Line is a sequence of moves
+type Line []*Node
+
+// Game is a PGN game
+type Game struct {
+	Tags Tags
+
+	// Moves is the main line
+	Moves Line
+
+	// comments written after the last move, before the result
+	Comments []string
+
+	// game termination marker, one of WhiteWins, BlackWins, Draw or Unknown
+	Result string
+}
+
+// NewGame builds a game from a start position and the moves played from it
+//
+//	startFEN: start position, chess.StartingFEN if empty
+//	moves: main line moves
+func NewGame(startFEN string, moves []chess.Move) (*Game, error) {
+	return NewVariantGame(nil, startFEN, moves)
+}
+
+// NewVariantGame is like NewGame with the rules of the variant, nil for standard chess.
+// the start position is the start position of the variant if startFEN is empty
+func NewVariantGame(v chess.Variant, startFEN string, moves []chess.Move) (*Game, error) {
+	if v == nil {
+		v = chess.Standard{}
+	}
+	if startFEN == "" {
+		startFEN = v.StartingFEN()
+	}
+
+	gs := chess.NewGame()
+	gs.SetVariant(v)
+	if err := gs.FromFEN(startFEN); err != nil {
+		return nil, err
+	}
+
+	g := &Game{Result: Unknown}
+	for _, move := range moves {
+		if err := handOver(gs, move); err != nil {
+			return nil, err
+		}
+		san := gs.MoveToSAN(move)
+		if err := playMove(gs, move); err != nil {
+			return nil, err
+		}
+		g.Moves = append(g.Moves, &Node{Move: move, SAN: san})
+	}
+
+	g.setStartTags(v, startFEN)
+
+	return g, nil
+}
+
+// setStartTags sets the Result tag, the Variant tag of a variant game
+// and the SetUp and FEN tags of a game not starting from the start position of its variant
+func (g *Game) setStartTags(v chess.Variant, startFEN string) {
+	g.Tags.Set("Result", Unknown)
+	if _, ok := v.(chess.Standard); !ok {
+		name, ok := variantTags[v.Name()]
+		if !ok {
+			name = v.Name()
+		}
+		g.Tags.Set("Variant", name)
+	}
+	if startFEN != v.StartingFEN() {
+		g.Tags.Set("SetUp", "1")
+		g.Tags.Set("FEN", startFEN)
+	}
+}
+
+// Variant returns the rules of the game from the Variant tag, standard chess if there is no tag.
+// Chess960 games are played with the standard rules
+func (g *Game) Variant() (chess.Variant, error) {
+	name, _ := g.Tags.Get("Variant")
+	name = strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(name))
+
+	switch name {
+	case "", "standard", "chess960", "fischerandom", "fromposition":
+		return chess.Standard{}, nil
+	}
+	return chess.VariantByName(name)
+}
+
+// StartFEN returns the start position of the game from the FEN tag, the start position of the variant if there is no tag
+func (g *Game) StartFEN() string {
+	if fen, ok := g.Tags.Get("FEN"); ok {
+		return fen
+	}
+	if v, err := g.Variant(); err == nil {
+		return v.StartingFEN()
+	}
+	return chess.StartingFEN
+}
+
+// newGameState returns a game at the start position with the rules of the variant of the game
+func (g *Game) newGameState() (*chess.GameState, error) {
+	v, err := g.Variant()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTag, err)
+	}
+
+	gs := chess.NewGame()
+	gs.SetVariant(v)
+	if err := gs.FromFEN(g.StartFEN()); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTag, err)
+	}
+	return gs, nil
+}
+
+// GameState replays the main line and returns the resulting game state with full history
+func (g *Game) GameState() (*chess.GameState, error) {
+	gs, err := g.newGameState()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, node := range g.Moves {
+		if err := playMove(gs, node.Move); err != nil {
+			return nil, err
+		}
+	}
+
+	return gs, nil
+}
+
+// playMove makes an already validated move on gs
+func playMove(gs *chess.GameState, move chess.Move) error {
+	if err := handOver(gs, move); err != nil {
+		return err
+	}
+	if _, err := gs.PlayMove(move); err != nil {
+		return fmt.Errorf("%w: %w", ErrIllegalMove, err)
+	}
+	return nil
+}
+
+// handedOver returns the piece of a Bughouse drop that is not in the pocket, the piece was handed over
+// from the other board which is not part of the game. PGN only records the drops of a Bughouse board
+func handedOver(pos chess.Position, move chess.Move) (chess.Piece, bool) {
+	if _, ok := pos.Variant().(chess.Bughouse); !ok || !move.IsDrop() {
+		return chess.Empty, false
+	}
+
+	piece := chess.ASCIIPieces[move.Piece()]
+	pockets := pos.Pockets()
+	return piece, pockets[piece.Color()].Count(piece.Type()) == 0
+}
+
+// handOver adds the piece of the move to the pocket of gs if it was handed over, see handedOver
+func handOver(gs *chess.GameState, move chess.Move) error {
+	if piece, ok := handedOver(gs.Position(), move); ok {
+		if err := gs.AddToPocket(piece); err != nil {
+			return fmt.Errorf("%w: %w", ErrIllegalMove, err)
+		}
+	}
+	return nil
+}
+
+// handOverSAN adds the piece of the drop san to the pocket of the side to move of a Bughouse game,
+// it reports whether the piece was added
+func handOverSAN(gs *chess.GameState, san string) bool {
+	letter, _, ok := strings.Cut(san, "@")
+	if _, bughouse := gs.Variant().(chess.Bughouse); !ok || !bughouse || len(letter) > 1 {
+		return false
+	}
+
+	piece := chess.WPawn
+	if letter != "" {
+		piece = chess.Piece(letter[0])
+	}
+	if gs.SideToMove() == chess.Black {
+		piece = chess.Piece(unicode.ToLower(rune(piece)))
+	}
+	return gs.AddToPocket(piece) == nil
+}
@@ -0,0 +1,409 @@
+package pgn
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	chess "github.com/tommjj/chess_OG/chess_core"
+)
+
+const testPGN = `[Event "F/S Return Match"]
+[Site "Belgrade, Serbia JUG"]
+[Date "1992.11.04"]
+[Round "29"]
+[White "Fischer, Robert J."]
+[Black "Spassky, Boris V."]
+[Result "1/2-1/2"]
+
+1. e4 e5 2. Nf3 Nc6 3. Bb5 {This opening is called the Ruy Lopez.} 3... a6
+4. Ba4 Nf6 5. O-O Be7 6. Re1 b5 7. Bb3 d6 8. c3 O-O 9. h3 Nb8 10. d4 Nbd7
+11. c4 c6 12. cxb5 axb5 13. Nc3 Bb7 14. Bg5 b4 15. Nb1 h6 16. Bh4 c5 17. dxe5
+Nxe4 18. Bxe7 Qxe7 19. exd6 Qf6 20. Nbd2 Nxd6 21. Nc4 Nxc4 22. Bxc4 Nb6
+23. Ne5 Rae8 24. Bxf7+ Rxf7 25. Nxf7 Rxe1+ 26. Qxe1 Kxf7 27. Qe3 Qg5 28. Qxg5
+hxg5 29. b3 Ke6 30. a3 Kd6 31. axb4 cxb4 32. Ra5 Nd5 33. f3 Bc8 34. Kf2 Bf5
+35. Ra7 g6 36. Ra6+ Kc5 37. Ke1 Nf4 38. g3 Nxh3 39. Kd2 Kb5 40. Rd6 Kc5 41. Ra6
+Nf2 42. g4 Bd3 43. Re6 1/2-1/2
+
+[Event "Annotated"]
+[Site "?"]
+[Date "????.??.??"]
+[Round "?"]
+[White "?"]
+[Black "?"]
+[Result "1-0"]
+
+{Opening comment} 1. e4! e5 (1... c5 {Sicilian} 2. Nf3 (2. c3 $6) 2... d6) (1...
+e6) 2. Qh5?! Nc6 3. Bc4 Nf6?? ; hangs mate
+4. Qxf7# $1 1-0
+
+[Event "From position"]
+[SetUp "1"]
+[FEN "4k3/P7/8/8/8/8/8/4K3 b - - 0 40"]
+
+40... Kd8 41. a8=Q *
+`
+
+func TestParse(t *testing.T) {
+	games, err := ParseString(testPGN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(games) != 3 {
+		t.Fatalf("Parse() returned %d games, want 3", len(games))
+	}
+
+	fischer := games[0]
+	if len(fischer.Moves) != 85 {
+		t.Errorf("game 1 has %d moves, want 85", len(fischer.Moves))
+	}
+	if fischer.Result != Draw {
+		t.Errorf("game 1 result = %q, want %q", fischer.Result, Draw)
+	}
+	if got := fischer.Moves[4].Comments; len(got) != 1 || got[0] != "This opening is called the Ruy Lopez." {
+		t.Errorf("game 1 move 3 comments = %q", got)
+	}
+
+	annotated := games[1]
+	first := annotated.Moves[0]
+	if len(first.CommentsBefore) != 1 || first.CommentsBefore[0] != "Opening comment" {
+		t.Errorf("comments before first move = %q", first.CommentsBefore)
+	}
+	if len(first.NAGs) != 1 || first.NAGs[0] != 1 {
+		t.Errorf("1. e4! NAGs = %v, want [1]", first.NAGs)
+	}
+
+	reply := annotated.Moves[1]
+	if len(reply.Variations) != 2 {
+		t.Fatalf("1... e5 has %d variations, want 2", len(reply.Variations))
+	}
+	sicilian := reply.Variations[0]
+	if len(sicilian) != 3 || sicilian[0].SAN != "c5" || sicilian[1].Variations[0][0].SAN != "c3" {
+		t.Errorf("unexpected Sicilian variation")
+	}
+	if nags := sicilian[1].Variations[0][0].NAGs; len(nags) != 1 || nags[0] != 6 {
+		t.Errorf("2. c3 $6 NAGs = %v, want [6]", nags)
+	}
+	if got := annotated.Moves[5].Comments; len(got) != 1 || got[0] != "hangs mate" {
+		t.Errorf("line comment = %q", got)
+	}
+	if annotated.Moves[6].SAN != "Qxf7#" {
+		t.Errorf("last move SAN = %q, want %q", annotated.Moves[6].SAN, "Qxf7#")
+	}
+
+	gs, err := annotated.GameState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gs.State() != chess.ResultCheckmate {
+		t.Errorf("GameState().State() = %q, want %q", gs.State(), chess.ResultCheckmate)
+	}
+	if len(gs.History()) != 7 {
+		t.Errorf("GameState() history has %d moves, want 7", len(gs.History()))
+	}
+
+	position := games[2]
+	if position.Moves[1].SAN != "a8=Q+" {
+		t.Errorf("promotion SAN = %q, want %q", position.Moves[1].SAN, "a8=Q+")
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	games, err := ParseString(testPGN)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sb strings.Builder
+	if err := Write(&sb, games...); err != nil {
+		t.Fatal(err)
+	}
+	exported := sb.String()
+
+	again, err := ParseString(exported)
+	if err != nil {
+		t.Fatalf("parse exported PGN: %v\n%s", err, exported)
+	}
+
+	sb.Reset()
+	if err := Write(&sb, again...); err != nil {
+		t.Fatal(err)
+	}
+	if sb.String() != exported {
+		t.Errorf("round trip changed the PGN:\n%s\nwant:\n%s", sb.String(), exported)
+	}
+
+	for _, line := range strings.Split(exported, "\n") {
+		if len(line) > maxLineLength {
+			t.Errorf("line longer than %d characters: %q", maxLineLength, line)
+		}
+	}
+
+	if !strings.Contains(exported, "{Opening comment} 1. e4 $1 e5 (1... c5 {Sicilian} 2. Nf3 (2. c3 $6) 2... d6)") {
+		t.Errorf("unexpected movetext:\n%s", exported)
+	}
+	if !strings.Contains(exported, "40... Kd8 41. a8=Q+ *") {
+		t.Errorf("unexpected movetext:\n%s", exported)
+	}
+
+	// a comment with a closing brace is kept
+	g := games[1]
+	g.Moves[0].Comments = []string{"the } brace"}
+	g.Moves[1].Variations[0][0].CommentsBefore = []string{"a {set}"}
+	again, err = ParseString(g.String())
+	if err != nil {
+		t.Fatalf("parse exported PGN: %v\n%s", err, g.String())
+	}
+	if got := again[0].Moves[0]; !slices.Equal(got.Comments, g.Moves[0].Comments) ||
+		!slices.Equal(again[0].Moves[1].Variations[0][0].CommentsBefore, g.Moves[1].Variations[0][0].CommentsBefore) {
+		t.Errorf("comments = %q %q, want %q %q\n%s", got.Comments, again[0].Moves[1].Variations[0][0].CommentsBefore,
+			g.Moves[0].Comments, g.Moves[1].Variations[0][0].CommentsBefore, g.String())
+	}
+}
+
+func TestNewGame(t *testing.T) {
+	gs := chess.NewGame()
+	if err := gs.FromFEN(chess.StartingFEN); err != nil {
+		t.Fatal(err)
+	}
+	for _, uci := range []string{"f2f3", "e7e5", "g2g4", "d8h4"} {
+		if _, err := gs.MakeMoveUCI(gs.SideToMove(), uci); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var moves []chess.Move
+	for _, h := range gs.History() {
+		moves = append(moves, h.Move)
+	}
+
+	g, err := NewGame("", moves)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Result = BlackWins
+
+	want := "1. f3 e5 2. g4 Qh4# 0-1\n"
+	if got := g.String(); !strings.HasSuffix(got, want) {
+		t.Errorf("String() =\n%s\nwant suffix %q", got, want)
+	}
+}
+
+func TestVariantRoundTrip(t *testing.T) {
+	tests := []struct {
+		variant chess.Variant
+		tag     string
+		moves   []string
+	}{
+		{chess.Horde{}, "Horde", []string{"e4e5", "d7d6"}},
+		{chess.Atomic{}, "Atomic", []string{"e2e4", "d7d5", "e4d5"}},
+		{chess.ThreeCheck{}, "Three-check", []string{"e2e4", "f7f6", "d1h5"}},
+	}
+
+	for _, tt := range tests {
+		gs, err := chess.NewVariantGame(tt.variant)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var moves []chess.Move
+		for _, uci := range tt.moves {
+			if _, err := gs.MakeMoveUCI(gs.SideToMove(), uci); err != nil {
+				t.Fatalf("%s: %s: %v", tt.tag, uci, err)
+			}
+		}
+		for _, h := range gs.History() {
+			moves = append(moves, h.Move)
+		}
+
+		g, err := NewVariantGame(tt.variant, "", moves)
+		if err != nil {
+			t.Fatalf("%s: NewVariantGame() error: %v", tt.tag, err)
+		}
+		if tag, _ := g.Tags.Get("Variant"); tag != tt.tag || g.StartFEN() != tt.variant.StartingFEN() {
+			t.Errorf("%s: Variant tag = %q, start %q", tt.tag, tag, g.StartFEN())
+		}
+
+		games, err := ParseString(g.String())
+		if err != nil {
+			t.Fatalf("%s: Parse() error: %v\n%s", tt.tag, err, g.String())
+		}
+		replay, err := games[0].GameState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if replay.Variant() != tt.variant || replay.ToFEN() != gs.ToFEN() {
+			t.Errorf("%s: replay = %s %q, want %q", tt.tag, replay.Variant().Name(), replay.ToFEN(), gs.ToFEN())
+		}
+	}
+}
+
+func TestDropRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		variant chess.Variant
+		start   string
+		moves   []string
+		handed  map[int]chess.Piece // pieces handed over before the move of the index
+	}{
+		{"crazyhouse", chess.Crazyhouse{}, "", []string{"e2e4", "d7d5", "e4d5", "d8d5", "P@e4", "d5e4"}, nil},
+		{"crazyhouse pockets", chess.Crazyhouse{}, "4k3/8/8/8/8/8/8/4K3[QRn] b - - 0 1", []string{"N@e3", "Q@d2", "e8f7"}, nil},
+		{"bughouse", chess.Bughouse{}, "", []string{"e2e4", "e7e5", "N@f3", "N@c6"}, map[int]chess.Piece{2: chess.WKnight, 3: chess.BKnight}},
+	}
+
+	for _, tt := range tests {
+		start := tt.start
+		if start == "" {
+			start = tt.variant.StartingFEN()
+		}
+		gs := chess.NewGame()
+		gs.SetVariant(tt.variant)
+		if err := gs.FromFEN(start); err != nil {
+			t.Fatal(err)
+		}
+		for i, uci := range tt.moves {
+			if piece, ok := tt.handed[i]; ok {
+				if err := gs.AddToPocket(piece); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, err := gs.MakeMoveUCI(gs.SideToMove(), uci); err != nil {
+				t.Fatalf("%s: %s: %v", tt.name, uci, err)
+			}
+		}
+		var moves []chess.Move
+		for _, h := range gs.History() {
+			moves = append(moves, h.Move)
+		}
+
+		g, err := NewVariantGame(tt.variant, tt.start, moves)
+		if err != nil {
+			t.Fatalf("%s: NewVariantGame() error: %v", tt.name, err)
+		}
+		if g.StartFEN() != start {
+			t.Errorf("%s: StartFEN() = %q, want %q", tt.name, g.StartFEN(), start)
+		}
+
+		games, err := ParseString(g.String())
+		if err != nil {
+			t.Fatalf("%s: Parse() error: %v\n%s", tt.name, err, g.String())
+		}
+		replay, err := games[0].GameState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if replay.ToFEN() != gs.ToFEN() {
+			t.Errorf("%s: replay = %q, want %q\n%s", tt.name, replay.ToFEN(), gs.ToFEN(), g.String())
+		}
+
+		tree, err := games[0].Tree()
+		if err != nil {
+			t.Fatalf("%s: Tree() error: %v", tt.name, err)
+		}
+		if last := tree.Mainline(); last[len(last)-1].Position().FEN() != gs.ToFEN() {
+			t.Errorf("%s: Tree() ends in %q, want %q", tt.name, last[len(last)-1].Position().FEN(), gs.ToFEN())
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		pgn  string
+		want error
+	}{
+		{"illegal move", "1. e4 e4 *", ErrIllegalMove},
+		{"unclosed comment", "1. e4 {oops *", ErrUnclosedPart},
+		{"unclosed variation", "1. e4 (1. d4 *", ErrUnclosedPart},
+		{"bad tag", "[Event Foo]\n1. e4 *", ErrInvalidTag},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseString(tt.pgn); !errors.Is(err, tt.want) {
+				t.Errorf("Parse() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTreeRoundTrip(t *testing.T) {
+	const annotated = `[Event "Blitz"]
+[Site "?"]
+[Date "????.??.??"]
+[Round "?"]
+[White "?"]
+[Black "?"]
+[Result "*"]
+
+1. e4 {[%clk 0:03:00]} 1... e5 {Solid [%clk 0:02:58.5]} (1... c5 $1 {Sicilian}
+2. Nf3 (2. c3 $6) 2... d6) 2. Nf3!? Nc6?? *
+`
+	games, err := ParseString(annotated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := games[0]
+
+	tree, err := g.Tree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mainline := tree.Mainline()
+	if len(mainline) != 4 {
+		t.Fatalf("Tree() main line has %d moves, want 4", len(mainline))
+	}
+	if e5 := mainline[1]; !e5.HasClock || e5.Clock != 2*time.Minute+58500*time.Millisecond || len(e5.Comments) != 1 || e5.Comments[0] != "Solid" {
+		t.Errorf("1... e5 clock = %v %v, comments %q", e5.Clock, e5.HasClock, e5.Comments)
+	}
+	if e4 := mainline[0]; !e4.HasClock || len(e4.Comments) != 0 {
+		t.Errorf("1. e4 clock = %v, comments %q", e4.Clock, e4.Comments)
+	}
+	c5 := mainline[1].Variations()[0]
+	if c5.SAN != "c5" || c5.NAGs[0] != chess.NAGGood || len(c5.Next().Variations()) != 1 {
+		t.Errorf("unexpected Sicilian variation")
+	}
+	if nags := mainline[3].NAGs; len(nags) != 1 || nags[0] != chess.NAGBlunder {
+		t.Errorf("2... Nc6?? NAGs = %v", nags)
+	}
+
+	g.SetTree(tree)
+	if got, want := g.String(), games[0].String(); got != want {
+		t.Errorf("SetTree(Tree()) changed the PGN:\n%s\nwant:\n%s", got, want)
+	}
+	reparsed, _ := ParseString(annotated)
+	if got, want := g.String(), reparsed[0].String(); got != want {
+		t.Errorf("SetTree(Tree()) =\n%s\nwant:\n%s", got, want)
+	}
+
+	// the promoted variation is written as the main line
+	c := tree.Cursor()
+	c.Enter(0)
+	c.Enter(1)
+	c.PromoteToMainline()
+	if got := strings.Join(strings.Fields(FromTree(tree).String()), " "); !strings.Contains(got, "1. e4 {[%clk 0:03:00]} 1... c5 $1 {Sicilian} (1... e5 {Solid [%clk 0:02:58.5]} 2. Nf3 $5 Nc6 $4) 2. Nf3 (2. c3 $6) 2... d6 *") {
+		t.Errorf("FromTree() after PromoteToMainline() =\n%s", got)
+	}
+}
+
+func TestFormatClock(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{time.Hour + 5*time.Minute + 9*time.Second, "1:05:09"},
+		{9*time.Second + 500*time.Millisecond, "0:00:09.5"},
+		{9*time.Second + 20*time.Millisecond, "0:00:09.02"},
+		{9*time.Second + 300*time.Microsecond, "0:00:09"},
+	}
+	for _, tt := range tests {
+		got := formatClock(tt.d)
+		if got != tt.want {
+			t.Errorf("formatClock(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+		if _, _, ok := extractClock([]string{"[%clk " + got + "]"}); !ok {
+			t.Errorf("extractClock() of %q failed", got)
+		}
+	}
+}
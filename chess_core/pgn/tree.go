@@ -0,0 +1,141 @@
+package pgn
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	chess "github.com/tommjj/chess_OG/chess_core"
+)
+
+// clockCommand matches the clock command of a comment, ex: [%clk 1:05:09.5]
+var clockCommand = regexp.MustCompile(`\s*\[%clk\s+(\d+):(\d{1,2}):(\d{1,2}(?:\.\d+)?)\]\s*`)
+
+// Tree returns the movetext of the game as a game tree, the [%clk] commands of the comments become the node clocks
+func (g *Game) Tree() (*chess.GameTree, error) {
+	v, err := g.Variant()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTag, err)
+	}
+	pos, err := chess.ParseVariantPosition(v, g.StartFEN())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTag, err)
+	}
+
+	tree := chess.NewGameTree(pos)
+	tree.Root().Comments = append([]string(nil), g.Comments...)
+
+	if err := addLine(tree.Cursor(), g.Moves); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// addLine plays the line and its variations from the cursor position
+func addLine(c *chess.Cursor, line Line) error {
+	for _, node := range line {
+		parent := c.Node()
+		if piece, ok := handedOver(parent.Position(), node.Move); ok {
+			if err := c.AddToPocket(piece); err != nil {
+				return fmt.Errorf("%w %q: %w", ErrIllegalMove, node.SAN, err)
+			}
+		}
+
+		n, err := c.Play(node.Move)
+		if err != nil {
+			return fmt.Errorf("%w %q: %w", ErrIllegalMove, node.SAN, err)
+		}
+		n.NAGs = append([]int(nil), node.NAGs...)
+		n.CommentsBefore = append([]string(nil), node.CommentsBefore...)
+		n.Comments, n.Clock, n.HasClock = extractClock(node.Comments)
+
+		for _, variation := range node.Variations {
+			_ = c.Goto(parent)
+			if err := addLine(c, variation); err != nil {
+				return err
+			}
+		}
+		_ = c.Goto(n)
+	}
+
+	return nil
+}
+
+// FromTree builds a game from a game tree, see SetTree
+func FromTree(tree *chess.GameTree) *Game {
+	g := &Game{Result: Unknown}
+	start := tree.Root().Position()
+	g.setStartTags(start.Variant(), start.FEN())
+
+	g.SetTree(tree)
+	return g
+}
+
+// SetTree replaces the movetext of the game by the game tree, the tags and the result are kept.
+// the node clocks are written as [%clk] commands at the end of their comments
+func (g *Game) SetTree(tree *chess.GameTree) {
+	root := tree.Root()
+
+	g.Comments = append([]string(nil), root.Comments...)
+	g.Moves = treeLine(root.Next())
+}
+
+// treeLine returns the line starting at n with the variations of its moves
+func treeLine(n *chess.TreeNode) Line {
+	var line Line
+	for ; n != nil; n = n.Next() {
+		node := &Node{
+			Move:           n.Move,
+			SAN:            n.SAN,
+			NAGs:           append([]int(nil), n.NAGs...),
+			CommentsBefore: append([]string(nil), n.CommentsBefore...),
+			Comments:       append([]string(nil), n.Comments...),
+		}
+		if n.HasClock {
+			clock := "[%clk " + formatClock(n.Clock) + "]"
+			if last := len(node.Comments) - 1; last >= 0 {
+				node.Comments[last] += " " + clock
+			} else {
+				node.Comments = append(node.Comments, clock)
+			}
+		}
+
+		// the other children of the parent are the variations of the continuation of its line
+		if parent := n.Parent(); parent.Next() == n {
+			for _, variation := range parent.Children()[1:] {
+				node.Variations = append(node.Variations, treeLine(variation))
+			}
+		}
+		line = append(line, node)
+	}
+	return line
+}
+
+// extractClock removes the first [%clk] command of the comments, the comments left empty are removed
+func extractClock(comments []string) (rest []string, clock time.Duration, ok bool) {
+	for _, comment := range comments {
+		if !ok {
+			if m := clockCommand.FindStringSubmatchIndex(comment); m != nil {
+				d, err := time.ParseDuration(comment[m[2]:m[3]] + "h" + comment[m[4]:m[5]] + "m" + comment[m[6]:m[7]] + "s")
+				if err == nil {
+					clock, ok = d, true
+					comment = strings.TrimSpace(comment[:m[0]] + " " + comment[m[1]:])
+				}
+			}
+		}
+		if comment != "" {
+			rest = append(rest, comment)
+		}
+	}
+	return rest, clock, ok
+}
+
+// formatClock formats a clock like the [%clk] command, ex: 1:05:09 or 0:00:09.5
+func formatClock(d time.Duration) string {
+	s := fmt.Sprintf("%d:%02d:%02d", d/time.Hour, d%time.Hour/time.Minute, d%time.Minute/time.Second)
+	if frac := d % time.Second; frac >= time.Millisecond {
+		s += strings.TrimRight(fmt.Sprintf(".%03d", frac/time.Millisecond), "0")
+	}
+	return s
+}
@@ -0,0 +1,194 @@
+package pgn
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	chess "github.com/tommjj/chess_OG/chess_core"
+)
+
+// maximum line length of exported movetext
+const maxLineLength = 79
+
+// Write writes the games to w in PGN export format
+func Write(w io.Writer, games ...*Game) error {
+	for i, g := range games {
+		if i > 0 {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+		if _, err := io.WriteString(w, g.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// String returns the game in PGN export format
+func (g *Game) String() string {
+	var sb strings.Builder
+
+	result := g.Result
+	if result == "" {
+		result = Unknown
+	}
+
+	// Seven Tag Roster first, then the other tags in their order
+	for _, name := range SevenTagRoster {
+		value, ok := g.Tags.Get(name)
+		switch {
+		case name == "Result":
+			value = result
+		case !ok:
+			value = "?"
+		}
+		writeTag(&sb, name, value)
+	}
+	for _, tag := range g.Tags {
+		if !slices.Contains(SevenTagRoster, tag.Name) {
+			writeTag(&sb, tag.Name, tag.Value)
+		}
+	}
+	sb.WriteByte('\n')
+
+	ply := g.startPly()
+
+	var tokens []string
+	tokens = appendLine(tokens, g.Moves, ply)
+	for _, comment := range g.Comments {
+		tokens = append(tokens, commentToken(comment))
+	}
+	tokens = append(tokens, result)
+
+	writeWrapped(&sb, tokens)
+	sb.WriteByte('\n')
+
+	return sb.String()
+}
+
+func writeTag(sb *strings.Builder, name, value string) {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	fmt.Fprintf(sb, "[%s \"%s\"]\n", name, value)
+}
+
+// appendLine appends the movetext tokens of a line starting at ply
+func appendLine(tokens []string, line Line, ply int) []string {
+	needNumber := true
+
+	for _, node := range line {
+		for _, comment := range node.CommentsBefore {
+			tokens = append(tokens, commentToken(comment))
+			needNumber = true
+		}
+
+		moveNumber := strconv.Itoa(ply/2 + 1)
+		switch {
+		case ply%2 == 0:
+			tokens = append(tokens, moveNumber+".")
+		case needNumber:
+			tokens = append(tokens, moveNumber+"...")
+		}
+		needNumber = false
+
+		tokens = append(tokens, node.SAN)
+		for _, nag := range node.NAGs {
+			tokens = append(tokens, "$"+strconv.Itoa(nag))
+		}
+		for _, comment := range node.Comments {
+			tokens = append(tokens, commentToken(comment))
+			needNumber = true
+		}
+
+		for _, variation := range node.Variations {
+			varTokens := appendLine(nil, variation, ply)
+			if len(varTokens) == 0 {
+				continue
+			}
+			// a rest of line comment can not be joined to the parentheses
+			if isLineComment(varTokens[0]) {
+				varTokens = append([]string{"("}, varTokens...)
+			} else {
+				varTokens[0] = "(" + varTokens[0]
+			}
+			if last := len(varTokens) - 1; isLineComment(varTokens[last]) {
+				varTokens = append(varTokens, ")")
+			} else {
+				varTokens[last] += ")"
+			}
+
+			tokens = append(tokens, varTokens...)
+			needNumber = true
+		}
+
+		ply++
+	}
+
+	return tokens
+}
+
+// writeWrapped writes tokens separated by spaces, wrapping lines at maxLineLength.
+// a rest of line comment is never wrapped and ends its line
+func writeWrapped(sb *strings.Builder, tokens []string) {
+	lineLength := 0
+
+	for _, token := range tokens {
+		if isLineComment(token) {
+			if lineLength > 0 && lineLength+len(token)+1 > maxLineLength {
+				sb.WriteByte('\n')
+			} else if lineLength > 0 {
+				sb.WriteByte(' ')
+			}
+			sb.WriteString(token)
+			sb.WriteByte('\n')
+			lineLength = 0
+			continue
+		}
+
+		for _, word := range strings.Split(token, " ") {
+			if lineLength > 0 && lineLength+len(word)+1 > maxLineLength {
+				sb.WriteByte('\n')
+				lineLength = 0
+			}
+			if lineLength > 0 {
+				sb.WriteByte(' ')
+				lineLength++
+			}
+
+			sb.WriteString(word)
+			lineLength += len(word)
+		}
+	}
+}
+
+// commentToken returns the movetext token of a comment, a comment with a closing brace
+// can not be a brace comment and is written as a rest of line comment
+func commentToken(comment string) string {
+	if !strings.Contains(comment, "}") {
+		return "{" + comment + "}"
+	}
+	return ";" + strings.Join(strings.Fields(comment), " ")
+}
+
+func isLineComment(token string) bool {
+	return strings.HasPrefix(token, ";")
+}
+
+// startPly returns the ply index of the side to move in the start position, 0 for white's first move
+func (g *Game) startPly() int {
+	v, _ := g.Variant()
+	pos, err := chess.ParseVariantPosition(v, g.StartFEN())
+	if err != nil {
+		return 0
+	}
+
+	ply := (pos.FullmoveNumber() - 1) * 2
+	if pos.SideToMove() == chess.Black {
+		ply++
+	}
+	return ply
+}
@@ -0,0 +1,255 @@
+// Pieces in hand and drops of Crazyhouse and Bughouse
+//
+// references:
+// - https://lichess.org/variant/crazyhouse
+// - https://www.chessvariants.com/d.betza/chessvar/bughouse.html
+// - https://github.com/fairy-stockfish/Fairy-Stockfish/wiki/Variant-FEN-format
+
+package chess_core
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Pocket holds the number of pieces in hand of a side by piece type, kings are never in hand
+type Pocket [King]int
+
+// Count returns the number of pieces of type pt in the pocket
+func (p *Pocket) Count(pt PieceType) int {
+	if pt < Pawn || pt >= King {
+		return 0
+	}
+	return p[pt]
+}
+
+// IsEmpty reports whether the pocket holds no piece
+func (p *Pocket) IsEmpty() bool {
+	return *p == Pocket{}
+}
+
+// Pockets are the pockets of White and Black
+type Pockets [2]Pocket
+
+// sub returns the pockets minus the pieces of q
+func (p *Pockets) sub(q *Pockets) Pockets {
+	var r Pockets
+	for color := range r {
+		for pt := range r[color] {
+			r[color][pt] = p[color][pt] - q[color][pt]
+		}
+	}
+	return r
+}
+
+// pocketOrder is the order of the pieces in the FEN pockets
+var pocketOrder = [5]PieceType{Queen, Rook, Bishop, Knight, Pawn}
+
+// String returns the pockets in FEN notation without the brackets, ex: "Qn"
+func (p *Pockets) String() string {
+	var sb strings.Builder
+	for _, color := range [2]Color{White, Black} {
+		for _, pt := range pocketOrder {
+			for range p[color][pt] {
+				sb.WriteByte(byte(getPieceBySide(pt, color)))
+			}
+		}
+	}
+	return sb.String()
+}
+
+// parsePlacement splits the FEN piece placement of a drop variant, ex: "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR[Qn]",
+// into the board without the promoted piece marks, the pockets and the squares of the promoted pieces marked with '~'
+func parsePlacement(placement string) (board string, pockets Pockets, promoted BitBoard, err error) {
+	board, hand, found := strings.Cut(placement, "[")
+	if found {
+		var ok bool
+		if hand, ok = strings.CutSuffix(hand, "]"); !ok {
+			return "", pockets, 0, fmt.Errorf("%w: unclosed pocket", ErrInvalidFEN)
+		}
+
+		for _, char := range hand {
+			piece := Piece(char)
+			if !IsValidPiece(piece) || piece.Type() == King {
+				return "", pockets, 0, fmt.Errorf("%w: %q in pocket", ErrInvalidPiece, char)
+			}
+			pockets[piece.Color()][piece.Type()]++
+		}
+	}
+
+	if !strings.Contains(board, "~") {
+		return board, pockets, 0, nil
+	}
+
+	// the promoted mark follows the piece, so it is on the square before the current one
+	var sb strings.Builder
+	rank, file := 7, 0
+	for _, char := range board {
+		switch {
+		case char == '~':
+			if file == 0 {
+				return "", pockets, 0, fmt.Errorf("%w: promoted mark without piece", ErrInvalidFEN)
+			}
+			promoted.Set(Square(rank*8 + file - 1))
+			continue
+		case char == '/':
+			rank, file = rank-1, 0
+		case char >= '1' && char <= '8':
+			file += int(char - '0')
+		default:
+			file++
+		}
+		sb.WriteRune(char)
+	}
+
+	return sb.String(), pockets, promoted, nil
+}
+
+// formatPlacement appends the pockets and the promoted piece marks to the FEN piece placement of a drop variant
+func formatPlacement(board string, pockets *Pockets, promoted BitBoard) string {
+	if promoted != 0 {
+		var sb strings.Builder
+		rank, file := 7, 0
+		for _, char := range board {
+			sb.WriteRune(char)
+
+			switch {
+			case char == '/':
+				rank, file = rank-1, 0
+			case char >= '1' && char <= '8':
+				file += int(char - '0')
+			default:
+				if promoted.IsSet(Square(rank*8 + file)) {
+					sb.WriteByte('~')
+				}
+				file++
+			}
+		}
+		board = sb.String()
+	}
+
+	return board + "[" + pockets.String() + "]"
+}
+
+// dropTargets returns the squares where the side to move can drop a piece,
+// a drop never exposes the king so in check it must block the only checker
+func dropTargets(b *Board) BitBoard {
+	targets := ^b.BitBoards.AllPieces
+	if !b.InCheck() {
+		return targets
+	}
+
+	king := Square(b.BitBoards.PiecesByType(b.SideToMove, King).LeastSignificantBit())
+	checkers := AttackersTo(king, b.SideToMove, b.BitBoards)
+	if checkers.Count() > 1 {
+		return 0
+	}
+	return targets & BetweenBits(king, Square(checkers.LeastSignificantBit()))
+}
+
+// generateDrops appends the drops of the pieces in the pocket of the side to move to ml
+func generateDrops(b *Board, ml *MoveList) {
+	side := b.SideToMove
+	pocket := &b.Pockets[side]
+	if pocket.IsEmpty() {
+		return
+	}
+
+	targets := dropTargets(b)
+	for pt := Pawn; pt < King; pt++ {
+		if pocket[pt] == 0 {
+			continue
+		}
+
+		squares := targets
+		if pt == Pawn {
+			squares &^= rankMask(0) | rankMask(7)
+		}
+
+		piece := getPieceBySide(pt, side)
+		for squares != 0 {
+			ml.Add(NewDropMove(piece, popLSB(&squares)))
+		}
+	}
+}
+
+// hasPockets reports whether the pieces in hand can be dropped in the variant
+func hasPockets(v Variant) bool {
+	switch v.(type) {
+	case Crazyhouse, Bughouse:
+		return true
+	}
+	return false
+}
+
+// Pockets returns the pieces in hand of White and Black
+func (gs *GameState) Pockets() Pockets {
+	return gs.Position().Pockets()
+}
+
+// AddToPocket puts the piece in the pocket of its color, it is how the pieces captured on the
+// other board of a Bughouse game are handed over. they are kept when the moves are taken back
+func (gs *GameState) AddToPocket(piece Piece) error {
+	gs.mx.Lock()
+	defer gs.mx.Unlock()
+
+	pos, err := gs.pos.AddToPocket(piece)
+	if err != nil {
+		return err
+	}
+
+	gs.pos = pos
+	return nil
+}
+
+// AddToPocket returns the position with the piece in the pocket of its color, see GameState.AddToPocket
+func (p Position) AddToPocket(piece Piece) (Position, error) {
+	if !hasPockets(p.variant) {
+		return p, fmt.Errorf("%w: %s has no pockets", ErrInvalidMove, p.rules().Name())
+	}
+	if !IsValidPiece(piece) || piece.Type() == King {
+		return p, fmt.Errorf("%w: %q in pocket", ErrInvalidPiece, piece)
+	}
+
+	p.pockets[piece.Color()][piece.Type()]++
+	p.hash = p.computeZobristHash()
+
+	return p, nil
+}
+
+// MakeDrop drops a piece of type pt from the pocket of side on the square to
+func (gs *GameState) MakeDrop(side Color, pt PieceType, to Square) (GameStatus, error) {
+	gs.mx.Lock()
+	defer gs.mx.Unlock()
+
+	if gs.state != ResultOngoing {
+		return gs.state, ErrMatchEnd
+	}
+
+	if gs.pos.sideToMove != side {
+		return "", ErrMoveOutOfTurn
+	}
+
+	ml := make(MoveList, 0, 64)
+	gs.pos.generateMoves(&ml)
+
+	move := NewDropMove(getPieceBySide(pt, side), to)
+	if !slices.Contains(ml, move) {
+		return "", ErrInvalidMove
+	}
+
+	return gs.makeMove(move)
+}
+
+// CapturedPiece returns the piece captured by the last move as it goes to a pocket,
+// captured promoted pieces are pawns. it returns Empty if the last move is not a capture
+func (gs *GameState) CapturedPiece() Piece {
+	gs.mx.Lock()
+	defer gs.mx.Unlock()
+
+	if gs.node.parent == nil {
+		return Empty
+	}
+	return gs.node.parent.position.capturedPiece(gs.node.Move)
+}
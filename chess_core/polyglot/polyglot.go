@@ -0,0 +1,281 @@
+// Package polyglot reads opening books in the Polyglot .bin format
+//
+// references:
+// - http://hgm.nubati.net/book_format.html
+// - https://www.chessprogramming.org/PolyGlot
+
+package polyglot
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"slices"
+
+	chess "github.com/tommjj/chess_OG/chess_core"
+)
+
+var (
+	ErrInvalidBook = errors.New("invalid polyglot book")
+)
+
+// entrySize is the size of a book entry in bytes
+const entrySize = 16
+
+// offsets in randomKeys
+const (
+	castleOffset    = 768
+	enPassantOffset = 772
+	turnOffset      = 780
+)
+
+// Entry is a book entry, the move is in the Polyglot encoding
+type Entry struct {
+	Key    uint64
+	Move   uint16
+	Weight uint16
+	Learn  uint32
+}
+
+// BookMove is a legal move of a position with its book weight
+type BookMove struct {
+	Move   chess.Move
+	Weight uint16
+}
+
+// Book is an opening book, its entries are sorted by key
+type Book struct {
+	entries []Entry
+}
+
+// Open reads the book at path
+func Open(path string) (*Book, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return Read(bytes.NewReader(data))
+}
+
+// Read reads a book, the entries do not have to be sorted
+func Read(r io.Reader) (*Book, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data)%entrySize != 0 {
+		return nil, fmt.Errorf("%w: size %d is not a multiple of %d", ErrInvalidBook, len(data), entrySize)
+	}
+
+	entries := make([]Entry, len(data)/entrySize)
+	for i := range entries {
+		b := data[i*entrySize:]
+		entries[i] = Entry{
+			Key:    binary.BigEndian.Uint64(b[0:8]),
+			Move:   binary.BigEndian.Uint16(b[8:10]),
+			Weight: binary.BigEndian.Uint16(b[10:12]),
+			Learn:  binary.BigEndian.Uint32(b[12:16]),
+		}
+	}
+
+	// stable so entries with the same key keep the file order
+	slices.SortStableFunc(entries, func(a, b Entry) int {
+		switch {
+		case a.Key < b.Key:
+			return -1
+		case a.Key > b.Key:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	return &Book{entries: entries}, nil
+}
+
+// Len returns the number of entries
+func (b *Book) Len() int {
+	return len(b.entries)
+}
+
+// Entries returns the entries of the position with the Polyglot key
+func (b *Book) Entries(key uint64) []Entry {
+	i, _ := slices.BinarySearchFunc(b.entries, key, func(e Entry, key uint64) int {
+		switch {
+		case e.Key < key:
+			return -1
+		case e.Key > key:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	j := i
+	for j < len(b.entries) && b.entries[j].Key == key {
+		j++
+	}
+
+	return b.entries[i:j]
+}
+
+// Probe returns the legal book moves of the position, entries with illegal moves are skipped
+func (b *Book) Probe(gs *chess.GameState) []BookMove {
+	entries := b.Entries(Key(gs))
+	if len(entries) == 0 {
+		return nil
+	}
+
+	legal := gs.LegalMoves()
+	moves := make([]BookMove, 0, len(entries))
+	for _, entry := range entries {
+		if move, ok := decodeMove(gs, legal, entry.Move); ok {
+			moves = append(moves, BookMove{Move: move, Weight: entry.Weight})
+		}
+	}
+
+	return moves
+}
+
+// BestMove returns the book move with the highest weight
+func (b *Book) BestMove(gs *chess.GameState) (chess.Move, bool) {
+	moves := b.Probe(gs)
+	if len(moves) == 0 {
+		return 0, false
+	}
+
+	best := moves[0]
+	for _, move := range moves[1:] {
+		if move.Weight > best.Weight {
+			best = move
+		}
+	}
+
+	return best.Move, true
+}
+
+// RandomMove picks a book move with a probability proportional to its weight,
+// rng may be nil to use the global generator
+func (b *Book) RandomMove(gs *chess.GameState, rng *rand.Rand) (chess.Move, bool) {
+	moves := b.Probe(gs)
+
+	total := 0
+	for _, move := range moves {
+		total += int(move.Weight)
+	}
+	if total == 0 {
+		return 0, false
+	}
+
+	var n int
+	if rng != nil {
+		n = rng.IntN(total)
+	} else {
+		n = rand.IntN(total)
+	}
+
+	for _, move := range moves {
+		n -= int(move.Weight)
+		if n < 0 {
+			return move.Move, true
+		}
+	}
+
+	return 0, false
+}
+
+// Key returns the Polyglot key of the position
+func Key(gs *chess.GameState) uint64 {
+	var key uint64
+
+	pos := gs.Position()
+	bbs := pos.BitBoards()
+	for sq := range chess.Square(64) {
+		piece := bbs.GetPieceAt(sq)
+		if !chess.IsValidPiece(piece) {
+			continue
+		}
+
+		// black pawn 0, white pawn 1, black knight 2 ... white king 11
+		kind := 2 * int(piece.Type()-chess.Pawn)
+		if piece.Color() == chess.White {
+			kind++
+		}
+		key ^= randomKeys[64*kind+int(sq)]
+	}
+
+	for i, right := range [4]int{chess.WK, chess.WQ, chess.BK, chess.BQ} {
+		if pos.CastlingRights()&right != 0 {
+			key ^= randomKeys[castleOffset+i]
+		}
+	}
+
+	// the en passant file is only hashed when a pawn can capture on it
+	if ep := pos.EnPassantSquare(); ep.IsValid() {
+		pawns := bbs.PiecesByType(pos.SideToMove(), chess.Pawn)
+		rank, file := int(ep)/8, int(ep)%8
+		captureRank := rank - 1
+		if pos.SideToMove() == chess.Black {
+			captureRank = rank + 1
+		}
+
+		for _, f := range [2]int{file - 1, file + 1} {
+			if f >= 0 && f < 8 && pawns.IsSet(chess.Square(captureRank*8+f)) {
+				key ^= randomKeys[enPassantOffset+file]
+				break
+			}
+		}
+	}
+
+	if pos.SideToMove() == chess.White {
+		key ^= randomKeys[turnOffset]
+	}
+
+	return key
+}
+
+// promotion piece types of the move encoding
+var promotions = [5]chess.PieceType{0, chess.Knight, chess.Bishop, chess.Rook, chess.Queen}
+
+// decodeMove returns the legal move of the Polyglot move encoding,
+// castling is encoded as the king capturing its own rook
+func decodeMove(gs *chess.GameState, legal chess.MoveList, encoded uint16) (chess.Move, bool) {
+	to := chess.Square(encoded & 0x3F)
+	from := chess.Square((encoded >> 6) & 0x3F)
+	promoIndex := (encoded >> 12) & 0x7
+	if promoIndex >= uint16(len(promotions)) {
+		return 0, false
+	}
+	promo := promotions[promoIndex]
+
+	pos := gs.Position()
+	king, rook := pos.PieceAt(from), pos.PieceAt(to)
+	if king.Type() == chess.King && rook.Type() == chess.Rook && rook.Color() == king.Color() {
+		if to > from {
+			to = from + 2
+		} else {
+			to = from - 2
+		}
+	}
+
+	for _, move := range legal {
+		if chess.Square(move.From()) != from || chess.Square(move.To()) != to {
+			continue
+		}
+
+		var movePromo chess.PieceType
+		if move.IsPromotion() {
+			movePromo = chess.ASCIIPieces[move.Promoted()].Type()
+		}
+		if movePromo == promo {
+			return move, true
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,147 @@
+package polyglot
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math/rand/v2"
+	"strings"
+	"testing"
+
+	chess "github.com/tommjj/chess_OG/chess_core"
+)
+
+// keys from the Polyglot book format specification
+func TestKey(t *testing.T) {
+	tests := []struct {
+		moves string
+		want  uint64
+	}{
+		{"", 0x463b96181691fc9c},
+		{"e2e4", 0x823c9b50fd114196},
+		{"e2e4 d7d5", 0x0756b94461c50fb0},
+		{"e2e4 d7d5 e4e5", 0x662fafb965db29d4},
+		{"e2e4 d7d5 e4e5 f7f5", 0x22a48b5a8e47ff78},
+		{"e2e4 d7d5 e4e5 f7f5 e1e2", 0x652a607ca3f242c1},
+		{"e2e4 d7d5 e4e5 f7f5 e1e2 e8f7", 0x00fdd303c946bdd9},
+		{"a2a4 b7b5 h2h4 b5b4 c2c4", 0x3c8123ea7b067637},
+		{"a2a4 b7b5 h2h4 b5b4 c2c4 b4c3 a1a3", 0x5c3f9b829b279560},
+	}
+
+	for _, tt := range tests {
+		gs := chess.NewGame()
+		if err := gs.FromFEN(chess.StartingFEN); err != nil {
+			t.Fatal(err)
+		}
+		for _, move := range strings.Fields(tt.moves) {
+			if _, err := gs.MakeMoveUCI(gs.SideToMove(), move); err != nil {
+				t.Fatalf("move %s: %v", move, err)
+			}
+		}
+		if got := Key(gs); got != tt.want {
+			t.Errorf("Key(%q) = %#016x, want %#016x", tt.moves, got, tt.want)
+		}
+	}
+}
+
+// encodeMove returns the Polyglot encoding of a move in UCI notation
+func encodeMove(t *testing.T, uci string) uint16 {
+	t.Helper()
+
+	from, to, promo, err := chess.ParseUCIMove(uci)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoded := uint16(to) | uint16(from)<<6
+	if promo != 0 {
+		encoded |= uint16(promo-chess.Pawn) << 12
+	}
+	return encoded
+}
+
+func writeBook(t *testing.T, entries ...Entry) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		if err := binary.Write(&buf, binary.BigEndian, entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestBook(t *testing.T) {
+	start, castle, promotion := chess.NewGame(), chess.NewGame(), chess.NewGame()
+	if err := start.FromFEN(chess.StartingFEN); err != nil {
+		t.Fatal(err)
+	}
+	if err := castle.FromFEN("r3k2r/pppppppp/8/8/8/8/PPPPPPPP/R3K2R w KQkq - 0 1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := promotion.FromFEN("8/P6k/8/8/8/8/8/K7 w - - 0 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	data := writeBook(t,
+		Entry{Key: Key(castle), Move: encodeMove(t, "e1h1"), Weight: 5},
+		Entry{Key: Key(start), Move: encodeMove(t, "d2d4"), Weight: 1},
+		Entry{Key: Key(start), Move: encodeMove(t, "e2e5"), Weight: 10}, // illegal
+		Entry{Key: Key(start), Move: encodeMove(t, "e2e4"), Weight: 3},
+		Entry{Key: Key(promotion), Move: encodeMove(t, "a7a8n"), Weight: 1},
+	)
+
+	book, err := Read(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if book.Len() != 5 {
+		t.Errorf("Len() = %d, want 5", book.Len())
+	}
+
+	moves := book.Probe(start)
+	if len(moves) != 2 || moves[0].Move.UCI() != "d2d4" || moves[1].Move.UCI() != "e2e4" {
+		t.Errorf("Probe(start) = %v", moves)
+	}
+
+	if move, ok := book.BestMove(start); !ok || move.UCI() != "e2e4" {
+		t.Errorf("BestMove(start) = %v %v, want e2e4", move.UCI(), ok)
+	}
+
+	if move, ok := book.BestMove(castle); !ok || !move.IsCastle() || move.UCI() != "e1g1" {
+		t.Errorf("BestMove(castle) = %v %v, want e1g1", move.UCI(), ok)
+	}
+
+	if move, ok := book.BestMove(promotion); !ok || move.UCI() != "a7a8n" {
+		t.Errorf("BestMove(promotion) = %v %v, want a7a8n", move.UCI(), ok)
+	}
+
+	out := start.Copy()
+	if _, err := out.MakeMoveUCI(chess.White, "g1f3"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := book.BestMove(out); ok {
+		t.Error("BestMove() found a move of a position out of the book")
+	}
+
+	// weighted selection, e2e4 is picked 3 times out of 4
+	rng := rand.New(rand.NewPCG(1, 2))
+	counts := map[string]int{}
+	for range 4000 {
+		move, ok := book.RandomMove(start, rng)
+		if !ok {
+			t.Fatal("RandomMove() found no move")
+		}
+		counts[move.UCI()]++
+	}
+	if counts["e2e4"] < 2800 || counts["e2e4"] > 3200 || counts["e2e4"]+counts["d2d4"] != 4000 {
+		t.Errorf("RandomMove() counts = %v", counts)
+	}
+}
+
+func TestReadInvalidBook(t *testing.T) {
+	if _, err := Read(bytes.NewReader(make([]byte, 17))); !errors.Is(err, ErrInvalidBook) {
+		t.Errorf("Read() error = %v, want ErrInvalidBook", err)
+	}
+}
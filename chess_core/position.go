@@ -0,0 +1,638 @@
+// Immutable position
+//
+// references:
+// - https://www.chessprogramming.org/Chess_Position
+// - https://www.chessprogramming.org/Copy-Make
+
+package chess_core
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Position is a chess position, it is a value that is never modified once created so it can be
+// shared between goroutines without locking. MakeMove returns the next position.
+// the zero Position is an empty board, use ParsePosition or GameState.Position to get one
+type Position struct {
+	bb BitBoards
+
+	sideToMove      Color
+	castlingRights  int    // bitmask for castling rights (1=White kingside, 2=White queenside, 4=Black kingside, 8=Black queenside)
+	enPassantSquare Square // square index for en passant target square, 0-63, or 64 if none
+	halfmoveClock   int    // for fifty-move rule
+	fullmoveNumber  int    // starts at 1, increments after Black
+
+	castling *castlingInfo // castling squares, nil for standard chess
+
+	variant Variant // rules of the game, nil for standard chess
+	checks  [2]int  // checks given by White and Black, see ThreeCheck
+
+	pockets  Pockets  // pieces in hand, see Crazyhouse
+	promoted BitBoard // promoted pieces of the drop variants
+
+	hash uint64 // zobrist hash, updated incrementally
+}
+
+// ParsePosition parses a standard chess position from the FEN string
+func ParsePosition(fen string) (Position, error) {
+	return ParseVariantPosition(nil, fen)
+}
+
+// ParseVariantPosition parses a position of the variant from the FEN string, nil for standard chess
+func ParseVariantPosition(v Variant, fen string) (Position, error) {
+	// standard positions keep the fast move generation
+	if _, ok := v.(Standard); ok {
+		v = nil
+	}
+
+	p := Position{variant: v}
+	if err := p.setFEN(fen); err != nil {
+		return Position{}, err
+	}
+	return p, nil
+}
+
+// setFEN sets the pieces and the state of the position from the fen string, the variant is kept
+func (p *Position) setFEN(fen string) error {
+	placement, lastFen, _ := strings.Cut(fen, " ")
+
+	// the pockets and the promoted pieces of the drop variants are part of the piece placement
+	p.pockets, p.promoted = Pockets{}, 0
+	if hasPockets(p.variant) {
+		var err error
+		if placement, p.pockets, p.promoted, err = parsePlacement(placement); err != nil {
+			return wrapError(err, "pockets in FromFEN")
+		}
+	} else if strings.ContainsAny(placement, "[~") {
+		return wrapError(ErrInvalidFEN, "pockets in FromFEN")
+	}
+
+	if err := p.bb.FromFEN(placement); err != nil {
+		return wrapError(err, "bitboards FromFEN")
+	}
+	p.promoted &= p.bb.AllPieces
+
+	// Parse FEN string to set other fields, the clocks are optional like in EPD
+	fields := strings.Fields(lastFen)
+	if len(fields) < 3 {
+		return wrapError(ErrInvalidFEN, "arguments parsing in FromFEN")
+	}
+	if _, ok := p.rules().(fenVariant); len(fields) > 6 || (len(fields) > 5 && !ok) {
+		return wrapError(ErrInvalidFEN, "trailing fields in FromFEN")
+	}
+	sideToMove, castling, enPassant := fields[0], fields[1], fields[2]
+
+	halfmove, fullmove := 0, 1
+	if len(fields) > 3 {
+		var err error
+		if halfmove, err = strconv.Atoi(fields[3]); err != nil {
+			return wrapError(ErrInvalidHalfmove, "halfmove in FromFEN")
+		}
+	}
+	if len(fields) > 4 {
+		var err error
+		if fullmove, err = strconv.Atoi(fields[4]); err != nil {
+			return wrapError(ErrInvalidFullmove, "fullmove in FromFEN")
+		}
+	}
+
+	switch sideToMove {
+	case "w":
+		p.sideToMove = White
+	case "b":
+		p.sideToMove = Black
+	default:
+		return wrapError(ErrInvalidSideToMove, "side to move in FromFEN")
+	}
+
+	castlingRights, ci, err := parseCastling(&p.bb, castling)
+	if err != nil {
+		return wrapError(err, "castling rights in FromFEN")
+	}
+	p.castlingRights = castlingRights
+	p.castling = ci
+
+	if enPassant == "-" {
+		p.enPassantSquare = Square(NoEnPassant)
+	} else {
+		sq, ok := parseSquare(enPassant)
+		if !ok {
+			return wrapError(ErrInvalidEnPassant, "en passant square in FromFEN")
+		}
+		p.enPassantSquare = sq
+	}
+
+	if halfmove < 0 {
+		return wrapError(ErrNegativeHalfmove, "halfmove in FromFEN")
+	}
+	p.halfmoveClock = halfmove
+
+	if fullmove <= 0 {
+		return wrapError(ErrNegativeFullmove, "fullmove in FromFEN")
+	}
+	p.fullmoveNumber = fullmove
+
+	rules := p.rules()
+	b := p.board()
+	b.Checks = [2]int{}
+	if v, ok := rules.(fenVariant); ok {
+		if fields := strings.Fields(fen); len(fields) > 6 {
+			if err := v.parseFENField(&b, fields[6]); err != nil {
+				return wrapError(err, "variant field in FromFEN")
+			}
+		}
+	}
+	p.checks = b.Checks
+
+	if err := rules.Validate(&b); err != nil {
+		return fmt.Errorf("position in FromFEN: %w", err)
+	}
+
+	p.hash = p.computeZobristHash()
+
+	return nil
+}
+
+// FEN returns the FEN string of the position
+func (p Position) FEN() string {
+	bb := &p.bb
+
+	var fenBoard strings.Builder
+	for rank := 7; rank >= 0; rank-- {
+		emptyCount := 0
+		for file := range 8 {
+			sq := rank*8 + file
+			piece := bb.GetPieceAt(Square(sq))
+
+			if piece == Empty {
+				emptyCount++
+			} else {
+				if emptyCount > 0 {
+					fenBoard.WriteString(fmt.Sprintf("%d", emptyCount))
+					emptyCount = 0
+				}
+				fenBoard.WriteByte(byte(piece))
+			}
+		}
+		if emptyCount > 0 {
+			fenBoard.WriteString(fmt.Sprintf("%d", emptyCount))
+		}
+		if rank > 0 {
+			fenBoard.WriteByte('/')
+		}
+	}
+
+	side := "w"
+	if p.sideToMove == Black {
+		side = "b"
+	}
+
+	// Castling rights
+	castle := formatCastling(bb, p.castlingRights, p.castlingSquares())
+
+	//  En passant
+	enpassant := "-"
+	if p.enPassantSquare != NoEnPassant {
+		enpassant = SquareToCoordinates[p.enPassantSquare]
+	}
+
+	placement := fenBoard.String()
+	if hasPockets(p.variant) {
+		placement = formatPlacement(placement, &p.pockets, p.promoted)
+	}
+
+	// Combine everything
+	fen := fmt.Sprintf("%s %s %s %s %d %d",
+		placement,
+		side,
+		castle,
+		enpassant,
+		p.halfmoveClock,
+		p.fullmoveNumber,
+	)
+
+	if v, ok := p.variant.(fenVariant); ok {
+		b := p.board()
+		fen += " " + v.formatFENField(&b)
+	}
+
+	return fen
+}
+
+func (p Position) String() string {
+	return p.bb.String()
+}
+
+// BitBoards returns a copy of the pieces of the position
+func (p Position) BitBoards() BitBoards {
+	return p.bb
+}
+
+// PieceAt returns the piece on the square sq, Empty if none
+func (p Position) PieceAt(sq Square) Piece {
+	return p.bb.GetPieceAt(sq)
+}
+
+func (p Position) SideToMove() Color {
+	return p.sideToMove
+}
+
+// CastlingRights returns the castling rights bitmask, see WK, WQ, BK and BQ
+func (p Position) CastlingRights() int {
+	return p.castlingRights
+}
+
+// EnPassantSquare returns the en passant target square, NoEnPassant if none
+func (p Position) EnPassantSquare() Square {
+	return p.enPassantSquare
+}
+
+func (p Position) HalfmoveClock() int {
+	return p.halfmoveClock
+}
+
+func (p Position) FullmoveNumber() int {
+	return p.fullmoveNumber
+}
+
+// Hash returns the zobrist hash of the position, see GameState.Hash
+func (p Position) Hash() uint64 {
+	return p.hash
+}
+
+// Variant returns the rules of the position
+func (p Position) Variant() Variant {
+	return p.rules()
+}
+
+// Pockets returns the pieces in hand of White and Black
+func (p Position) Pockets() Pockets {
+	return p.pockets
+}
+
+// IsChess960 reports whether the position uses Chess960 castling rules
+func (p Position) IsChess960() bool {
+	return p.castlingSquares().chess960
+}
+
+// castlingSquares returns the castling squares of the position
+func (p *Position) castlingSquares() *castlingInfo {
+	if p.castling == nil {
+		return standardCastling
+	}
+	return p.castling
+}
+
+// rules returns the variant of the position, Standard if none
+func (p *Position) rules() Variant {
+	if p.variant == nil {
+		return Standard{}
+	}
+	return p.variant
+}
+
+// board returns the position for the variant hooks, the bitboards are shared
+func (p *Position) board() Board {
+	return Board{
+		BitBoards:       &p.bb,
+		SideToMove:      p.sideToMove,
+		CastlingRights:  p.castlingRights,
+		EnPassantSquare: p.enPassantSquare,
+		Checks:          p.checks,
+		Pockets:         p.pockets,
+		Promoted:        p.promoted,
+		castling:        p.castling,
+	}
+}
+
+// LegalMoves returns all legal moves of the side to move, it does not detect the end of the game
+// by repetition or by the 75-move rule, see GameState.LegalMoves
+func (p Position) LegalMoves() MoveList {
+	ml := make(MoveList, 0, 64)
+	p.generateMoves(&ml)
+	return ml
+}
+
+// generateMoves appends the legal moves of the side to move in the variant of the position to ml
+func (p *Position) generateMoves(ml *MoveList) {
+	if p.variant == nil {
+		generateLegalMoves(&p.bb, p.sideToMove, p.castlingRights, p.enPassantSquare, p.castlingSquares(), ml)
+		return
+	}
+
+	b := p.board()
+	p.variant.GenerateMoves(&b, ml)
+}
+
+// Result returns the result of the position with the rules of its variant, ResultOngoing if the game goes on.
+// the winner is White or Black for checkmates and the variant wins
+func (p Position) Result() (GameStatus, Color) {
+	b := p.board()
+	return p.rules().Result(&b)
+}
+
+// MakeMove returns the position after the legal move of the side to move, the position is not modified
+func (p Position) MakeMove(move Move) (Position, error) {
+	if move.Side() != p.sideToMove {
+		return p, ErrMoveOutOfTurn
+	}
+
+	ml := make(MoveList, 0, 64)
+	if p.variant != nil {
+		p.generateMoves(&ml)
+	} else {
+		generatePseudoLegalMoves(&p.bb, p.sideToMove, p.castlingRights, p.enPassantSquare, p.castlingSquares(), &ml)
+	}
+	if !slices.Contains(ml, move) {
+		return p, ErrInvalidMove
+	}
+
+	next := p
+	if !next.doMove(move) {
+		return p, ErrMoveIntoCheck
+	}
+	return next, nil
+}
+
+// MakeUnsafeMove returns the position after a pseudo legal move of the side to move without validation,
+// it is meant for search.
+//
+//	returns false and the position if the move leaves the king in check
+func (p Position) MakeUnsafeMove(move Move) (Position, bool) {
+	if p.variant != nil {
+		ml := make(MoveList, 0, 64)
+		p.generateMoves(&ml)
+		if !slices.Contains(ml, move) {
+			return p, false
+		}
+	}
+
+	next := p
+	if !next.doMove(move) {
+		return p, false
+	}
+	return next, true
+}
+
+func (p *Position) createMove(from, to Square, promo PieceType) (Move, error) {
+	bb := &p.bb
+
+	pieceToMove := bb.GetPieceAt(from)
+	if pieceToMove == Empty {
+		return 0, ErrInvalidMove
+	}
+
+	side := pieceToMove.Color()
+	allyPieces := bb.OccupiedBy(side)
+	enemyPieces := bb.OccupiedBy(side.Opposite())
+	allPieces := bb.AllPieces
+
+	switch pieceToMove.Type() {
+	case Pawn:
+		var promotionRank, forwardStep, doublePushRank int
+		var isSingle, isDouble, isCapture bool
+
+		if side == White {
+			forwardStep = 8
+			promotionRank = 8
+			doublePushRank = 2
+		} else {
+			forwardStep = -8
+			promotionRank = 1
+			doublePushRank = 7
+		}
+
+		isSingle = from+Square(forwardStep) == to && to.ToBB()&allPieces == 0
+		if isSingle {
+			isPromotion := to.Rank() == promotionRank
+			if isPromotion {
+				switch promo {
+				case Queen:
+					return NewMove(uint32(from), uint32(to), uint32(encodedPieceIndex(pieceToMove)), uint32(Queen), 0, 0, 0, 0), nil
+				case Rook:
+					return NewMove(uint32(from), uint32(to), uint32(encodedPieceIndex(pieceToMove)), uint32(Rook), 0, 0, 0, 0), nil
+				case Bishop:
+					return NewMove(uint32(from), uint32(to), uint32(encodedPieceIndex(pieceToMove)), uint32(Bishop), 0, 0, 0, 0), nil
+				case Knight:
+					return NewMove(uint32(from), uint32(to), uint32(encodedPieceIndex(pieceToMove)), uint32(Knight), 0, 0, 0, 0), nil
+				default:
+					return 0, ErrInvalidPromotion
+				}
+			}
+			return NewMove(uint32(from), uint32(to), uint32(encodedPieceIndex(pieceToMove)), 0, 0, 0, 0, 0), nil
+		}
+
+		midSquare := from + Square(forwardStep)
+		isDouble = from+Square(forwardStep*2) == to &&
+			to.ToBB()&allPieces == 0 &&
+			midSquare.ToBB()&allPieces == 0 &&
+			from.Rank() == doublePushRank
+		if isDouble {
+			return NewMove(uint32(from), uint32(to), uint32(encodedPieceIndex(pieceToMove)), 0, 0, 1, 0, 0), nil
+		}
+
+		isCapture = PawnAttacks(from, side)&to.ToBB() != 0 &&
+			((to.ToBB()&enemyPieces) != 0 || to == p.enPassantSquare)
+		if isCapture {
+			isPromotion := to.Rank() == promotionRank
+			if isPromotion {
+				switch promo {
+				case Queen:
+					return NewMove(uint32(from), uint32(to), uint32(encodedPieceIndex(pieceToMove)), uint32(Queen), 1, 0, 0, 0), nil
+				case Rook:
+					return NewMove(uint32(from), uint32(to), uint32(encodedPieceIndex(pieceToMove)), uint32(Rook), 1, 0, 0, 0), nil
+				case Bishop:
+					return NewMove(uint32(from), uint32(to), uint32(encodedPieceIndex(pieceToMove)), uint32(Bishop), 1, 0, 0, 0), nil
+				case Knight:
+					return NewMove(uint32(from), uint32(to), uint32(encodedPieceIndex(pieceToMove)), uint32(Knight), 1, 0, 0, 0), nil
+				default:
+					return 0, ErrInvalidPromotion
+				}
+			}
+
+			isEnPassant := to == p.enPassantSquare
+			if isEnPassant {
+				return NewMove(uint32(from), uint32(to), uint32(encodedPieceIndex(pieceToMove)), 0, 1, 0, 1, 0), nil
+			}
+
+			return NewMove(uint32(from), uint32(to), uint32(encodedPieceIndex(pieceToMove)), 0, 1, 0, 0, 0), nil
+		}
+
+		return 0, ErrInvalidMove
+	case Rook:
+		attacks := RookAttacks(from, allPieces) & ^allyPieces
+		if to.ToBB()&attacks == 0 {
+			return 0, ErrInvalidMove
+		}
+		isCapture := (enemyPieces >> to) & 1
+		return NewMove(uint32(from), uint32(to), uint32(encodedPieceIndex(pieceToMove)), 0, uint32(isCapture), 0, 0, 0), nil
+	case Bishop:
+		attacks := BishopAttacks(from, allPieces) & ^allyPieces
+		if to.ToBB()&attacks == 0 {
+			return 0, ErrInvalidMove
+		}
+		isCapture := (enemyPieces >> to) & 1
+		return NewMove(uint32(from), uint32(to), uint32(encodedPieceIndex(pieceToMove)), 0, uint32(isCapture), 0, 0, 0), nil
+	case Knight:
+		attacks := KnightAttacks(from) & ^allyPieces
+		if to.ToBB()&attacks == 0 {
+			return 0, ErrInvalidMove
+		}
+		isCapture := (enemyPieces >> to) & 1
+		return NewMove(uint32(from), uint32(to), uint32(encodedPieceIndex(pieceToMove)), 0, uint32(isCapture), 0, 0, 0), nil
+	case Queen:
+		attacks := QueenAttacks(from, allPieces) & ^allyPieces
+		if to.ToBB()&attacks == 0 {
+			return 0, ErrInvalidMove
+		}
+		isCapture := (enemyPieces >> to) & 1
+		return NewMove(uint32(from), uint32(to), uint32(encodedPieceIndex(pieceToMove)), 0, uint32(isCapture), 0, 0, 0), nil
+	case King:
+		// castling, the king moves to its target square or onto its own castling rook (Chess960 notation)
+		ci := p.castlingSquares()
+		kingSide, queenSide := sideCastlingRights(side)
+		for _, right := range [2]int{kingSide, queenSide} {
+			kingTo, _ := castlingTargets(right)
+			if from != ci.king[side] || p.castlingRights&right == 0 ||
+				(to != ci.rookSquare(right) && (to != kingTo || KingAttacks(from)&to.ToBB() != 0)) {
+				continue
+			}
+
+			if !ci.canCastle(bb, side, p.castlingRights, right) {
+				return 0, ErrInvalidMove
+			}
+			return NewMove(uint32(from), uint32(kingTo), uint32(encodedPieceIndex(pieceToMove)), 0, 0, 0, 0, 1), nil
+		}
+
+		attacks := KingAttacks(from) & ^allyPieces
+		if to.ToBB()&attacks != 0 && !IsAttacked(to, side, bb) {
+			isCapture := (enemyPieces >> to) & 1
+			return NewMove(uint32(from), uint32(to), uint32(encodedPieceIndex(pieceToMove)), 0, uint32(isCapture), 0, 0, 0), nil
+		}
+
+		return 0, ErrInvalidMove
+	default:
+		return 0, ErrInvalidMove
+	}
+}
+
+// findMove returns the legal move between the squares, castling moves can also be given
+// as the king capturing its own rook
+func (p *Position) findMove(from, to Square, promo PieceType) (Move, error) {
+	if p.variant == nil {
+		return p.createMove(from, to, promo)
+	}
+
+	ml := make(MoveList, 0, 64)
+	p.generateMoves(&ml)
+
+	ci := p.castlingSquares()
+	for _, move := range ml {
+		if Square(move.From()) != from || PieceType(move.Promoted()) != promo {
+			continue
+		}
+		if Square(move.To()) == to || move.IsCastle() && ci.rookSquare(castlingRightOf(move)) == to {
+			return move, nil
+		}
+	}
+
+	if promo != 0 {
+		return 0, ErrInvalidPromotion
+	}
+	return 0, ErrInvalidMove
+}
+
+// doMove makes a pseudo legal move of the side to move,
+// it returns false and keeps the position if the move leaves the king in check
+func (p *Position) doMove(move Move) bool {
+	if p.variant != nil {
+		p.doVariantMove(move)
+		return true
+	}
+
+	side := p.sideToMove
+	ci := p.castlingSquares()
+
+	captured, key := makeUnsafeMove(&p.bb, move, ci)
+
+	if IsKingAttacked(side, &p.bb) {
+		unmakeUnsafeMove(&p.bb, move, captured, ci)
+		return false
+	}
+
+	if side == Black {
+		p.fullmoveNumber += 1
+	}
+	p.sideToMove = side.Opposite()
+
+	// HalfmoveClock update
+	if move.IsCapture() || ASCIIPieces[move.Piece()].Type() == Pawn {
+		p.halfmoveClock = 0
+	} else {
+		p.halfmoveClock++
+	}
+
+	// CastlingRights and EnPassantSquare update
+	castling, enPassant := nextCastlingAndEnPassant(move, p.castlingRights, ci)
+	p.hash = nextZobristHash(p.hash, key, p.castlingRights, castling, p.enPassantSquare, enPassant)
+	p.castlingRights = castling
+	p.enPassantSquare = enPassant
+
+	return true
+}
+
+// doVariantMove makes a legal move with the variant rules
+func (p *Position) doVariantMove(move Move) {
+	b := p.board()
+	p.variant.MakeMove(&b, move)
+
+	if p.sideToMove == Black {
+		p.fullmoveNumber += 1
+	}
+	p.sideToMove = b.SideToMove
+
+	if move.IsCapture() || ASCIIPieces[move.Piece()].Type() == Pawn {
+		p.halfmoveClock = 0
+	} else {
+		p.halfmoveClock++
+	}
+
+	p.castlingRights = b.CastlingRights
+	p.enPassantSquare = b.EnPassantSquare
+	p.checks = b.Checks
+	p.pockets = b.Pockets
+	p.promoted = b.Promoted
+
+	// a move can remove more pieces than the captured one, ex: Atomic
+	p.hash = p.computeZobristHash()
+}
+
+// capturedPiece returns the piece captured by the move as it goes to a pocket, promoted pieces are pawns
+func (p *Position) capturedPiece(move Move) Piece {
+	if !move.IsCapture() {
+		return Empty
+	}
+
+	sq := Square(move.To())
+	if move.IsEnPassant() {
+		if move.Side() == White {
+			sq -= 8
+		} else {
+			sq += 8
+		}
+	}
+
+	captured := p.bb.GetPieceAt(sq)
+	if p.promoted.IsSet(sq) {
+		return getPieceBySide(Pawn, captured.Color())
+	}
+	return captured
+}
+
+func (p *Position) computeZobristHash() uint64 {
+	return computeZobristHash(&p.bb, p.sideToMove, p.enPassantSquare, p.castlingRights) ^
+		pocketsHash(&p.pockets) ^ checksHash(p.checks)
+}
@@ -0,0 +1,109 @@
+package chess_core
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestPositionMakeMove(t *testing.T) {
+	pos, err := ParsePosition(StartingFEN)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	move, err := pos.ParseSAN("e4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, err := pos.MakeMove(move)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := pos.FEN(); got != StartingFEN {
+		t.Errorf("after MakeMove FEN() = %q, want the position unchanged", got)
+	}
+	if got, want := next.FEN(), "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1"; got != want {
+		t.Errorf("next FEN() = %q, want %q", got, want)
+	}
+	if want := next.computeZobristHash(); next.Hash() != want {
+		t.Errorf("next Hash() = %x, want %x", next.Hash(), want)
+	}
+
+	if _, err := next.MakeMove(move); !errors.Is(err, ErrMoveOutOfTurn) {
+		t.Errorf("MakeMove() out of turn error = %v, want %v", err, ErrMoveOutOfTurn)
+	}
+	if _, err := ParsePosition("8/8/8/8/8/8/8/8 w - - 0 1"); !errors.Is(err, ErrNoKing) {
+		t.Errorf("ParsePosition() without kings error = %v, want %v", err, ErrNoKing)
+	}
+}
+
+func TestParsePositionClocks(t *testing.T) {
+	pos, err := ParsePosition("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3")
+	if err != nil {
+		t.Fatalf("ParsePosition() without clocks: %v", err)
+	}
+	if pos.HalfmoveClock() != 0 || pos.FullmoveNumber() != 1 {
+		t.Errorf("clocks = %d %d, want 0 1", pos.HalfmoveClock(), pos.FullmoveNumber())
+	}
+
+	if _, err := ParsePosition("4k3/8/8/8/8/8/8/4K3 w - - x 1"); !errors.Is(err, ErrInvalidHalfmove) {
+		t.Errorf("ParsePosition() error = %v, want %v", err, ErrInvalidHalfmove)
+	}
+	if _, err := ParsePosition("4k3/8/8/8/8/8/8/4K3 w -"); !errors.Is(err, ErrInvalidFEN) {
+		t.Errorf("ParsePosition() error = %v, want %v", err, ErrInvalidFEN)
+	}
+}
+
+func TestGameStateCopy(t *testing.T) {
+	gs, _ := playVariant(t, Standard{}, StartingFEN, "e2e4", "e7e5")
+
+	c := gs.Copy()
+	if got := len(c.History()); got != 2 {
+		t.Errorf("Copy() history has %d moves, want 2", got)
+	}
+	if got := c.StartFen(); got != StartingFEN {
+		t.Errorf("Copy() StartFen() = %q, want %q", got, StartingFEN)
+	}
+
+	if err := c.Undo(2); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.ToFEN(); got != StartingFEN {
+		t.Errorf("after Undo ToFEN() = %q, want %q", got, StartingFEN)
+	}
+	if got := len(gs.History()); got != 2 {
+		t.Errorf("Undo of the copy changed the game history to %d moves", got)
+	}
+}
+
+func TestPositionConcurrentReaders(t *testing.T) {
+	gs := NewGame()
+	if err := gs.FromFEN(StartingFEN); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				pos := gs.Position()
+				if n := pos.Perft(2); n < 20 {
+					t.Errorf("Perft(2) = %d in %q", n, pos.FEN())
+				}
+				_ = gs.State()
+				_, _ = gs.Winner()
+			}
+		}()
+	}
+
+	for _, uci := range []string{"e2e4", "e7e5", "g1f3", "b8c6", "f1b5"} {
+		if _, err := gs.MakeMoveUCI(gs.SideToMove(), uci); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+}
@@ -0,0 +1,333 @@
+// Standard Algebraic Notation (SAN)
+//
+// references:
+// - https://www.chessprogramming.org/Algebraic_Chess_Notation#Standard_Algebraic_Notation_.28SAN.29
+
+package chess_core
+
+import (
+	"strings"
+)
+
+// MoveToSAN returns the SAN of a legal move in the position, ex: "Nbd2", "exd6", "e8=Q+", "O-O"
+func (p Position) MoveToSAN(move Move) string {
+	if p.variant != nil {
+		return p.variantMoveToSAN(move)
+	}
+
+	return moveToSAN(&p.bb, p.sideToMove, p.castlingRights, p.enPassantSquare, p.castlingSquares(), move)
+}
+
+// ParseSAN returns the legal move described by the SAN string san in the position
+func (p Position) ParseSAN(san string) (Move, error) {
+	ml := make(MoveList, 0, 64)
+	p.generateMoves(&ml)
+
+	return parseSANMove(ml, san, promotesToKing(p.variant))
+}
+
+// MoveToSAN returns the SAN of a legal move in the current position, ex: "Nbd2", "exd6", "e8=Q+", "O-O"
+func (gs *GameState) MoveToSAN(move Move) string {
+	return gs.Position().MoveToSAN(move)
+}
+
+// ParseSAN returns the legal move described by the SAN string san in the current position
+func (gs *GameState) ParseSAN(san string) (Move, error) {
+	return gs.Position().ParseSAN(san)
+}
+
+func moveToSAN(bb *BitBoards, side Color, castling int, enPassantSquare Square, ci *castlingInfo, move Move) string {
+	var san strings.Builder
+
+	// the legal moves are only needed to disambiguate piece moves
+	var ml MoveList
+	if !move.IsCastle() && ASCIIPieces[move.Piece()].Type() != Pawn {
+		ml = make(MoveList, 0, 64)
+		generateLegalMoves(bb, side, castling, enPassantSquare, ci, &ml)
+	}
+	san.WriteString(sanMove(move, ml))
+
+	// check and checkmate suffixes
+	bbCopy := bb.Copy()
+	makeUnsafeMove(bbCopy, move, ci)
+
+	enemy := side.Opposite()
+	if IsKingAttacked(enemy, bbCopy) {
+		nextCastling, nextEnPassant := nextCastlingAndEnPassant(move, castling, ci)
+		if hasAnyLegalMove(bbCopy, enemy, nextCastling, nextEnPassant, ci) {
+			san.WriteByte('+')
+		} else {
+			san.WriteByte('#')
+		}
+	}
+
+	return san.String()
+}
+
+// variantMoveToSAN is like moveToSAN with the rules of the variant of the position
+func (p *Position) variantMoveToSAN(move Move) string {
+	ml := make(MoveList, 0, 64)
+	p.generateMoves(&ml)
+	san := sanMove(move, ml)
+
+	b := p.board()
+	next := b.Copy()
+	p.variant.MakeMove(&next, move)
+
+	if status, _ := p.variant.Result(&next); status == ResultCheckmate {
+		return san + "#"
+	}
+
+	check := next.InCheck()
+	switch p.variant.(type) {
+	case Antichess: // the king is not royal
+		check = false
+	case Atomic: // kings next to each other are not in check
+		check = check && !kingsAdjacent(next.BitBoards)
+	}
+	if check {
+		san += "+"
+	}
+
+	return san
+}
+
+// sanMove returns the SAN of a move without the check suffix, ml holds the legal moves to disambiguate piece moves
+func sanMove(move Move, ml MoveList) string {
+	var san strings.Builder
+
+	from := Square(move.From())
+	to := Square(move.To())
+	pieceType := ASCIIPieces[move.Piece()].Type()
+
+	switch {
+	case move.IsDrop():
+		san.WriteByte(byte(ASCIIPieces[pieceType]))
+		san.WriteByte('@')
+		san.WriteString(SquareToCoordinates[to])
+	case move.IsCastle():
+		if to.File() == SquareG1.File() {
+			san.WriteString("O-O")
+		} else {
+			san.WriteString("O-O-O")
+		}
+	case pieceType == Pawn:
+		if move.IsCapture() {
+			san.WriteByte(SquareToCoordinates[from][0])
+			san.WriteByte('x')
+		}
+		san.WriteString(SquareToCoordinates[to])
+		if move.IsPromotion() {
+			san.WriteByte('=')
+			san.WriteByte(byte(ASCIIPieces[move.Promoted()]))
+		}
+	default:
+		san.WriteByte(byte(ASCIIPieces[pieceType]))
+
+		// disambiguation
+		ambiguous, sameFile, sameRank := false, false, false
+		for _, other := range ml {
+			otherFrom := Square(other.From())
+			if other.Piece() != move.Piece() || Square(other.To()) != to || otherFrom == from || other.IsDrop() {
+				continue
+			}
+
+			ambiguous = true
+			if otherFrom.File() == from.File() {
+				sameFile = true
+			}
+			if otherFrom.Rank() == from.Rank() {
+				sameRank = true
+			}
+		}
+
+		if ambiguous {
+			switch {
+			case !sameFile:
+				san.WriteByte(SquareToCoordinates[from][0])
+			case !sameRank:
+				san.WriteByte(SquareToCoordinates[from][1])
+			default:
+				san.WriteString(SquareToCoordinates[from])
+			}
+		}
+
+		if move.IsCapture() {
+			san.WriteByte('x')
+		}
+		san.WriteString(SquareToCoordinates[to])
+	}
+
+	return san.String()
+}
+
+// parseSANMove returns the move of ml described by the SAN string san, king allows promotions to a king
+func parseSANMove(ml MoveList, san string, king bool) (Move, error) {
+	s := strings.TrimSpace(san)
+
+	// drop check, checkmate and annotation suffixes
+	s = strings.TrimRight(s, "+#!?")
+	s = strings.TrimSuffix(s, "e.p.")
+	s = strings.TrimSpace(s)
+
+	if s == "" {
+		return 0, ErrInvalidSAN
+	}
+
+	// castling
+	switch s {
+	case "O-O", "0-0", "O-O-O", "0-0-0":
+		long := len(s) == 5
+		for _, move := range ml {
+			if !move.IsCastle() {
+				continue
+			}
+			if (Square(move.To()).File() == SquareC1.File()) == long {
+				return move, nil
+			}
+		}
+		return 0, ErrInvalidMove
+	}
+
+	// drops, ex: "N@f3", "@e4" for a pawn
+	if piece, square, ok := strings.Cut(s, "@"); ok {
+		return parseSANDrop(ml, piece, square)
+	}
+
+	pieceType := Pawn
+	if p := Piece(s[0]); isWhitePiece(p) {
+		switch p {
+		case WPawn, WKnight, WBishop, WRook, WQueen, WKing:
+			pieceType = p.Type()
+		default:
+			return 0, ErrInvalidSAN
+		}
+		s = s[1:]
+	}
+
+	// promotion
+	var promo PieceType
+	var ok bool
+	if i := strings.IndexByte(s, '='); i >= 0 {
+		if i+2 != len(s) {
+			return 0, ErrInvalidSAN
+		}
+		if promo, ok = promotionPieceType(s[i+1], king); !ok {
+			return 0, ErrInvalidPromotion
+		}
+		s = s[:i]
+	} else if n := len(s); n > 2 && pieceType == Pawn && isWhitePiece(Piece(s[n-1])) {
+		// accept promotions without '=', ex: e8Q
+		if promo, ok = promotionPieceType(s[n-1], king); !ok {
+			return 0, ErrInvalidPromotion
+		}
+		s = s[:n-1]
+	}
+
+	s = strings.ReplaceAll(s, "x", "")
+	s = strings.ReplaceAll(s, "-", "")
+	if len(s) < 2 || len(s) > 4 {
+		return 0, ErrInvalidSAN
+	}
+
+	to, ok := parseSquare(s[len(s)-2:])
+	if !ok {
+		return 0, ErrInvalidSAN
+	}
+
+	fromFile, fromRank := -1, -1
+	for _, c := range s[:len(s)-2] {
+		switch {
+		case c >= 'a' && c <= 'h':
+			fromFile = int(c-'a') + 1
+		case c >= '1' && c <= '8':
+			fromRank = int(c-'1') + 1
+		default:
+			return 0, ErrInvalidSAN
+		}
+	}
+
+	var found Move
+	matches := 0
+	for _, move := range ml {
+		from := Square(move.From())
+		if Square(move.To()) != to ||
+			move.IsCastle() || move.IsDrop() ||
+			ASCIIPieces[move.Piece()].Type() != pieceType ||
+			PieceType(move.Promoted()) != promo ||
+			(fromFile != -1 && from.File() != fromFile) ||
+			(fromRank != -1 && from.Rank() != fromRank) {
+			continue
+		}
+
+		found = move
+		matches++
+	}
+
+	switch matches {
+	case 0:
+		return 0, ErrInvalidMove
+	case 1:
+		return found, nil
+	default:
+		return 0, ErrAmbiguousSAN
+	}
+}
+
+// parseSANDrop returns the drop of ml of the piece letter on the square, no letter is a pawn
+func parseSANDrop(ml MoveList, piece, square string) (Move, error) {
+	pieceType := Pawn
+	switch len(piece) {
+	case 0:
+	case 1:
+		p := Piece(piece[0])
+		if !isWhitePiece(p) || p == WKing {
+			return 0, ErrInvalidSAN
+		}
+		pieceType = p.Type()
+	default:
+		return 0, ErrInvalidSAN
+	}
+
+	to, ok := parseSquare(square)
+	if !ok {
+		return 0, ErrInvalidSAN
+	}
+
+	for _, move := range ml {
+		if move.IsDrop() && Square(move.To()) == to && ASCIIPieces[move.Piece()].Type() == pieceType {
+			return move, nil
+		}
+	}
+	return 0, ErrInvalidMove
+}
+
+// promotionPieceType returns the piece type of promotion letter c, ex: 'Q' or 'q',
+// the king is only accepted when king is set
+func promotionPieceType(c byte, king bool) (PieceType, bool) {
+	switch Piece(c) {
+	case WQueen, BQueen:
+		return Queen, true
+	case WRook, BRook:
+		return Rook, true
+	case WBishop, BBishop:
+		return Bishop, true
+	case WKnight, BKnight:
+		return Knight, true
+	case WKing, BKing:
+		return King, king
+	default:
+		return 0, false
+	}
+}
+
+// parseSquare parses a coordinate like "e4"
+func parseSquare(s string) (Square, bool) {
+	if len(s) != 2 {
+		return -1, false
+	}
+	if s[0] < 'a' || s[0] > 'h' || s[1] < '1' || s[1] > '8' {
+		return -1, false
+	}
+	return Square(int(s[0]-'a') + int(s[1]-'1')*8), true
+}
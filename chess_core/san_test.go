@@ -0,0 +1,77 @@
+package chess_core
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSAN(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		input string
+		want  string
+	}{
+		{"pawn push", StartingFEN, "e4", "e4"},
+		{"knight", StartingFEN, "Nf3", "Nf3"},
+		{"over disambiguated", StartingFEN, "Ng1f3", "Nf3"},
+		{"file disambiguation", "4k3/8/8/8/8/5N2/8/1N2K3 w - - 0 1", "Nbd2", "Nbd2"},
+		{"rank disambiguation", "4k3/8/8/8/8/R7/8/R3K3 w - - 0 1", "R1a2", "R1a2"},
+		{"square disambiguation", "4k3/8/8/8/8/Q7/8/Q1Q1K3 w - - 0 1", "Qa1b2", "Qa1b2"},
+		{"promotion with check", "4k3/P7/8/8/8/8/8/4K3 w - - 0 1", "a8=Q", "a8=Q+"},
+		{"promotion without equal sign", "4k3/P7/8/8/8/8/8/4K3 w - - 0 1", "a8N", "a8=N"},
+		{"capture promotion", "1r2k3/P7/8/8/8/8/8/4K3 w - - 0 1", "axb8=R", "axb8=R+"},
+		{"checkmate", "rnbqkbnr/pppp1ppp/8/4p3/6P1/5P2/PPPPP2P/RNBQKBNR b KQkq - 0 2", "Qh4", "Qh4#"},
+		{"en passant", "4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", "exd6 e.p.", "exd6"},
+		{"short castle", "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "O-O", "O-O"},
+		{"long castle", "r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", "0-0-0", "O-O-O"},
+		{"annotated capture", "4k3/8/8/3p4/5N2/8/8/4K3 w - - 0 1", "Nxd5!?", "Nxd5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs := NewGame()
+			if err := gs.FromFEN(tt.fen); err != nil {
+				t.Fatal(err)
+			}
+
+			move, err := gs.ParseSAN(tt.input)
+			if err != nil {
+				t.Fatalf("ParseSAN(%q) error: %v", tt.input, err)
+			}
+
+			if got := gs.MoveToSAN(move); got != tt.want {
+				t.Errorf("MoveToSAN() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSANErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		input string
+		want  error
+	}{
+		{"ambiguous", "4k3/8/8/8/8/5N2/8/1N2K3 w - - 0 1", "Nd2", ErrAmbiguousSAN},
+		{"illegal", StartingFEN, "e5", ErrInvalidMove},
+		{"castling not allowed", StartingFEN, "O-O", ErrInvalidMove},
+		{"bad promotion", "4k3/P7/8/8/8/8/8/4K3 w - - 0 1", "a8=K", ErrInvalidPromotion},
+		{"garbage", StartingFEN, "Zz9", ErrInvalidSAN},
+		{"empty", StartingFEN, "", ErrInvalidSAN},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs := NewGame()
+			if err := gs.FromFEN(tt.fen); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := gs.ParseSAN(tt.input); !errors.Is(err, tt.want) {
+				t.Errorf("ParseSAN(%q) error = %v, want %v", tt.input, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+// Static exchange evaluation
+//
+// references:
+// - https://www.chessprogramming.org/Static_Exchange_Evaluation
+// - https://www.chessprogramming.org/SEE_-_The_Swap_Algorithm
+
+package chess_core
+
+// SEEPieceValues are the piece values by piece type used by SEE
+var SEEPieceValues = [7]int{0, 100, 300, 300, 500, 900, 20000}
+
+// SEE returns the material balance in centipawns for the side to move of the capture sequence
+// started by move on its target square, both sides capture with their least valuable attacker
+// and may stop capturing when it loses material, x-ray attackers behind the capturing pieces join in,
+// pins are ignored and the king never captures a defended piece
+func (p Position) SEE(move Move) int {
+	return see(&p.bb, move)
+}
+
+// SEE returns the static exchange evaluation of the move in the current position, see Position.SEE
+func (gs *GameState) SEE(move Move) int {
+	return gs.Position().SEE(move)
+}
+
+func see(bb *BitBoards, move Move) int {
+	if move.IsCastle() {
+		return 0
+	}
+
+	from, to := Square(move.From()), Square(move.To())
+
+	var gain [32]int
+	attackerValue := SEEPieceValues[ASCIIPieces[move.Piece()].Type()]
+	occupancy := bb.AllPieces &^ from.ToBB()
+
+	switch {
+	case move.IsEnPassant():
+		gain[0] = SEEPieceValues[Pawn]
+		occupancy &^= enPassantCaptureSquare(to, move.Side()).ToBB()
+	case bb.AllPieces.IsSet(to):
+		gain[0] = SEEPieceValues[bb.GetPieceAt(to).Type()]
+	}
+
+	if move.IsPromotion() {
+		promoted := SEEPieceValues[ASCIIPieces[move.Promoted()].Type()]
+		gain[0] += promoted - SEEPieceValues[Pawn]
+		attackerValue = promoted
+	}
+
+	bishops := bb.WhiteBishops | bb.BlackBishops | bb.WhiteQueens | bb.BlackQueens
+	rooks := bb.WhiteRooks | bb.BlackRooks | bb.WhiteQueens | bb.BlackQueens
+	attackers := attackersOf(bb, to, occupancy) & occupancy
+
+	side := move.Side().Opposite()
+	d := 0
+	for {
+		sideAttackers := attackers & bb.OccupiedBy(side)
+		if sideAttackers == 0 {
+			break
+		}
+
+		sq, pieceType := leastValuableAttacker(bb, sideAttackers)
+
+		// the king can not capture a defended piece
+		if pieceType == King && attackers&bb.OccupiedBy(side.Opposite()) != 0 {
+			break
+		}
+
+		d++
+		gain[d] = attackerValue - gain[d-1]
+		if d == len(gain)-1 {
+			break
+		}
+
+		attackerValue = SEEPieceValues[pieceType]
+		occupancy &^= sq.ToBB()
+
+		// sliders behind the capturing piece
+		attackers |= BishopAttacks(to, occupancy)&bishops | RookAttacks(to, occupancy)&rooks
+		attackers &= occupancy
+
+		side = side.Opposite()
+	}
+
+	for ; d > 0; d-- {
+		gain[d-1] = -max(-gain[d-1], gain[d])
+	}
+
+	return gain[0]
+}
+
+// leastValuableAttacker returns the square and the type of the least valuable piece in attackers
+func leastValuableAttacker(bb *BitBoards, attackers BitBoard) (Square, PieceType) {
+	for pieceType := Pawn; pieceType <= King; pieceType++ {
+		pieces := attackers & (bb.PiecesByType(White, pieceType) | bb.PiecesByType(Black, pieceType))
+		if pieces != 0 {
+			return Square(pieces.LeastSignificantBit()), pieceType
+		}
+	}
+
+	return -1, 0
+}
@@ -0,0 +1,40 @@
+package chess_core
+
+import "testing"
+
+func TestSEE(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		uci  string
+		want int
+	}{
+		{"undefended pawn", "1k1r4/1pp4p/p7/4p3/8/P5P1/1PP4P/2K1R3 w - - 0 1", "e1e5", 100},
+		{"x-ray exchange", "1k1r3q/1ppn3p/p4b2/4p3/8/P2N2P1/1PP1R1BP/2K1Q3 w - - 0 1", "d3e5", -200},
+		{"pawn takes defended knight", "4k3/8/4p3/3n4/4P3/8/8/4K3 w - - 0 1", "e4d5", 200},
+		{"queen takes defended pawn", "4k3/8/4p3/3p4/8/8/8/3QK3 w - - 0 1", "d1d5", -800},
+		{"quiet move to attacked square", "4k3/8/4p3/8/8/8/8/3RK3 w - - 0 1", "d1d5", -500},
+		{"quiet move to safe square", StartingFEN, "e2e4", 0},
+		{"king can not take defended piece", "4r2k/8/8/8/8/8/3p1K2/4R3 b - - 0 1", "d2e1q", 1300},
+		{"en passant", "4k3/8/8/3Pp3/8/8/8/4K3 w - e6 0 1", "d5e6", 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gs := newTestGame(t, tt.fen)
+
+			from, to, promo, err := ParseUCIMove(tt.uci)
+			if err != nil {
+				t.Fatal(err)
+			}
+			move, err := gs.pos.createMove(from, to, promo)
+			if err != nil {
+				t.Fatalf("createMove(%s): %v", tt.uci, err)
+			}
+
+			if got := gs.SEE(move); got != tt.want {
+				t.Errorf("SEE(%s) = %d, want %d", tt.uci, got, tt.want)
+			}
+		})
+	}
+}
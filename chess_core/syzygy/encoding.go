@@ -0,0 +1,130 @@
+package syzygy
+
+// lookup tables of the position encoding, filled by init
+
+var (
+	// binomial[k][n] is the number of ways to choose k elements from n
+	binomial [7][64]uint64
+
+	// mapB1H1H7 maps the squares below the a1-h8 diagonal to 0..27
+	mapB1H1H7 [64]int
+	// mapA1D1D4 maps the squares of the a1-d1-d4 triangle to 0..9, the diagonal squares last
+	mapA1D1D4 [64]int
+	// mapKK maps the 462 legal placements of two kings with the first one in the a1-d1-d4 triangle
+	mapKK [10][64]int
+
+	// mapPawns maps the squares a2-h7 to 0..47, the leading pawn has the highest value
+	mapPawns [64]int
+	// leadPawnIdx and leadPawnsSize encode the leading pawns by count and file
+	leadPawnIdx   [6][64]int
+	leadPawnsSize [6][4]int
+)
+
+func init() {
+	initEncoding()
+}
+
+// offA1H8 returns the distance of sq above the a1-h8 diagonal, negative below it
+func offA1H8(sq int) int {
+	return sq/8 - sq%8
+}
+
+func kingAttacks(sq int) uint64 {
+	var attacks uint64
+	for dr := -1; dr <= 1; dr++ {
+		for df := -1; df <= 1; df++ {
+			r, f := sq/8+dr, sq%8+df
+			if (dr != 0 || df != 0) && r >= 0 && r < 8 && f >= 0 && f < 8 {
+				attacks |= 1 << (r*8 + f)
+			}
+		}
+	}
+	return attacks
+}
+
+func initEncoding() {
+	code := 0
+	for sq := range 64 {
+		if offA1H8(sq) < 0 {
+			mapB1H1H7[sq] = code
+			code++
+		}
+	}
+
+	code = 0
+	var diagonal []int
+	for sq := 0; sq <= 27; sq++ { // a1..d4
+		switch {
+		case offA1H8(sq) < 0 && sq%8 <= 3:
+			mapA1D1D4[sq] = code
+			code++
+		case offA1H8(sq) == 0 && sq%8 <= 3:
+			diagonal = append(diagonal, sq)
+		}
+	}
+	for _, sq := range diagonal {
+		mapA1D1D4[sq] = code
+		code++
+	}
+
+	// both kings on the diagonal are encoded last
+	type pair struct{ idx, sq int }
+	var bothOnDiagonal []pair
+	code = 0
+	for idx := range 10 {
+		for s1 := 0; s1 <= 27; s1++ {
+			if mapA1D1D4[s1] != idx || (idx == 0 && s1 != 1) { // b1 is mapped to 0
+				continue
+			}
+
+			for s2 := range 64 {
+				switch {
+				case (kingAttacks(s1)|1<<s1)&(1<<s2) != 0: // illegal
+				case offA1H8(s1) == 0 && offA1H8(s2) > 0: // first on the diagonal, second above
+				case offA1H8(s1) == 0 && offA1H8(s2) == 0:
+					bothOnDiagonal = append(bothOnDiagonal, pair{idx, s2})
+				default:
+					mapKK[idx][s2] = code
+					code++
+				}
+			}
+		}
+	}
+	for _, p := range bothOnDiagonal {
+		mapKK[p.idx][p.sq] = code
+		code++
+	}
+
+	binomial[0][0] = 1
+	for n := 1; n < 64; n++ {
+		for k := 0; k < len(binomial) && k <= n; k++ {
+			if k > 0 {
+				binomial[k][n] += binomial[k-1][n-1]
+			}
+			if k < n {
+				binomial[k][n] += binomial[k][n-1]
+			}
+		}
+	}
+
+	// 47 squares are available when the leading pawn is on a2,
+	// each rank up removes the two mirrored squares below it
+	available := 47
+	for count := 1; count <= 5; count++ {
+		for file := range 4 {
+			idx := 0
+			for rank := 1; rank <= 6; rank++ {
+				sq := rank*8 + file
+				if count == 1 {
+					mapPawns[sq] = available
+					available--
+					mapPawns[sq^7] = available
+					available--
+				}
+				leadPawnIdx[count][sq] = idx
+				idx += int(binomial[count-1][mapPawns[sq]])
+			}
+			leadPawnsSize[count][file] = idx
+		}
+	}
+}
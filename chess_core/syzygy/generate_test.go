@@ -0,0 +1,568 @@
+package syzygy
+
+import (
+	"encoding/binary"
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	chess "github.com/tommjj/chess_OG/chess_core"
+)
+
+// the 3 piece tables of testdata are written by
+//
+//	go test ./syzygy -run TestGenerateTables -generate
+//
+// the positions are solved by retrograde analysis with the move generation of chess_core
+// and compressed with the pairs and Huffman coding of the table files
+var generate = flag.Bool("generate", false, "write the 3 piece tables of testdata")
+
+// material is a solved 3 piece material, the piece is white.
+// positions are indexed by the squares of the white king, the piece and the black king and by the side to move
+type material struct {
+	name  string
+	piece chess.Piece
+	legal []bool
+	wdl   []WDL // for the side to move
+	dtz   []int // plies to the zeroing move or the mate of wins and losses
+}
+
+const materialSize = 64 * 64 * 64 * 2
+
+func materialKey(wk, wp, bk, stm int) int {
+	return ((wk*64+wp)*64+bk)*2 + stm
+}
+
+// edge is a legal move, next is -1 when the material changes and the value of the next position is known
+type edge struct {
+	next    int
+	zeroing bool
+	value   WDL
+}
+
+func TestGenerateTables(t *testing.T) {
+	if !*generate {
+		t.Skip("run with -generate to write the tables of testdata")
+	}
+
+	solved := map[chess.PieceType]*material{}
+	for _, piece := range []chess.Piece{chess.WQueen, chess.WRook, chess.WBishop, chess.WKnight, chess.WPawn} {
+		m := solveMaterial(t, piece, solved)
+		solved[piece.Type()] = m
+		writeTables(t, "testdata", m)
+	}
+
+	tb, err := Open("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range solved {
+		checkTables(t, tb, m)
+	}
+}
+
+// solveMaterial computes the WDL and the DTZ of the positions of KXvK,
+// the materials of the promotions must be solved
+func solveMaterial(t *testing.T, piece chess.Piece, solved map[chess.PieceType]*material) *material {
+	m := &material{
+		name:  "K" + string(pieceLetters[chess.King-piece.Type()]) + "vK",
+		piece: piece,
+		legal: make([]bool, materialSize),
+		wdl:   make([]WDL, materialSize),
+		dtz:   make([]int, materialSize),
+	}
+	edges := make([][]edge, materialSize)
+	known := make([]bool, materialSize)
+
+	for k := range materialSize {
+		wk, wp, bk, stm := k>>13, k>>7&63, k>>1&63, k&1
+		if wk == wp || wk == bk || wp == bk {
+			continue
+		}
+		pos, err := chess.ParsePosition(materialFEN(piece, wk, wp, bk, stm))
+		if err != nil {
+			continue
+		}
+		m.legal[k] = true
+
+		moves := pos.LegalMoves()
+		if len(moves) == 0 {
+			known[k] = true
+			if pos.InCheck() {
+				m.wdl[k] = Loss
+			}
+			continue
+		}
+
+		for _, move := range moves {
+			next, _ := pos.MakeUnsafeMove(move)
+			e := edge{next: -1, zeroing: move.IsCapture() || chess.ASCIIPieces[move.Piece()].Type() == chess.Pawn}
+
+			bb := next.BitBoards()
+			others := bb.OccupiedBy(chess.White) &^ bb.Pieces(chess.WKing)
+			if !others.IsEmpty() { // the piece is not captured
+				sq := others.LeastSignificantBit()
+				nk := materialKey(bb.Pieces(chess.WKing).LeastSignificantBit(), sq, bb.Pieces(chess.BKing).LeastSignificantBit(), stm^1)
+				if promoted := bb.GetPieceAt(chess.Square(sq)); promoted != piece {
+					e.value = solved[promoted.Type()].wdl[nk]
+				} else {
+					e.next = nk
+				}
+			}
+			edges[k] = append(edges[k], e)
+		}
+	}
+
+	// a position is won when a move loses for the opponent and lost when all the moves win for the opponent,
+	// the positions left are draws
+	for changed := true; changed; {
+		changed = false
+		for k, moves := range edges {
+			if !m.legal[k] || known[k] {
+				continue
+			}
+
+			best, unknown := Loss, false
+			for _, e := range moves {
+				value := e.value
+				if e.next >= 0 {
+					if !known[e.next] {
+						unknown = true
+						continue
+					}
+					value = m.wdl[e.next]
+				}
+				best = max(best, -value)
+			}
+
+			if best == Win || !unknown {
+				m.wdl[k], known[k], changed = best, true, true
+			}
+		}
+	}
+
+	// the DTZ of a win is the shortest way to a zeroing move or a mate, the DTZ of a loss the longest one
+	for k := range m.dtz {
+		m.dtz[k] = -1
+		if m.legal[k] && len(edges[k]) == 0 && m.wdl[k] == Loss {
+			m.dtz[k] = 0
+		}
+	}
+	for level, assigned := 1, true; assigned; level++ {
+		assigned = false
+		for k, moves := range edges {
+			if !m.legal[k] || m.dtz[k] >= 0 || m.wdl[k] == Draw {
+				continue
+			}
+
+			dtz := -1
+			for _, e := range moves {
+				next := e.value
+				if e.next >= 0 {
+					next = m.wdl[e.next]
+				}
+
+				if m.wdl[k] == Win {
+					if next == Loss && (e.zeroing && level == 1 || !e.zeroing && m.dtz[e.next] == level-1) {
+						dtz = level
+					}
+					continue
+				}
+
+				switch {
+				case e.zeroing:
+					dtz = max(dtz, 1)
+				case m.dtz[e.next] < 0 || m.dtz[e.next] >= level:
+					dtz = -2 // the longest way is not known yet
+				case dtz != -2:
+					dtz = max(dtz, m.dtz[e.next]+1)
+				}
+				if dtz == -2 {
+					break
+				}
+			}
+
+			if dtz > 0 {
+				m.dtz[k], assigned = dtz, true
+			}
+		}
+	}
+
+	for k := range m.dtz {
+		if m.legal[k] && m.wdl[k] != Draw && (m.dtz[k] < 0 || m.dtz[k] > 100) {
+			t.Fatalf("%s: %s DTZ = %d", m.name, m.fen(k), m.dtz[k])
+		}
+	}
+	return m
+}
+
+// materialFEN returns the FEN of a position of the material
+func materialFEN(piece chess.Piece, wk, wp, bk, stm int) string {
+	var board [64]byte
+	board[wk], board[wp], board[bk] = 'K', byte(piece), 'k'
+
+	var fen []byte
+	for rank := 7; rank >= 0; rank-- {
+		empty := byte(0)
+		for file := range 8 {
+			if c := board[rank*8+file]; c != 0 {
+				if empty > 0 {
+					fen = append(fen, '0'+empty)
+				}
+				fen, empty = append(fen, c), 0
+			} else {
+				empty++
+			}
+		}
+		if empty > 0 {
+			fen = append(fen, '0'+empty)
+		}
+		if rank > 0 {
+			fen = append(fen, '/')
+		}
+	}
+	return string(fen) + [2]string{" w - - 0 1", " b - - 0 1"}[stm]
+}
+
+func (m *material) fen(k int) string {
+	return materialFEN(m.piece, k>>13, k>>7&63, k>>1&63, k&1)
+}
+
+// writeTables writes the WDL and the DTZ table of the material to dir,
+// the DTZ tables store white to move and the values in plies
+func writeTables(t *testing.T, dir string, m *material) {
+	pieces := []byte{byte(chess.King), byte(m.piece.Type()), byte(chess.King) | blackCode}
+	if m.piece == chess.WPawn {
+		pieces = []byte{pawnCode, byte(chess.King), byte(chess.King) | blackCode}
+	}
+
+	for kind, magic := range [2][4]byte{wdlMagic, dtzMagic} {
+		tb, err := newTable(tableKind(kind), m.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		files, sides, flags := 1, 2, byte(1) // the WDL table is split by side to move
+		if tb.hasPawns {
+			files, flags = 4, flags|2
+		}
+		if tb.kind == dtzTable {
+			sides, flags = 1, flags&^1
+		}
+
+		values := map[*pairsData][]int{}
+		for f := range files {
+			for side := range sides {
+				d := &pairsData{}
+				copy(d.pieces[:], pieces)
+				if tb.kind == dtzTable {
+					d.flags = flagWinPlies | flagLossPlies
+				}
+				tb.setGroups(d, [2]int{0, 0xF}, f)
+				tb.items[side][f] = d
+
+				values[d] = make([]int, d.groupIdx[slices.Index(d.groupLen[:], 0)])
+				for i := range values[d] {
+					values[d][i] = -1 // not a legal position
+				}
+			}
+		}
+
+		for k, legal := range m.legal {
+			if !legal {
+				continue
+			}
+			var b board
+			b.pieces[k>>13], b.pieces[k>>7&63], b.pieces[k>>1&63], b.stm = pieces[0], pieces[1], pieces[2], k&1
+			if m.piece == chess.WPawn {
+				b.pieces[k>>13], b.pieces[k>>7&63] = pieces[1], pieces[0]
+			}
+
+			d, idx, _, changeSTM := tb.encode(&b, false)
+			value := int(m.wdl[k]) + 2
+			if tb.kind == dtzTable {
+				// only white to move is stored, the draws and the mated positions are never probed
+				if changeSTM || m.wdl[k] == Draw || m.dtz[k] == 0 {
+					continue
+				}
+				value = m.dtz[k] - 1
+			}
+
+			if old := values[d][idx]; old >= 0 && old != value {
+				t.Fatalf("%s: %s has the index %d of a position with the value %d, not %d", m.name, m.fen(k), idx, old, value)
+			}
+			values[d][idx] = value
+		}
+
+		data := append(magic[:], flags)
+		for range files {
+			data = append(data, 0x00) // order of the groups
+			for _, piece := range pieces {
+				data = append(data, piece|piece<<4)
+			}
+		}
+		data = append(data, make([]byte, len(data)&1)...)
+
+		var parts [][4][]byte
+		for f := range files {
+			for side := range sides {
+				d := tb.items[side][f]
+				part := compress(t, d.flags, values[d])
+				data = append(data, part[0]...)
+				parts = append(parts, part)
+			}
+		}
+		if tb.kind == dtzTable {
+			data = append(data, make([]byte, len(data)&1)...)
+		}
+		for i := 1; i < 4; i++ {
+			for _, part := range parts {
+				if i == 3 {
+					data = append(data, make([]byte, -len(data)&0x3F)...)
+				}
+				data = append(data, part[i]...)
+			}
+		}
+		// the table files end with 16 bytes of checksum
+		data = append(data, make([]byte, -len(data)&0x3F+16)...)
+
+		if err := os.WriteFile(filepath.Join(dir, m.name+extensions[kind]), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// compression parameters: 64 byte blocks and a sparse index entry every 256 values
+const (
+	blockBits   = 6
+	spanBits    = 8
+	maxSymbols  = 0xFFF
+	maxSymValue = 256
+)
+
+// compress returns the sizes, the sparse index, the block lengths and the blocks of a sub-table,
+// the values of the illegal positions, -1, repeat the previous value
+func compress(t *testing.T, flags byte, values []int) [4][]byte {
+	last := slices.IndexFunc(values, func(v int) bool { return v >= 0 })
+	if last < 0 {
+		return [4][]byte{{flags | flagSingleValue, 0}}
+	}
+	last = values[last]
+	for i, v := range values {
+		if v < 0 {
+			values[i] = last
+		}
+		last = values[i]
+	}
+	if !slices.ContainsFunc(values, func(v int) bool { return v != values[0] }) {
+		return [4][]byte{{flags | flagSingleValue, byte(values[0])}}
+	}
+
+	// the symbols are the values then the most frequent pairs of symbols
+	type symbol struct{ left, right, count int }
+	var syms []symbol
+	seq := make([]int, len(values))
+	for i, v := range values {
+		id := slices.IndexFunc(syms, func(s symbol) bool { return s.right < 0 && s.left == v })
+		if id < 0 {
+			id = len(syms)
+			syms = append(syms, symbol{left: v, right: -1, count: 1})
+		}
+		seq[i] = id
+	}
+
+	for len(syms) < maxSymbols {
+		pairs := map[[2]int]int{}
+		for i := 0; i+1 < len(seq); i++ {
+			if syms[seq[i]].count+syms[seq[i+1]].count <= maxSymValue {
+				pairs[[2]int{seq[i], seq[i+1]}]++
+			}
+		}
+
+		best, count := [2]int{}, 0
+		for pair, n := range pairs {
+			if n > count || n == count && (pair[0] < best[0] || pair[0] == best[0] && pair[1] < best[1]) {
+				best, count = pair, n
+			}
+		}
+		if count < 4 {
+			break
+		}
+
+		id := len(syms)
+		syms = append(syms, symbol{left: best[0], right: best[1], count: syms[best[0]].count + syms[best[1]].count})
+		out := seq[:0]
+		for i := 0; i < len(seq); i++ {
+			if i+1 < len(seq) && seq[i] == best[0] && seq[i+1] == best[1] {
+				out = append(out, id)
+				i++
+			} else {
+				out = append(out, seq[i])
+			}
+		}
+		seq = out
+	}
+
+	freq := make([]int, len(syms))
+	for _, s := range seq {
+		freq[s]++
+	}
+	lengths := huffmanLengths(freq)
+
+	// canonical code: the longest codes have the lowest symbols, the unused symbols are last
+	order := make([]int, len(syms))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int { return lengths[b] - lengths[a] })
+	newID := make([]int, len(syms))
+	for id, old := range order {
+		newID[old] = id
+	}
+
+	minLen, maxLen := 64, 0
+	for _, l := range lengths {
+		if l > 0 {
+			minLen, maxLen = min(minLen, l), max(maxLen, l)
+		}
+	}
+	if minLen == 0 || maxLen > 32 {
+		t.Fatalf("code lengths %d to %d", minLen, maxLen)
+	}
+
+	classes := maxLen - minLen + 1
+	counts := make([]int, classes)
+	for _, l := range lengths {
+		if l > 0 {
+			counts[l-minLen]++
+		}
+	}
+	lowestSym, base := make([]int, classes), make([]uint64, classes)
+	for i := classes - 2; i >= 0; i-- {
+		lowestSym[i] = lowestSym[i+1] + counts[i+1]
+		if (base[i+1]+uint64(counts[i+1]))%2 != 0 {
+			t.Fatal("incomplete Huffman code")
+		}
+		base[i] = (base[i+1] + uint64(counts[i+1])) / 2
+	}
+
+	sizes := []byte{flags, blockBits, spanBits, 0, 0, 0, 0, 0, byte(maxLen), byte(minLen)}
+	for _, sym := range lowestSym {
+		sizes = binary.LittleEndian.AppendUint16(sizes, uint16(sym))
+	}
+	sizes = binary.LittleEndian.AppendUint16(sizes, uint16(len(syms)))
+	tree := make([]byte, 3*len(syms))
+	for old, s := range syms {
+		left, right := s.left, maxSymbols
+		if s.right >= 0 {
+			left, right = newID[s.left], newID[s.right]
+		}
+		off := 3 * newID[old]
+		tree[off], tree[off+1], tree[off+2] = byte(left), byte(left>>8&0xF|right<<4), byte(right>>4)
+	}
+	sizes = append(sizes, tree...)
+	sizes = append(sizes, make([]byte, len(syms)&1)...)
+
+	// the symbols are packed in blocks, a symbol is never split
+	var blocks, blockLengths []byte
+	var starts []int
+	block, used, count, start := make([]byte, 1<<blockBits), 0, 0, 0
+	flush := func() {
+		blocks = append(blocks, block...)
+		blockLengths = binary.LittleEndian.AppendUint16(blockLengths, uint16(count-1))
+		starts = append(starts, start)
+		block, used, start, count = make([]byte, 1<<blockBits), 0, start+count, 0
+	}
+	for _, old := range seq {
+		l := lengths[old]
+		if used+l > 8<<blockBits || count+syms[old].count > 0x10000 {
+			flush()
+		}
+		i := newID[old] - lowestSym[l-minLen]
+		code := base[l-minLen] + uint64(i)
+		for b := l - 1; b >= 0; b-- {
+			if code>>b&1 != 0 {
+				block[used/8] |= 0x80 >> (used % 8)
+			}
+			used++
+		}
+		count += syms[old].count
+	}
+	flush()
+	binary.LittleEndian.PutUint32(sizes[4:], uint32(len(starts)))
+
+	// the entry k of the sparse index is the block and the offset of the value k * span + span/2
+	var sparse []byte
+	const span = 1 << spanBits
+	for k := 0; k*span < len(values); k++ {
+		ref := k*span + span/2
+		b := len(starts) - 1
+		for starts[b] > ref {
+			b--
+		}
+		if ref-starts[b] > 0xFFFF {
+			t.Fatalf("sparse index offset %d", ref-starts[b])
+		}
+		sparse = binary.LittleEndian.AppendUint32(sparse, uint32(b))
+		sparse = binary.LittleEndian.AppendUint16(sparse, uint16(ref-starts[b]))
+	}
+
+	return [4][]byte{sizes, sparse, blockLengths, blocks}
+}
+
+// huffmanLengths returns the code length of each symbol, 0 for the unused ones
+func huffmanLengths(freq []int) []int {
+	type node struct{ freq, parent int }
+	var nodes []node
+	var active []int
+	for _, f := range freq {
+		if f > 0 {
+			active = append(active, len(nodes))
+		}
+		nodes = append(nodes, node{f, -1})
+	}
+
+	for len(active) > 1 {
+		slices.SortStableFunc(active, func(a, b int) int { return nodes[a].freq - nodes[b].freq })
+		parent := len(nodes)
+		nodes = append(nodes, node{nodes[active[0]].freq + nodes[active[1]].freq, -1})
+		nodes[active[0]].parent, nodes[active[1]].parent = parent, parent
+		active = append(active[2:], parent)
+	}
+
+	lengths := make([]int, len(freq))
+	for i, f := range freq {
+		if f == 0 {
+			continue
+		}
+		for n := i; nodes[n].parent >= 0; n = nodes[n].parent {
+			lengths[i]++
+		}
+	}
+	return lengths
+}
+
+// checkTables probes all the positions of the material
+func checkTables(t *testing.T, tb *Tablebase, m *material) {
+	for k, legal := range m.legal {
+		if !legal {
+			continue
+		}
+		pos, _ := chess.ParsePosition(m.fen(k))
+
+		wdl, _, err := tb.search(pos, false)
+		if err != nil || wdl != m.wdl[k] {
+			t.Fatalf("%s: WDL = %v, %v, want %v", m.fen(k), wdl, err, m.wdl[k])
+		}
+
+		want := m.dtz[k] * sign(int(m.wdl[k]))
+		if m.wdl[k] == Loss && m.dtz[k] == 0 {
+			want = -1 // mated
+		}
+		if dtz, err := tb.probeDTZ(pos); err != nil || dtz != want {
+			t.Fatalf("%s: DTZ = %d, %v, want %d", m.fen(k), dtz, err, want)
+		}
+	}
+}
@@ -0,0 +1,353 @@
+// Package syzygy probes Syzygy endgame tablebases of up to 5 pieces
+//
+// references:
+// - https://syzygy-tables.info
+// - https://github.com/syzygy1/tb
+// - https://github.com/official-stockfish/Stockfish/blob/master/src/syzygy/tbprobe.cpp
+// - https://github.com/jdart1/Fathom
+
+package syzygy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	chess "github.com/tommjj/chess_OG/chess_core"
+)
+
+var (
+	ErrTableNotFound  = errors.New("tablebase table not found")
+	ErrInvalidTable   = errors.New("invalid tablebase table")
+	ErrTooManyPieces  = errors.New("too many pieces for the tablebases")
+	ErrCastlingRights = errors.New("tablebases do not have positions with castling rights")
+)
+
+// maxPieces is the largest number of pieces, kings included, of the supported tables
+const maxPieces = 5
+
+// pieceLetters are the piece letters of table names in table order, indexed by King - piece type
+const pieceLetters = "KQRBNP"
+
+// WDL is the result of a position for the side to move with the 50 move rule
+type WDL int
+
+const (
+	Loss        WDL = -2 // loss
+	BlessedLoss WDL = -1 // loss that is a draw by the 50 move rule
+	Draw        WDL = 0
+	CursedWin   WDL = 1 // win that is a draw by the 50 move rule
+	Win         WDL = 2 // win
+)
+
+func (w WDL) String() string {
+	switch w {
+	case Loss:
+		return "loss"
+	case BlessedLoss:
+		return "blessed loss"
+	case Draw:
+		return "draw"
+	case CursedWin:
+		return "cursed win"
+	case Win:
+		return "win"
+	default:
+		return fmt.Sprintf("WDL(%d)", int(w))
+	}
+}
+
+// Tablebase is a set of table files, the tables are read on their first probe
+type Tablebase struct {
+	mu        sync.Mutex
+	paths     [2]map[string]string // table name to file path by table kind
+	tables    [2]map[string]*table // loaded tables by table kind
+	maxPieces int
+}
+
+// Open scans the directories for .rtbw and .rtbz files,
+// files with other names or more than 5 pieces are ignored
+func Open(dirs ...string) (*Tablebase, error) {
+	tb := &Tablebase{
+		paths:  [2]map[string]string{{}, {}},
+		tables: [2]map[string]*table{{}, {}},
+	}
+
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+
+			ext := filepath.Ext(entry.Name())
+			name := strings.TrimSuffix(entry.Name(), ext)
+			for kind, tableExt := range extensions {
+				if ext != tableExt {
+					continue
+				}
+				if _, err := newTable(tableKind(kind), name); err != nil {
+					continue
+				}
+
+				tb.paths[kind][name] = filepath.Join(dir, entry.Name())
+				tb.maxPieces = max(tb.maxPieces, len(name)-1)
+			}
+		}
+	}
+
+	return tb, nil
+}
+
+// MaxPieces returns the largest number of pieces of the found tables
+func (tb *Tablebase) MaxPieces() int {
+	return tb.maxPieces
+}
+
+// ProbeWDL returns the result of the position for the side to move,
+// the position must have no castling rights and at most MaxPieces pieces
+func (tb *Tablebase) ProbeWDL(gs *chess.GameState) (WDL, error) {
+	pos := gs.Position()
+	if err := tb.check(pos); err != nil {
+		return Draw, err
+	}
+
+	wdl, _, err := tb.search(pos, false)
+	return wdl, err
+}
+
+// ProbeDTZ returns the distance to zeroing in plies of the position with its WDL sign,
+// the 50 move counter is reset by the next capture or pawn move after that many plies in an optimal game,
+// it is 0 for draws and is off by one ply for some positions, see DTZ in the syzygy documentation,
+// cursed wins and blessed losses are counted from 100
+func (tb *Tablebase) ProbeDTZ(gs *chess.GameState) (int, error) {
+	pos := gs.Position()
+	if err := tb.check(pos); err != nil {
+		return 0, err
+	}
+
+	return tb.probeDTZ(pos)
+}
+
+func (tb *Tablebase) check(pos chess.Position) error {
+	if pos.CastlingRights() != 0 {
+		return ErrCastlingRights
+	}
+	bb := pos.BitBoards()
+	if n := bb.CountPieces(); n > tb.maxPieces || n > maxPieces {
+		return fmt.Errorf("%w: %d", ErrTooManyPieces, n)
+	}
+	return nil
+}
+
+// search returns the WDL value of the position after the captures, and pawn moves when checkZeroing is set,
+// zeroingBest is set when one of them is the best move.
+// the tables do not have en passant so the captures are searched before probing
+func (tb *Tablebase) search(pos chess.Position, checkZeroing bool) (value WDL, zeroingBest bool, err error) {
+	best := Loss
+	moves := pos.LegalMoves()
+	count := 0
+
+	for _, move := range moves {
+		if !move.IsCapture() && (!checkZeroing || chess.ASCIIPieces[move.Piece()].Type() != chess.Pawn) {
+			continue
+		}
+		count++
+
+		next, _ := pos.MakeUnsafeMove(move)
+		v, _, err := tb.search(next, false)
+		if err != nil {
+			return Draw, false, err
+		}
+
+		if -v > best {
+			best = -v
+			if best >= Win {
+				return best, true, nil
+			}
+		}
+	}
+
+	// all the legal moves have been searched
+	noMoreMoves := count > 0 && count == len(moves)
+	if noMoreMoves {
+		value = best
+	} else {
+		v, _, err := tb.probe(wdlTable, pos, Draw)
+		if err != nil {
+			return Draw, false, err
+		}
+		value = WDL(v)
+	}
+
+	if best >= value {
+		return best, best > Draw || noMoreMoves, nil
+	}
+	return value, false, nil
+}
+
+func (tb *Tablebase) probeDTZ(pos chess.Position) (int, error) {
+	wdl, zeroingBest, err := tb.search(pos, true)
+	if err != nil || wdl == Draw {
+		return 0, err
+	}
+	if zeroingBest {
+		return dtzBeforeZeroing(wdl), nil
+	}
+
+	dtz, changeSTM, err := tb.probe(dtzTable, pos, wdl)
+	if err != nil {
+		return 0, err
+	}
+	if !changeSTM {
+		if wdl == CursedWin || wdl == BlessedLoss {
+			dtz += 100
+		}
+		return dtz * sign(int(wdl)), nil
+	}
+
+	// the table only has the other side to move, the DTZ is the best one of the moves
+	minDTZ := 0xFFFF
+	for _, move := range pos.LegalMoves() {
+		zeroing := move.IsCapture() || chess.ASCIIPieces[move.Piece()].Type() == chess.Pawn
+
+		next, _ := pos.MakeUnsafeMove(move)
+		if zeroing {
+			var v WDL
+			v, _, err = tb.search(next, false)
+			dtz = -dtzBeforeZeroing(v)
+		} else {
+			dtz, err = tb.probeDTZ(next)
+			dtz = -dtz
+		}
+
+		// a mate can not be found by the tables
+		if dtz == 1 && next.InCheck() && len(next.LegalMoves()) == 0 {
+			minDTZ = 1
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		if !zeroing {
+			dtz += sign(dtz)
+		}
+		if dtz < minDTZ && sign(dtz) == sign(int(wdl)) {
+			minDTZ = dtz
+		}
+	}
+
+	// no move keeps the result, the side to move is mated
+	if minDTZ == 0xFFFF {
+		return -1, nil
+	}
+	return minDTZ, nil
+}
+
+// dtzBeforeZeroing returns the DTZ of a position where the best move is zeroing
+func dtzBeforeZeroing(wdl WDL) int {
+	switch wdl {
+	case Win:
+		return 1
+	case CursedWin:
+		return 101
+	case BlessedLoss:
+		return -101
+	case Loss:
+		return -1
+	default:
+		return 0
+	}
+}
+
+func sign(n int) int {
+	switch {
+	case n > 0:
+		return 1
+	case n < 0:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// probe looks the position up in its table, the value is a WDL for WDL tables
+func (tb *Tablebase) probe(kind tableKind, pos chess.Position, wdl WDL) (value int, changeSTM bool, err error) {
+	bb := pos.BitBoards()
+
+	var b board
+	var material [2]strings.Builder
+	for _, pieceType := range [6]chess.PieceType{chess.King, chess.Queen, chess.Rook, chess.Bishop, chess.Knight, chess.Pawn} {
+		for side, color := range [2]chess.Color{chess.White, chess.Black} {
+			pieces := bb.PiecesByType(color, pieceType)
+			for sq := range chess.Square(64) {
+				if pieces.IsSet(sq) {
+					b.pieces[sq] = byte(pieceType) | byte(side)*blackCode
+					material[side].WriteByte(pieceLetters[chess.King-pieceType])
+				}
+			}
+		}
+	}
+	if pos.SideToMove() == chess.Black {
+		b.stm = 1
+	}
+
+	// only the kings are left
+	if bb.CountPieces() == 2 {
+		return int(Draw), false, nil
+	}
+
+	// the table is named after the stronger side first
+	name := material[0].String() + "v" + material[1].String()
+	blackStronger := false
+	t, err := tb.table(kind, name)
+	if errors.Is(err, ErrTableNotFound) {
+		name = material[1].String() + "v" + material[0].String()
+		blackStronger = true
+		t, err = tb.table(kind, name)
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	value, changeSTM = t.probe(&b, blackStronger, wdl)
+	return value, changeSTM, nil
+}
+
+// table returns the loaded table, the file is read on the first call
+func (tb *Tablebase) table(kind tableKind, name string) (*table, error) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	if t, ok := tb.tables[kind][name]; ok {
+		return t, nil
+	}
+
+	path, ok := tb.paths[kind][name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s%s", ErrTableNotFound, name, extensions[kind])
+	}
+
+	t, err := newTable(kind, name)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.init(data); err != nil {
+		return nil, err
+	}
+
+	tb.tables[kind][name] = t
+	return t, nil
+}
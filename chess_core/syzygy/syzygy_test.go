@@ -0,0 +1,328 @@
+package syzygy
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	chess "github.com/tommjj/chess_OG/chess_core"
+)
+
+// writeTable writes a pawnless 3 piece table with a single value for each side to move,
+// DTZ tables store white to move only
+func writeTable(t *testing.T, dir, file string, kind tableKind, pieces [3]byte, values ...byte) {
+	t.Helper()
+
+	magic := wdlMagic
+	flags := byte(1) // split: both sides to move
+	if kind == dtzTable {
+		magic, flags = dtzMagic, 0
+	}
+
+	data := append(magic[:], flags, 0x00)
+	for _, piece := range pieces {
+		data = append(data, piece|piece<<4)
+	}
+	data = append(data, 0) // word alignment
+	for _, value := range values {
+		data = append(data, flagSingleValue, value)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, file), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEncodingTables(t *testing.T) {
+	// 462 placements of two kings with the first one in the a1-d1-d4 triangle
+	count := 0
+	for _, row := range mapKK {
+		for _, idx := range row {
+			count = max(count, idx+1)
+		}
+	}
+	if count != 462 {
+		t.Errorf("mapKK has %d codes, want 462", count)
+	}
+
+	if got := mapB1H1H7[chess.SquareH7]; got != 27 {
+		t.Errorf("mapB1H1H7[h7] = %d, want 27", got)
+	}
+	if got := binomial[3][48]; got != 17296 {
+		t.Errorf("binomial[3][48] = %d, want 17296", got)
+	}
+}
+
+// the index of a pawnless position does not change with the board symmetries
+func TestEncodeSymmetry(t *testing.T) {
+	dir := t.TempDir()
+	writeTable(t, dir, "KRvK.rtbw", wdlTable, [3]byte{6, 4, 6 | blackCode}, 4, 0)
+
+	tb, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, err := tb.table(wdlTable, "KRvK")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	symmetries := []func(int) int{
+		func(sq int) int { return sq ^ 7 },
+		func(sq int) int { return sq ^ 56 },
+		func(sq int) int { return (sq>>3 | sq<<3) & 63 },
+	}
+
+	for _, squares := range [][3]int{{0, 9, 63}, {4, 60, 20}, {27, 36, 18}, {0, 63, 9}, {10, 3, 45}, {18, 45, 0}} {
+		var b board
+		b.pieces[squares[0]], b.pieces[squares[1]], b.pieces[squares[2]] = 6, 4, 6|blackCode
+		_, want, _, _ := table.encode(&b, false)
+		if want >= 31332 {
+			t.Fatalf("encode(%v) = %d, want < 31332", squares, want)
+		}
+
+		for i, symmetry := range symmetries {
+			var mirrored board
+			for sq, piece := range b.pieces {
+				mirrored.pieces[symmetry(sq)] = piece
+			}
+			if _, got, _, _ := table.encode(&mirrored, false); got != want {
+				t.Errorf("encode(%v) with symmetry %d = %d, want %d", squares, i, got, want)
+			}
+		}
+	}
+}
+
+func TestProbeWDL(t *testing.T) {
+	dir := t.TempDir()
+	writeTable(t, dir, "KRvK.rtbw", wdlTable, [3]byte{6, 4, 6 | blackCode}, 4, 0)
+
+	tb, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tb.MaxPieces(); got != 3 {
+		t.Errorf("MaxPieces() = %d, want 3", got)
+	}
+
+	tests := []struct {
+		fen  string
+		want WDL
+	}{
+		{"8/8/8/8/8/2k5/8/K6R w - - 0 1", Win},
+		{"8/8/8/8/8/2k5/8/K6R b - - 0 1", Loss},
+		{"7r/8/8/8/8/2K5/8/k7 w - - 0 1", Loss}, // colors switched
+		{"7r/8/8/8/8/2K5/8/k7 b - - 0 1", Win},
+		{"8/8/8/8/8/8/6kR/K7 b - - 0 1", Draw}, // the rook is captured
+		{"8/8/8/8/8/2k5/8/K7 w - - 0 1", Draw},
+	}
+
+	for _, tt := range tests {
+		gs := chess.NewGame()
+		if err := gs.FromFEN(tt.fen); err != nil {
+			t.Fatal(err)
+		}
+		got, err := tb.ProbeWDL(gs)
+		if err != nil {
+			t.Fatalf("ProbeWDL(%q): %v", tt.fen, err)
+		}
+		if got != tt.want {
+			t.Errorf("ProbeWDL(%q) = %v, want %v", tt.fen, got, tt.want)
+		}
+	}
+
+	errTests := []struct {
+		fen  string
+		want error
+	}{
+		{"8/8/8/8/8/2k5/8/K6Q w - - 0 1", ErrTableNotFound},
+		{"4k3/8/8/8/8/8/8/R3K3 w Q - 0 1", ErrCastlingRights},
+		{"4k3/8/8/8/8/8/8/RR2K3 w - - 0 1", ErrTooManyPieces},
+	}
+
+	for _, tt := range errTests {
+		gs := chess.NewGame()
+		if err := gs.FromFEN(tt.fen); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tb.ProbeWDL(gs); !errors.Is(err, tt.want) {
+			t.Errorf("ProbeWDL(%q) error = %v, want %v", tt.fen, err, tt.want)
+		}
+	}
+}
+
+func TestProbeDTZ(t *testing.T) {
+	dir := t.TempDir()
+	writeTable(t, dir, "KRvK.rtbw", wdlTable, [3]byte{6, 4, 6 | blackCode}, 4, 0)
+	writeTable(t, dir, "KRvK.rtbz", dtzTable, [3]byte{6, 4, 6 | blackCode}, 3)
+
+	tb, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		fen  string
+		want int
+	}{
+		{"k7/8/8/8/8/2K5/8/7R w - - 0 1", 7}, // 3 moves stored as plies
+		{"k7/8/8/8/8/2K5/8/7R b - - 0 1", -8},
+		{"8/8/8/8/8/8/6kR/K7 b - - 0 1", 0},
+	}
+
+	for _, tt := range tests {
+		gs := chess.NewGame()
+		if err := gs.FromFEN(tt.fen); err != nil {
+			t.Fatal(err)
+		}
+		got, err := tb.ProbeDTZ(gs)
+		if err != nil {
+			t.Fatalf("ProbeDTZ(%q): %v", tt.fen, err)
+		}
+		if got != tt.want {
+			t.Errorf("ProbeDTZ(%q) = %d, want %d", tt.fen, got, tt.want)
+		}
+	}
+}
+
+func TestInvalidTable(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "KRvK.rtbw"), []byte{1, 2, 3, 4, 5}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tb, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gs := chess.NewGame()
+	if err := gs.FromFEN("8/8/8/8/8/2k5/8/K6R w - - 0 1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tb.ProbeWDL(gs); !errors.Is(err, ErrInvalidTable) {
+		t.Errorf("ProbeWDL() error = %v, want %v", err, ErrInvalidTable)
+	}
+}
+
+// a compressed KRvK table with a one bit code, white to move wins at odd indexes and draws at even ones
+func TestDecompress(t *testing.T) {
+	const (
+		tbSize    = 31332
+		blockBits = 512
+		numBlocks = (tbSize + blockBits - 1) / blockBits
+	)
+
+	data := append(wdlMagic[:], 1, 0x00, 0x66, 0x44, 0xEE, 0)
+
+	// white to move: 64 byte blocks, a sparse entry every 512 values, 1 bit symbols 0 and 1
+	data = append(data, 0, 6, 9, 0, numBlocks, 0, 0, 0, 1, 1)
+	data = append(data, 0, 0, 2, 0)
+	data = append(data, 2, 0xF0, 0xFF, 4, 0xF0, 0xFF) // leaves with the values 2 and 4
+	// black to move: a single value
+	data = append(data, flagSingleValue, 2)
+
+	for block := range numBlocks {
+		data = append(data, byte(block), 0, 0, 0, blockBits/2%256, blockBits/2/256)
+	}
+	for range numBlocks {
+		data = append(data, (blockBits-1)%256, (blockBits-1)/256)
+	}
+	for len(data)%64 != 0 {
+		data = append(data, 0)
+	}
+	for range numBlocks * 64 {
+		data = append(data, 0x55)
+	}
+	data = append(data, make([]byte, 8)...)
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "KRvK.rtbw"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tb, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, err := tb.table(wdlTable, "KRvK")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := table.items[0][0]
+	for _, idx := range []uint64{0, 1, 255, 256, 511, 512, 1000, 1001, tbSize - 1} {
+		want := 2 + 2*int(idx%2)
+		if got := table.decompress(d, idx); got != want {
+			t.Errorf("decompress(%d) = %d, want %d", idx, got, want)
+		}
+	}
+
+	for _, fen := range []string{"8/8/8/8/8/2k5/8/K6R w - - 0 1", "8/8/8/8/8/5k2/8/K2R4 w - - 0 1", "4k3/8/8/8/3K4/8/8/1R6 w - - 0 1"} {
+		gs := chess.NewGame()
+		if err := gs.FromFEN(fen); err != nil {
+			t.Fatal(err)
+		}
+		pos := gs.Position()
+
+		var b board
+		for sq := range chess.Square(64) {
+			if piece := pos.PieceAt(sq); chess.IsValidPiece(piece) {
+				b.pieces[sq] = byte(piece.Type())
+				if piece.Color() == chess.Black {
+					b.pieces[sq] |= blackCode
+				}
+			}
+		}
+		_, idx, _, _ := table.encode(&b, false)
+
+		want := Draw
+		if idx%2 == 1 {
+			want = Win
+		}
+		if got, err := tb.ProbeWDL(gs); err != nil || got != want {
+			t.Errorf("ProbeWDL(%q) = %v, %v, want %v", fen, got, err, want)
+		}
+	}
+}
+
+// the compressed 3 piece tables of testdata, see TestGenerateTables,
+// or the tables of the directory of SYZYGY_PATH, ex: the ones of https://tablebase.lichess.ovh/tables/standard
+func TestRealTables(t *testing.T) {
+	dir := os.Getenv("SYZYGY_PATH")
+	if dir == "" {
+		dir = "testdata"
+	}
+
+	tb, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		fen string
+		wdl WDL
+		dtz int
+	}{
+		{"k7/8/1K6/8/8/8/8/7R w - - 0 1", Win, 1},   // Rh8#
+		{"k7/8/1K6/8/8/8/8/7R b - - 0 1", Loss, -2}, // Kb8 Rh8#
+		{"8/8/8/8/8/8/6kR/K7 b - - 0 1", Draw, 0},   // Kxh2
+		{"8/4P3/8/8/8/8/k7/4K3 w - - 0 1", Win, 1},  // e8=Q
+		{"k7/8/8/8/8/8/P7/K7 w - - 0 1", Draw, 0},   // rook pawn
+		{"8/8/8/8/8/8/3kP3/7K b - - 0 1", Draw, 0},  // Kxe2
+	}
+
+	for _, tt := range tests {
+		gs := chess.NewGame()
+		if err := gs.FromFEN(tt.fen); err != nil {
+			t.Fatal(err)
+		}
+
+		if wdl, err := tb.ProbeWDL(gs); err != nil || wdl != tt.wdl {
+			t.Errorf("ProbeWDL(%q) = %v, %v, want %v", tt.fen, wdl, err, tt.wdl)
+		}
+		if dtz, err := tb.ProbeDTZ(gs); err != nil || dtz != tt.dtz {
+			t.Errorf("ProbeDTZ(%q) = %d, %v, want %d", tt.fen, dtz, err, tt.dtz)
+		}
+	}
+}
@@ -0,0 +1,694 @@
+package syzygy
+
+import (
+	"encoding/binary"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type tableKind int
+
+const (
+	wdlTable tableKind = iota
+	dtzTable
+)
+
+var (
+	wdlMagic = [4]byte{0x71, 0xE8, 0x23, 0x5D}
+	dtzMagic = [4]byte{0xD7, 0x66, 0x0C, 0xA5}
+)
+
+// file extensions by table kind
+var extensions = [2]string{".rtbw", ".rtbz"}
+
+// pairs data flags
+const (
+	flagSTM         = 1
+	flagMapped      = 2
+	flagWinPlies    = 4
+	flagLossPlies   = 8
+	flagWide        = 16
+	flagSingleValue = 128
+)
+
+// table piece codes: type 1..6 (pawn..king) and 8 for black, same as the chess_core piece types
+const (
+	pawnCode  = 1
+	blackCode = 8
+)
+
+// pairsData is a compressed sub-table for one side to move and one leading pawn file,
+// offsets are relative to the start of the file
+type pairsData struct {
+	flags       byte
+	sizeofBlock int
+	span        uint64
+	numBlocks   int
+	maxSymLen   int
+	minSymLen   int // the value of single value tables
+
+	pieces   [maxPieces]byte
+	groupIdx [maxPieces + 1]uint64
+	groupLen [maxPieces + 1]int
+	mapIdx   [4]int // offsets of the DTZ maps from table.mapOffset
+
+	lowestSym       int
+	base64          []uint64
+	symlen          []byte
+	btree           int
+	sparseIndex     int
+	sparseIndexSize int
+	blockLength     int
+	blockLengthSize int
+	data            int
+}
+
+// table is a WDL or DTZ table file, the white pieces are the left side of its name
+type table struct {
+	kind tableKind
+	name string
+
+	pieceCount      int
+	hasPawns        bool
+	hasUniquePieces bool
+	symmetric       bool   // both sides have the same pieces
+	pawnCount       [2]int // pawns of the leading color first
+
+	data      []byte
+	items     [2][4]*pairsData // [side][file]
+	mapOffset int
+}
+
+// newTable returns the table of a name like "KRPvKR", the file is loaded later
+func newTable(kind tableKind, name string) (*table, error) {
+	white, black, ok := strings.Cut(name, "v")
+	if !ok || !validSide(white) || !validSide(black) || len(white)+len(black) > maxPieces {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTable, name)
+	}
+
+	t := &table{
+		kind:       kind,
+		name:       name,
+		pieceCount: len(white) + len(black),
+		hasPawns:   strings.ContainsRune(name, 'P'),
+		symmetric:  white == black,
+	}
+
+	for _, side := range [2]string{white, black} {
+		for _, c := range "QRBNP" {
+			if strings.Count(side, string(c)) == 1 {
+				t.hasUniquePieces = true
+			}
+		}
+	}
+
+	// the leading color has the fewest pawns, it is white when black has none
+	whitePawns, blackPawns := strings.Count(white, "P"), strings.Count(black, "P")
+	if blackPawns == 0 || whitePawns > 0 && blackPawns >= whitePawns {
+		t.pawnCount = [2]int{whitePawns, blackPawns}
+	} else {
+		t.pawnCount = [2]int{blackPawns, whitePawns}
+	}
+
+	return t, nil
+}
+
+// validSide reports whether the pieces of one side are a king followed by pieces in KQRBNP order
+func validSide(side string) bool {
+	if len(side) == 0 || side[0] != 'K' || strings.Count(side, "K") != 1 {
+		return false
+	}
+
+	last := 0
+	for _, c := range side {
+		i := strings.IndexRune(pieceLetters, c)
+		if i < last {
+			return false
+		}
+		last = i
+	}
+	return true
+}
+
+func (t *table) u16(off int) int     { return int(binary.LittleEndian.Uint16(t.data[off:])) }
+func (t *table) u32(off int) int     { return int(binary.LittleEndian.Uint32(t.data[off:])) }
+func (t *table) be32(off int) uint64 { return uint64(binary.BigEndian.Uint32(t.data[off:])) }
+func (t *table) be64(off int) uint64 { return binary.BigEndian.Uint64(t.data[off:]) }
+
+// left and right return the symbols a symbol of the pairs tree expands to
+func (t *table) left(d *pairsData, sym int) int {
+	off := d.btree + 3*sym
+	return int(t.data[off+1]&0xF)<<8 | int(t.data[off])
+}
+
+func (t *table) right(d *pairsData, sym int) int {
+	off := d.btree + 3*sym
+	return int(t.data[off+2])<<4 | int(t.data[off+1]>>4)
+}
+
+// init parses the table file, reads out of range on truncated files panic and are recovered as ErrInvalidTable
+func (t *table) init(data []byte) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %s: %v", ErrInvalidTable, t.name, r)
+		}
+	}()
+
+	magic := wdlMagic
+	if t.kind == dtzTable {
+		magic = dtzMagic
+	}
+	if len(data) < 5 || [4]byte(data[:4]) != magic {
+		return fmt.Errorf("%w: %s: bad magic", ErrInvalidTable, t.name)
+	}
+	if hasPawns := data[4]&2 != 0; hasPawns != t.hasPawns {
+		return fmt.Errorf("%w: %s: pawn flag does not match the name", ErrInvalidTable, t.name)
+	}
+
+	t.data = data
+	pos := 5
+
+	sides := 1
+	if t.kind == wdlTable && !t.symmetric {
+		sides = 2
+	}
+	maxFile := 0
+	if t.hasPawns {
+		maxFile = 3
+	}
+	pp := t.hasPawns && t.pawnCount[1] > 0 // pawns on both sides
+
+	for f := 0; f <= maxFile; f++ {
+		for i := range sides {
+			t.items[i][f] = &pairsData{}
+		}
+
+		order := [2][2]int{{int(data[pos] & 0xF), 0xF}, {int(data[pos] >> 4), 0xF}}
+		if pp {
+			order[0][1], order[1][1] = int(data[pos+1]&0xF), int(data[pos+1]>>4)
+			pos++
+		}
+		pos++
+
+		for k := 0; k < t.pieceCount; k, pos = k+1, pos+1 {
+			for i := range sides {
+				if i == 0 {
+					t.items[i][f].pieces[k] = data[pos] & 0xF
+				} else {
+					t.items[i][f].pieces[k] = data[pos] >> 4
+				}
+			}
+		}
+
+		for i := range sides {
+			t.setGroups(t.items[i][f], order[i], f)
+		}
+	}
+
+	pos += pos & 1 // word alignment
+
+	for f := 0; f <= maxFile; f++ {
+		for i := range sides {
+			pos = t.setSizes(t.items[i][f], pos)
+		}
+	}
+
+	if t.kind == dtzTable {
+		pos = t.setDTZMap(pos, maxFile)
+	}
+
+	for f := 0; f <= maxFile; f++ {
+		for i := range sides {
+			d := t.items[i][f]
+			d.sparseIndex = pos
+			pos += d.sparseIndexSize * 6
+		}
+	}
+
+	for f := 0; f <= maxFile; f++ {
+		for i := range sides {
+			d := t.items[i][f]
+			d.blockLength = pos
+			pos += d.blockLengthSize * 2
+		}
+	}
+
+	for f := 0; f <= maxFile; f++ {
+		for i := range sides {
+			d := t.items[i][f]
+			pos = (pos + 0x3F) &^ 0x3F // 64 byte alignment
+			d.data = pos
+			pos += d.numBlocks * d.sizeofBlock
+
+			if d.numBlocks > 0 && pos > len(data) {
+				return fmt.Errorf("%w: %s: truncated file", ErrInvalidTable, t.name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// setGroups splits the pieces in groups encoded together and computes the index factor of each group
+func (t *table) setGroups(d *pairsData, order [2]int, file int) {
+	firstLen := 2
+	switch {
+	case t.hasPawns:
+		firstLen = 0
+	case t.hasUniquePieces:
+		firstLen = 3
+	}
+
+	// ex: KRvKN is encoded as (KRK, N), groupLen is (3, 1)
+	n := 0
+	d.groupLen[0] = 1
+	for i := 1; i < t.pieceCount; i++ {
+		firstLen--
+		if firstLen > 0 || d.pieces[i] == d.pieces[i-1] {
+			d.groupLen[n]++
+		} else {
+			n++
+			d.groupLen[n] = 1
+		}
+	}
+	n++
+	d.groupLen[n] = 0
+
+	// the index is g1 * N(g2) * N(g3) + g2 * N(g3) + g3 in the group order of the table,
+	// the leading group is at order[0] and the remaining pawns at order[1]
+	pp := t.hasPawns && t.pawnCount[1] > 0
+	next := 1
+	freeSquares := 64 - d.groupLen[0]
+	if pp {
+		next = 2
+		freeSquares -= d.groupLen[1]
+	}
+
+	idx := uint64(1)
+	for k := 0; next < n || k == order[0] || k == order[1]; k++ {
+		switch k {
+		case order[0]: // leading pawns or pieces
+			d.groupIdx[0] = idx
+			switch {
+			case t.hasPawns:
+				idx *= uint64(leadPawnsSize[d.groupLen[0]][file])
+			case t.hasUniquePieces:
+				idx *= 31332
+			default:
+				idx *= 462
+			}
+		case order[1]: // remaining pawns
+			d.groupIdx[1] = idx
+			idx *= binomial[d.groupLen[1]][48-d.groupLen[0]]
+		default: // remaining pieces
+			d.groupIdx[next] = idx
+			idx *= binomial[d.groupLen[next]][freeSquares]
+			freeSquares -= d.groupLen[next]
+			next++
+		}
+	}
+
+	d.groupIdx[n] = idx
+}
+
+// setSizes reads the block sizes and the Huffman code of a sub-table
+func (t *table) setSizes(d *pairsData, pos int) int {
+	data := t.data
+
+	d.flags = data[pos]
+	pos++
+
+	if d.flags&flagSingleValue != 0 {
+		d.minSymLen = int(data[pos])
+		return pos + 1
+	}
+
+	// the index of the zero group length is the table size
+	tbSize := d.groupIdx[slices.Index(d.groupLen[:], 0)]
+
+	d.sizeofBlock = 1 << data[pos]
+	d.span = 1 << data[pos+1]
+	d.sparseIndexSize = int((tbSize + d.span - 1) / d.span)
+	padding := int(data[pos+2])
+	d.numBlocks = t.u32(pos + 3)
+	d.blockLengthSize = d.numBlocks + padding // padded so the sparse index stays in range
+	d.maxSymLen = int(data[pos+7])
+	d.minSymLen = int(data[pos+8])
+	pos += 9
+
+	// canonical Huffman code: base64[l] is the lowest symbol of length l + minSymLen padded to 64 bits
+	d.lowestSym = pos
+	d.base64 = make([]uint64, d.maxSymLen-d.minSymLen+1)
+	for i := len(d.base64) - 2; i >= 0; i-- {
+		d.base64[i] = (d.base64[i+1] + uint64(t.u16(d.lowestSym+2*i)) - uint64(t.u16(d.lowestSym+2*(i+1)))) / 2
+	}
+	for i := range d.base64 {
+		d.base64[i] <<= 64 - i - d.minSymLen
+	}
+	pos += len(d.base64) * 2
+
+	numSyms := t.u16(pos)
+	pos += 2
+	d.btree = pos
+
+	d.symlen = make([]byte, numSyms)
+	visited := make([]bool, numSyms)
+	for sym := range numSyms {
+		if !visited[sym] {
+			d.symlen[sym] = t.setSymlen(d, sym, visited)
+		}
+	}
+
+	return pos + numSyms*3 + numSyms&1
+}
+
+// setSymlen returns the number of values minus one a symbol of the pairs tree expands to
+func (t *table) setSymlen(d *pairsData, sym int, visited []bool) byte {
+	visited[sym] = true
+
+	right := t.right(d, sym)
+	if right == 0xFFF {
+		return 0
+	}
+
+	left := t.left(d, sym)
+	if !visited[left] {
+		d.symlen[left] = t.setSymlen(d, left, visited)
+	}
+	if !visited[right] {
+		d.symlen[right] = t.setSymlen(d, right, visited)
+	}
+
+	return d.symlen[left] + d.symlen[right] + 1
+}
+
+// setDTZMap reads the maps from the stored DTZ values to the distances of each WDL result
+func (t *table) setDTZMap(pos, maxFile int) int {
+	t.mapOffset = pos
+
+	for f := 0; f <= maxFile; f++ {
+		d := t.items[0][f]
+		if d.flags&flagMapped == 0 {
+			continue
+		}
+
+		if d.flags&flagWide != 0 {
+			pos += pos & 1
+			for i := range d.mapIdx {
+				d.mapIdx[i] = pos + 2 - t.mapOffset
+				pos += 2*t.u16(pos) + 2
+			}
+		} else {
+			for i := range d.mapIdx {
+				d.mapIdx[i] = pos + 1 - t.mapOffset
+				pos += int(t.data[pos]) + 1
+			}
+		}
+	}
+
+	return pos + pos&1
+}
+
+// decompress returns the value stored at index idx
+func (t *table) decompress(d *pairsData, idx uint64) int {
+	if d.flags&flagSingleValue != 0 {
+		return d.minSymLen
+	}
+
+	// the sparse index entry k points near the value k * span
+	k := idx / d.span
+	block := t.u32(d.sparseIndex + 6*int(k))
+	offset := t.u16(d.sparseIndex + 6*int(k) + 4)
+	offset += int(idx%d.span) - int(d.span/2)
+
+	for offset < 0 {
+		block--
+		offset += t.u16(d.blockLength+2*block) + 1
+	}
+	for offset > t.u16(d.blockLength+2*block) {
+		offset -= t.u16(d.blockLength+2*block) + 1
+		block++
+	}
+
+	ptr := d.data + block*d.sizeofBlock
+	buf64 := t.be64(ptr)
+	ptr += 8
+	buf64Size := 64
+
+	var sym int
+	for {
+		l := 0
+		for buf64 < d.base64[l] {
+			l++
+		}
+
+		sym = int((buf64-d.base64[l])>>(64-l-d.minSymLen)) + t.u16(d.lowestSym+2*l)
+
+		if offset < int(d.symlen[sym])+1 {
+			break
+		}
+		offset -= int(d.symlen[sym]) + 1
+
+		l += d.minSymLen
+		buf64 <<= l
+		buf64Size -= l
+		if buf64Size <= 32 {
+			buf64Size += 32
+			buf64 |= t.be32(ptr) << (64 - buf64Size)
+			ptr += 4
+		}
+	}
+
+	// expand the symbol until the leaf holding the value
+	for d.symlen[sym] != 0 {
+		left := t.left(d, sym)
+		if offset < int(d.symlen[left])+1 {
+			sym = left
+		} else {
+			offset -= int(d.symlen[left]) + 1
+			sym = t.right(d, sym)
+		}
+	}
+
+	return t.left(d, sym)
+}
+
+// board is a position in table terms: piece codes by square and the side to move (0 white, 1 black)
+type board struct {
+	pieces [64]byte
+	stm    int
+}
+
+// encode returns the sub-table and the index of the position,
+// blackStronger is set when the white pieces of the position are the right side of the table name,
+// changeSTM is set when the DTZ table only stores the other side to move
+func (t *table) encode(b *board, blackStronger bool) (d *pairsData, idx uint64, file int, changeSTM bool) {
+	var squares [maxPieces]int
+	var pieces [maxPieces]byte
+	var leadPawns uint64
+	size, leadCount := 0, 0
+
+	// the table stores white to move for symmetric material and white as the left side,
+	// other positions are looked up with the colors switched and the board flipped
+	flip := t.symmetric && b.stm == 1 || blackStronger
+	flipColor, flipSquares, stm := byte(0), 0, b.stm
+	if flip {
+		flipColor, flipSquares, stm = blackCode, 56, b.stm^1
+	}
+
+	// tables with pawns are split by the file of the leading pawn, the one with the highest mapPawns
+	if t.hasPawns {
+		pawn := t.items[0][0].pieces[0] ^ flipColor
+		for sq := range 64 {
+			if b.pieces[sq] == pawn {
+				squares[size] = sq ^ flipSquares
+				leadPawns |= 1 << sq
+				size++
+			}
+		}
+		leadCount = size
+
+		lead := 0
+		for i := 1; i < leadCount; i++ {
+			if mapPawns[squares[i]] > mapPawns[squares[lead]] {
+				lead = i
+			}
+		}
+		squares[0], squares[lead] = squares[lead], squares[0]
+
+		file = min(squares[0]%8, 7-squares[0]%8)
+	}
+
+	// DTZ tables store one side to move
+	if t.kind == dtzTable {
+		flags := t.items[0][file].flags
+		if int(flags&flagSTM) != stm && !(t.symmetric && !t.hasPawns) {
+			return nil, 0, file, true
+		}
+	}
+
+	for sq := range 64 {
+		if b.pieces[sq] != 0 && leadPawns&(1<<sq) == 0 {
+			squares[size] = sq ^ flipSquares
+			pieces[size] = b.pieces[sq] ^ flipColor
+			size++
+		}
+	}
+
+	if t.kind == dtzTable {
+		d = t.items[0][file]
+	} else {
+		d = t.items[stm][file]
+	}
+
+	// order the pieces like the table
+	for i := leadCount; i < size-1; i++ {
+		for j := i + 1; j < size; j++ {
+			if d.pieces[i] == pieces[j] {
+				pieces[i], pieces[j] = pieces[j], pieces[i]
+				squares[i], squares[j] = squares[j], squares[i]
+				break
+			}
+		}
+	}
+
+	// the leading piece is mapped to the a-d files
+	if squares[0]%8 > 3 {
+		for i := range size {
+			squares[i] ^= 7
+		}
+	}
+
+	if t.hasPawns {
+		idx = uint64(leadPawnIdx[leadCount][squares[0]])
+
+		slices.SortStableFunc(squares[1:leadCount], func(a, b int) int { return mapPawns[a] - mapPawns[b] })
+		for i := 1; i < leadCount; i++ {
+			idx += binomial[i][mapPawns[squares[i]]]
+		}
+	} else {
+		// without pawns the leading piece is also mapped to the ranks 1-4
+		if squares[0]/8 > 3 {
+			for i := range size {
+				squares[i] ^= 56
+			}
+		}
+
+		// the first piece of the leading group off the a1-h8 diagonal is mapped below it
+		for i := 0; i < d.groupLen[0]; i++ {
+			off := offA1H8(squares[i])
+			if off == 0 {
+				continue
+			}
+			if off > 0 {
+				for j := i; j < size; j++ {
+					squares[j] = (squares[j]>>3 | squares[j]<<3) & 63
+				}
+			}
+			break
+		}
+
+		if t.hasUniquePieces {
+			idx = uint64(encodeUniquePieces(squares[0], squares[1], squares[2]))
+		} else {
+			idx = uint64(mapKK[mapA1D1D4[squares[0]]][squares[1]])
+		}
+	}
+
+	idx *= d.groupIdx[0]
+
+	// remaining pawns then pieces, each group in ascending square order
+	// with the squares of the previous groups removed
+	group := d.groupLen[0]
+	remainingPawns := t.hasPawns && t.pawnCount[1] > 0
+	for next := 1; d.groupLen[next] != 0; next++ {
+		length := d.groupLen[next]
+		slices.Sort(squares[group : group+length])
+
+		var n uint64
+		for i := range length {
+			sq := squares[group+i]
+			adjust := 0
+			for _, s := range squares[:group] {
+				if sq > s {
+					adjust++
+				}
+			}
+			if remainingPawns {
+				adjust += 8
+			}
+			n += binomial[i+1][sq-adjust]
+		}
+
+		remainingPawns = false
+		idx += n * d.groupIdx[next]
+		group += length
+	}
+
+	return d, idx, file, false
+}
+
+// encodeUniquePieces encodes the leading group of three unique pieces, the first one is in the a1-d1-d4 triangle
+func encodeUniquePieces(s0, s1, s2 int) int {
+	adjust1 := b2i(s1 > s0)
+	adjust2 := b2i(s2 > s0) + b2i(s2 > s1)
+
+	switch {
+	case offA1H8(s0) != 0: // first piece below the diagonal
+		return (mapA1D1D4[s0]*63+(s1-adjust1))*62 + s2 - adjust2
+	case offA1H8(s1) != 0: // first on the diagonal, second below
+		return (6*63+(s0/8)*28+mapB1H1H7[s1])*62 + s2 - adjust2
+	case offA1H8(s2) != 0: // first two on the diagonal, third below
+		return 6*63*62 + 4*28*62 + (s0/8)*7*28 + (s1/8-adjust1)*28 + mapB1H1H7[s2]
+	default: // all on the diagonal
+		return 6*63*62 + 4*28*62 + 4*7*28 + (s0/8)*7*6 + (s1/8-adjust1)*6 + (s2/8 - adjust2)
+	}
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// probe returns the WDL value or the DTZ of the position, wdl is the WDL value of the position for DTZ tables
+func (t *table) probe(b *board, blackStronger bool, wdl WDL) (value int, changeSTM bool) {
+	d, idx, file, changeSTM := t.encode(b, blackStronger)
+	if changeSTM {
+		return 0, true
+	}
+
+	value = t.decompress(d, idx)
+	if t.kind == wdlTable {
+		return value - 2, false
+	}
+
+	return t.mapDTZ(file, value, wdl), false
+}
+
+// mapDTZ converts a stored DTZ value to plies
+func (t *table) mapDTZ(file, value int, wdl WDL) int {
+	// map index by WDL value + 2
+	wdlMap := [5]int{1, 3, 0, 2, 0}
+
+	d := t.items[0][file]
+	if d.flags&flagMapped != 0 {
+		off := t.mapOffset + d.mapIdx[wdlMap[wdl+2]]
+		if d.flags&flagWide != 0 {
+			value = t.u16(off + 2*value)
+		} else {
+			value = int(t.data[off+value])
+		}
+	}
+
+	// distances are stored in moves unless the plies flags are set
+	if wdl == Win && d.flags&flagWinPlies == 0 ||
+		wdl == Loss && d.flags&flagLossPlies == 0 ||
+		wdl == CursedWin || wdl == BlessedLoss {
+		value *= 2
+	}
+
+	return value + 1
+}
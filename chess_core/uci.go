@@ -0,0 +1,98 @@
+// UCI long algebraic move notation
+//
+// references:
+// - https://www.chessprogramming.org/Algebraic_Chess_Notation#Long_Algebraic_Notation_.28LAN.29
+// - https://www.wbec-ridderkerk.nl/html/UCIProtocol.html
+
+package chess_core
+
+// ParseUCIMove parses a move in UCI notation, ex: "e2e4", "e7e8q"
+//
+//	returns the source square, the target square and the promotion piece type (0 if no promotion)
+func ParseUCIMove(s string) (from, to Square, promo PieceType, err error) {
+	return parseUCIMove(s, false)
+}
+
+// parseUCIMove is like ParseUCIMove, king allows promotions to a king
+func parseUCIMove(s string, king bool) (from, to Square, promo PieceType, err error) {
+	if len(s) != 4 && len(s) != 5 {
+		return -1, -1, 0, ErrInvalidUCIMove
+	}
+
+	var ok bool
+	if from, ok = parseSquare(s[0:2]); !ok {
+		return -1, -1, 0, ErrInvalidUCIMove
+	}
+	if to, ok = parseSquare(s[2:4]); !ok {
+		return -1, -1, 0, ErrInvalidUCIMove
+	}
+
+	if len(s) == 5 {
+		if promo, ok = promotionPieceType(s[4], king); !ok {
+			return -1, -1, 0, ErrInvalidUCIMove
+		}
+	}
+
+	return from, to, promo, nil
+}
+
+// parseUCIDrop parses a drop in UCI notation, ex: "N@f3", "P@e4"
+func parseUCIDrop(s string) (pt PieceType, to Square, ok bool) {
+	if len(s) != 4 || s[1] != '@' {
+		return 0, -1, false
+	}
+
+	p := Piece(s[0])
+	if !isWhitePiece(p) || p == WKing {
+		return 0, -1, false
+	}
+
+	if to, ok = parseSquare(s[2:]); !ok {
+		return 0, -1, false
+	}
+	return p.Type(), to, true
+}
+
+// UCI returns the move in UCI notation, ex: "e2e4", "e7e8q", "N@f3" for drops
+func (m Move) UCI() string {
+	if m.IsDrop() {
+		return string(ASCIIPieces[ASCIIPieces[m.Piece()].Type()]) + "@" + SquareToCoordinates[m.To()]
+	}
+
+	uci := SquareToCoordinates[m.From()] + SquareToCoordinates[m.To()]
+	if m.IsPromotion() {
+		uci += string(PromotedPieces[ASCIIPieces[m.Promoted()]])
+	}
+	return uci
+}
+
+// MakeMoveUCI is like MakeMove but takes the move in UCI notation, ex: "e2e4", "e7e8q",
+// drops are like MakeDrop, ex: "N@f3"
+func (gs *GameState) MakeMoveUCI(side Color, uci string) (GameStatus, error) {
+	if pt, to, ok := parseUCIDrop(uci); ok {
+		return gs.MakeDrop(side, pt, to)
+	}
+
+	from, to, promo, err := parseUCIMove(uci, promotesToKing(gs.Variant()))
+	if err != nil {
+		return "", err
+	}
+
+	return gs.MakeMove(side, from, to, promo)
+}
+
+// MoveToUCI returns the move in UCI notation of the current game,
+// castling moves are written as the king capturing its own rook in Chess960 games, ex: "b1h1"
+func (gs *GameState) MoveToUCI(move Move) string {
+	return gs.Position().MoveToUCI(move)
+}
+
+// MoveToUCI returns the move in UCI notation of the position, see GameState.MoveToUCI
+func (p Position) MoveToUCI(move Move) string {
+	ci := p.castlingSquares()
+	if !move.IsCastle() || !ci.chess960 {
+		return move.UCI()
+	}
+
+	return SquareToCoordinates[move.From()] + SquareToCoordinates[ci.rookSquare(castlingRightOf(move))]
+}
@@ -0,0 +1,55 @@
+package chess_core
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseUCIMove(t *testing.T) {
+	tests := []struct {
+		input string
+		from  Square
+		to    Square
+		promo PieceType
+		err   error
+	}{
+		{"e2e4", SquareE2, SquareE4, 0, nil},
+		{"e7e8q", SquareE7, SquareE8, Queen, nil},
+		{"a2a1n", SquareA2, SquareA1, Knight, nil},
+		{"e7e8k", -1, -1, 0, ErrInvalidUCIMove},
+		{"e9e4", -1, -1, 0, ErrInvalidUCIMove},
+		{"0000", -1, -1, 0, ErrInvalidUCIMove},
+		{"e2", -1, -1, 0, ErrInvalidUCIMove},
+	}
+
+	for _, tt := range tests {
+		from, to, promo, err := ParseUCIMove(tt.input)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("ParseUCIMove(%q) error = %v, want %v", tt.input, err, tt.err)
+			continue
+		}
+		if from != tt.from || to != tt.to || promo != tt.promo {
+			t.Errorf("ParseUCIMove(%q) = %s %s %d, want %s %s %d", tt.input, from, to, promo, tt.from, tt.to, tt.promo)
+		}
+	}
+}
+
+func TestMakeMoveUCI(t *testing.T) {
+	gs := NewGame()
+	if err := gs.FromFEN("4k3/1P6/8/8/8/8/8/4K3 w - - 0 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := gs.MakeMoveUCI(White, "b7b8n"); err != nil {
+		t.Fatal(err)
+	}
+
+	history := gs.History()
+	if got := history[len(history)-1].Move.UCI(); got != "b7b8n" {
+		t.Errorf("Move.UCI() = %q, want %q", got, "b7b8n")
+	}
+
+	if got, want := gs.ToFEN(), "1N2k3/8/8/8/8/8/8/4K3 b - - 0 1"; got != want {
+		t.Errorf("ToFEN() = %q, want %q", got, want)
+	}
+}
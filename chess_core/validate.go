@@ -0,0 +1,178 @@
+// Position legality validation
+//
+// references:
+// - https://www.chessprogramming.org/Forsyth-Edwards_Notation
+// - https://chess.stackexchange.com/questions/1482/how-do-you-know-when-a-fen-position-is-legal
+
+package chess_core
+
+import "fmt"
+
+// lightSquares is the bitboard of the light squares, a1 is dark
+const lightSquares BitBoard = 0x55AA55AA55AA55AA
+
+// ValidatePosition checks that the position could be reached in a game,
+// it returns ErrNoKing, ErrMultipleKings, ErrPawnOnFirstOrLast, ErrTooManyPieces, ErrOpponentInCheck,
+// ErrImpossibleCheck, ErrInvalidCastling or ErrInvalidEnPassant
+func (gs *GameState) ValidatePosition() error {
+	return gs.Position().Validate()
+}
+
+// Validate checks that the position could be reached in a game, see GameState.ValidatePosition
+func (p Position) Validate() error {
+	return validatePosition(&p.bb, p.sideToMove, p.castlingRights, p.enPassantSquare, p.castlingSquares())
+}
+
+// ValidateFEN parses the FEN string and validates the position
+func ValidateFEN(fen string) error {
+	return NewGame().FromFEN(fen)
+}
+
+func validatePosition(bb *BitBoards, side Color, castling int, enPassant Square, ci *castlingInfo) error {
+	for _, color := range [2]Color{White, Black} {
+		if err := validateMaterial(bb, color); err != nil {
+			return err
+		}
+	}
+
+	if (bb.WhitePawns|bb.BlackPawns)&(rankMask(0)|rankMask(7)) != 0 {
+		return ErrPawnOnFirstOrLast
+	}
+
+	if IsKingAttacked(side.Opposite(), bb) {
+		return fmt.Errorf("%w: %s", ErrOpponentInCheck, side.Opposite())
+	}
+
+	if err := validateCastling(bb, castling, ci); err != nil {
+		return err
+	}
+
+	if err := validateEnPassant(bb, side, enPassant); err != nil {
+		return err
+	}
+
+	return validateCheckers(bb, side, enPassant)
+}
+
+// validateMaterial checks the king count, the piece count and the number of promoted pieces of color
+func validateMaterial(bb *BitBoards, color Color) error {
+	switch bb.PiecesByType(color, King).Count() {
+	case 0:
+		return fmt.Errorf("%w: %s", ErrNoKing, color)
+	case 1:
+	default:
+		return fmt.Errorf("%w: %s", ErrMultipleKings, color)
+	}
+
+	if bb.OccupiedBy(color).Count() > 16 {
+		return fmt.Errorf("%w: more than 16 %s pieces", ErrTooManyPieces, color)
+	}
+
+	pawns := bb.PiecesByType(color, Pawn).Count()
+	if pawns > 8 {
+		return fmt.Errorf("%w: more than 8 %s pawns", ErrTooManyPieces, color)
+	}
+
+	// pieces above the initial count must be promoted pawns
+	bishops := bb.PiecesByType(color, Bishop)
+	promoted := max(0, bb.PiecesByType(color, Queen).Count()-1) +
+		max(0, bb.PiecesByType(color, Rook).Count()-2) +
+		max(0, bb.PiecesByType(color, Knight).Count()-2) +
+		max(0, (bishops&lightSquares).Count()-1) +
+		max(0, (bishops&^lightSquares).Count()-1)
+
+	if pawns+promoted > 8 {
+		return fmt.Errorf("%w: impossible %s promotion count", ErrTooManyPieces, color)
+	}
+
+	return nil
+}
+
+// validateCastling checks that the kings and the rooks of the castling rights are on their squares
+func validateCastling(bb *BitBoards, castling int, ci *castlingInfo) error {
+	for i, right := range castlingRightByIndex {
+		if castling&right == 0 {
+			continue
+		}
+
+		side := White
+		if right == BK || right == BQ {
+			side = Black
+		}
+
+		if !bb.PiecesByType(side, King).IsSet(ci.king[side]) || !bb.PiecesByType(side, Rook).IsSet(ci.rook[i]) {
+			return fmt.Errorf("%w: %s king or rook moved", ErrInvalidCastling, side)
+		}
+	}
+
+	return nil
+}
+
+// validateEnPassant checks that a pawn of the side not to move just double pushed over the en passant square
+func validateEnPassant(bb *BitBoards, side Color, enPassant Square) error {
+	if enPassant == NoEnPassant {
+		return nil
+	}
+	if !enPassant.IsValid() {
+		return ErrInvalidEnPassant
+	}
+
+	rank, pushed, origin := 5, enPassant-8, enPassant+8
+	if side == Black {
+		rank, pushed, origin = 2, enPassant+8, enPassant-8
+	}
+
+	if int(enPassant)/8 != rank ||
+		!bb.IsSquareEmpty(enPassant) || !bb.IsSquareEmpty(origin) ||
+		!bb.PiecesByType(side.Opposite(), Pawn).IsSet(pushed) {
+		return fmt.Errorf("%w: no pawn double pushed over %s", ErrInvalidEnPassant, enPassant)
+	}
+
+	return nil
+}
+
+// validateCheckers checks that the checks on the king of side could be given by the last move
+func validateCheckers(bb *BitBoards, side Color, enPassant Square) error {
+	king := Square(bb.PiecesByType(side, King).LeastSignificantBit())
+	enemy := side.Opposite()
+
+	checkers := AttackersTo(king, side, bb)
+
+	switch checkers.Count() {
+	case 0:
+		return nil
+	case 1:
+	case 2:
+		// a move can not discover a check from a pawn or a knight, so one of the checkers is a slider
+		leapers := bb.PiecesByType(enemy, Pawn) | bb.PiecesByType(enemy, Knight)
+		if (checkers & leapers).Count() == 2 {
+			return fmt.Errorf("%w: double check by two pawns or knights", ErrImpossibleCheck)
+		}
+
+		// one move can not check from both sides of the same line
+		first := directionBetween(king, Square(checkers.LeastSignificantBit()))
+		second := directionBetween(king, Square(checkers.MostSignificantBit()))
+		if first != 0 && first == -second {
+			return fmt.Errorf("%w: aligned checkers", ErrImpossibleCheck)
+		}
+	default:
+		return fmt.Errorf("%w: more than 2 checkers", ErrImpossibleCheck)
+	}
+
+	// after a double push the pawn gives the check or discovers it
+	if enPassant.IsValid() {
+		pushed, origin := enPassant-8, enPassant+8
+		if side == Black {
+			pushed, origin = enPassant+8, enPassant-8
+		}
+
+		for checkers != 0 {
+			checker := popLSB(&checkers)
+			if checker != pushed && !BetweenBits(king, checker).IsSet(origin) {
+				return fmt.Errorf("%w: check not given by the double push", ErrImpossibleCheck)
+			}
+		}
+	}
+
+	return nil
+}
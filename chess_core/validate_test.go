@@ -0,0 +1,72 @@
+package chess_core
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateFEN(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		want error
+	}{
+		{"start", StartingFEN, nil},
+		{"en passant", "rnbqkbnr/ppp1pppp/8/8/3pP3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 3", nil},
+		{"promoted queens", "k7/8/8/8/8/8/1QQQ4/K7 w - - 0 1", nil},
+		{"double check", "4k3/8/8/8/8/5N2/8/K3R3 b - - 0 1", nil},
+		{"discovered check by double push", "8/8/7k/8/3P4/8/8/2B1K3 b - d3 0 1", nil},
+
+		{"no king", "8/8/8/8/8/8/8/K7 w - - 0 1", ErrNoKing},
+		{"two kings", "kk6/8/8/8/8/8/8/K7 w - - 0 1", ErrMultipleKings},
+		{"pawn on last rank", "P3k3/8/8/8/8/8/8/4K3 w - - 0 1", ErrPawnOnFirstOrLast},
+		{"pawn on first rank", "4k3/8/8/8/8/8/8/p3K3 w - - 0 1", ErrPawnOnFirstOrLast},
+		{"nine pawns", "4k3/8/8/8/8/P7/PPPPPPPP/4K3 w - - 0 1", ErrTooManyPieces},
+		{"too many pieces", "4k3/8/8/8/8/QQQQQQQQ/PPPPPPPP/NN2K3 w - - 0 1", ErrTooManyPieces},
+		{"impossible promotions", "4k3/8/8/8/8/8/QPPPPPPP/QQB1KBNR w - - 0 1", ErrTooManyPieces},
+		{"same colored bishops", "4k3/8/8/8/8/7B/PPPPPPPP/RN1QKB1R w - - 0 1", ErrTooManyPieces},
+		{"opponent in check", "4k3/4R3/8/8/8/8/8/4K3 w - - 0 1", ErrOpponentInCheck},
+		{"three checkers", "4k3/3P4/5N2/8/8/8/8/K3R3 b - - 0 1", ErrImpossibleCheck},
+		{"two knights", "4k3/8/3N1N2/8/8/8/8/K7 b - - 0 1", ErrImpossibleCheck},
+		{"aligned checkers", "4R3/8/8/8/4k3/8/8/K3R3 b - - 0 1", ErrImpossibleCheck},
+		{"check not from double push", "4k3/8/8/8/3P4/8/8/K3R3 b - d3 0 1", ErrImpossibleCheck},
+		{"castling without rook", "4k3/8/8/8/8/8/8/4K3 w K - 0 1", ErrInvalidCastling},
+		{"castling without double push", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq e6 0 1", ErrInvalidEnPassant},
+		{"en passant on wrong rank", "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e4 0 1", ErrInvalidEnPassant},
+		{"en passant file only", "4k3/8/8/8/8/8/8/4K3 w - e 0 1", ErrInvalidEnPassant},
+		{"en passant off the board", "4k3/8/8/8/8/8/8/4K3 w - i6 0 1", ErrInvalidEnPassant},
+		{"en passant too long", "4k3/8/8/8/8/8/8/4K3 w - e66 0 1", ErrInvalidEnPassant},
+		{"trailing field", "4k3/8/8/8/8/8/8/4K3 w - - 0 1 x", ErrInvalidFEN},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateFEN(tt.fen)
+			if tt.want == nil && err != nil {
+				t.Errorf("ValidateFEN() error = %v, want nil", err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("ValidateFEN() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatePosition(t *testing.T) {
+	gs := NewGame()
+	if err := gs.FromFEN(StartingFEN); err != nil {
+		t.Fatal(err)
+	}
+
+	gs.MakeMoveUCI(White, "h2h4")
+	gs.MakeMoveUCI(Black, "a7a5")
+	gs.MakeMoveUCI(White, "h1h3")
+	if err := gs.ValidatePosition(); err != nil {
+		t.Fatalf("ValidatePosition() error = %v", err)
+	}
+
+	gs.pos.castlingRights |= WK
+	if err := gs.ValidatePosition(); !errors.Is(err, ErrInvalidCastling) {
+		t.Errorf("ValidatePosition() error = %v, want ErrInvalidCastling", err)
+	}
+}
@@ -0,0 +1,201 @@
+// Chess variants
+//
+// references:
+// - https://lichess.org/variant
+// - https://www.chessprogramming.org/Chess_Variants
+
+package chess_core
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Variant changes the rules of the game, the hooks are called with a copy of the position
+// and must not call the methods of the GameState.
+type Variant interface {
+	// Name returns the lowercase name of the variant, ex: "atomic"
+	Name() string
+	// StartingFEN returns the start position
+	StartingFEN() string
+
+	// Validate checks that the position loaded from a FEN string is a position of the variant
+	Validate(b *Board) error
+	// GenerateMoves appends the legal moves of the side to move to ml, none when the game has ended
+	GenerateMoves(b *Board, ml *MoveList)
+	// MakeMove makes a legal move, it updates the pieces, the castling rights, the en passant square and the side to move
+	MakeMove(b *Board, move Move)
+	// Result returns the result of the position after a move, ResultOngoing if the game goes on.
+	// the winner is White or Black for the variant wins, ex: ResultKingOfTheHill
+	Result(b *Board) (status GameStatus, winner Color)
+}
+
+// fenVariant is a variant with an extra FEN field after the fullmove number
+type fenVariant interface {
+	parseFENField(b *Board, field string) error
+	formatFENField(b *Board) string
+}
+
+// Board is a position seen by the variant hooks
+type Board struct {
+	BitBoards       *BitBoards
+	SideToMove      Color
+	CastlingRights  int
+	EnPassantSquare Square
+	Checks          [2]int   // checks given by White and Black, see ThreeCheck
+	Pockets         Pockets  // pieces in hand, see Crazyhouse
+	Promoted        BitBoard // promoted pieces, they go to the pockets as pawns
+
+	castling *castlingInfo
+}
+
+// castlingSquares returns the castling squares of the position
+func (b *Board) castlingSquares() *castlingInfo {
+	if b.castling == nil {
+		return standardCastling
+	}
+	return b.castling
+}
+
+// Copy returns a copy of the board with its own bitboards
+func (b *Board) Copy() Board {
+	c := *b
+	c.BitBoards = b.BitBoards.Copy()
+	return c
+}
+
+// PseudoLegalMoves appends the moves of the side to move that may leave its king in check to ml
+func (b *Board) PseudoLegalMoves(ml *MoveList) {
+	generatePseudoLegalMoves(b.BitBoards, b.SideToMove, b.CastlingRights, b.EnPassantSquare, b.castlingSquares(), ml)
+}
+
+// LegalMoves appends the legal moves of standard chess to ml
+func (b *Board) LegalMoves(ml *MoveList) {
+	generateLegalMoves(b.BitBoards, b.SideToMove, b.CastlingRights, b.EnPassantSquare, b.castlingSquares(), ml)
+}
+
+// MakeMove makes a move with the standard rules and returns the captured piece, Empty if none.
+// drops take the piece from the pocket
+func (b *Board) MakeMove(move Move) Piece {
+	if move.IsDrop() {
+		piece := ASCIIPieces[move.Piece()]
+		b.BitBoards.SetPieceAt(Square(move.To()), piece)
+		b.Pockets[piece.Color()][piece.Type()]--
+		b.EnPassantSquare = NoEnPassant
+		b.SideToMove = b.SideToMove.Opposite()
+		return Empty
+	}
+
+	ci := b.castlingSquares()
+
+	captured, _ := makeUnsafeMove(b.BitBoards, move, ci)
+	b.CastlingRights, b.EnPassantSquare = nextCastlingAndEnPassant(move, b.CastlingRights, ci)
+	b.SideToMove = b.SideToMove.Opposite()
+
+	return captured
+}
+
+// InCheck reports whether the king of the side to move is attacked, it is false without a king
+func (b *Board) InCheck() bool {
+	return b.BitBoards.PiecesByType(b.SideToMove, King) != 0 && IsKingAttacked(b.SideToMove, b.BitBoards)
+}
+
+// Standard is the standard chess rules, it is the variant of games without one
+type Standard struct{}
+
+func (Standard) Name() string        { return "standard" }
+func (Standard) StartingFEN() string { return StartingFEN }
+
+func (Standard) Validate(b *Board) error {
+	return validatePosition(b.BitBoards, b.SideToMove, b.CastlingRights, b.EnPassantSquare, b.castlingSquares())
+}
+
+func (Standard) GenerateMoves(b *Board, ml *MoveList) {
+	b.LegalMoves(ml)
+}
+
+func (Standard) MakeMove(b *Board, move Move) {
+	b.MakeMove(move)
+}
+
+func (Standard) Result(b *Board) (GameStatus, Color) {
+	if status, winner := mateResult(b); status != ResultOngoing {
+		return status, winner
+	}
+
+	if isInsufficientMaterial(b.BitBoards) {
+		return ResultInsufficientMaterial, Both
+	}
+
+	return ResultOngoing, None
+}
+
+// mateResult returns the checkmate or stalemate result of standard chess
+func mateResult(b *Board) (GameStatus, Color) {
+	if hasAnyLegalMove(b.BitBoards, b.SideToMove, b.CastlingRights, b.EnPassantSquare, b.castlingSquares()) {
+		return ResultOngoing, None
+	}
+
+	if IsKingAttacked(b.SideToMove, b.BitBoards) {
+		return ResultCheckmate, b.SideToMove.Opposite()
+	}
+	return ResultStalemate, Both
+}
+
+// hasVariantMove reports whether the side to move has a legal move in the variant
+func hasVariantMove(v Variant, b *Board) bool {
+	ml := make(MoveList, 0, 64)
+	v.GenerateMoves(b, &ml)
+	return len(ml) > 0
+}
+
+// Variants are the supported variants
+var Variants = []Variant{
+	Standard{},
+	KingOfTheHill{},
+	ThreeCheck{},
+	Antichess{},
+	Atomic{},
+	Horde{},
+	Crazyhouse{},
+	Bughouse{},
+}
+
+// VariantByName returns the variant with the name, ex: "kingofthehill"
+func VariantByName(name string) (Variant, error) {
+	i := slices.IndexFunc(Variants, func(v Variant) bool { return v.Name() == name })
+	if i < 0 {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownVariant, name)
+	}
+	return Variants[i], nil
+}
+
+// NewVariantGame creates a game of the variant from its start position
+func NewVariantGame(v Variant) (*GameState, error) {
+	gs := NewGame()
+	gs.SetVariant(v)
+
+	if err := gs.FromFEN(v.StartingFEN()); err != nil {
+		return nil, err
+	}
+
+	return gs, nil
+}
+
+// SetVariant sets the rules of the game, the current position is not validated again
+// so it should be called before FromFEN
+func (gs *GameState) SetVariant(v Variant) {
+	gs.mx.Lock()
+	defer gs.mx.Unlock()
+
+	// standard games keep the fast move generation
+	if _, ok := v.(Standard); ok {
+		v = nil
+	}
+	gs.pos.variant = v
+}
+
+// Variant returns the rules of the game
+func (gs *GameState) Variant() Variant {
+	return gs.Position().Variant()
+}
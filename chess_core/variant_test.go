@@ -0,0 +1,160 @@
+package chess_core
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestVariantPerft(t *testing.T) {
+	tests := []struct {
+		variant Variant
+		nodes   []uint64
+	}{
+		{KingOfTheHill{}, []uint64{20, 400, 8902}},
+		{ThreeCheck{}, []uint64{20, 400, 8902}},
+		{Antichess{}, []uint64{20, 400, 8067, 153299}},
+		{Atomic{}, []uint64{20, 400, 8902, 197326}},
+		{Horde{}, []uint64{8, 128, 1274, 23310}},
+	}
+
+	for _, tt := range tests {
+		gs, err := NewVariantGame(tt.variant)
+		if err != nil {
+			t.Fatalf("NewVariantGame(%s) error: %v", tt.variant.Name(), err)
+		}
+
+		for i, want := range tt.nodes {
+			if got := gs.Perft(i + 1); got != want {
+				t.Errorf("%s Perft(%d) = %d, want %d", tt.variant.Name(), i+1, got, want)
+			}
+		}
+	}
+}
+
+// playVariant loads the position and plays the UCI moves, it returns the status of the last move
+func playVariant(t *testing.T, v Variant, fen string, moves ...string) (*GameState, GameStatus) {
+	t.Helper()
+
+	gs := NewGame()
+	gs.SetVariant(v)
+	if err := gs.FromFEN(fen); err != nil {
+		t.Fatalf("FromFEN(%q) error: %v", fen, err)
+	}
+
+	status := ResultOngoing
+	for _, move := range moves {
+		var err error
+		if status, err = gs.MakeMoveUCI(gs.SideToMove(), move); err != nil {
+			t.Fatalf("MakeMoveUCI(%s) in %q: %v", move, gs.ToFEN(), err)
+		}
+	}
+
+	return gs, status
+}
+
+func TestVariantResults(t *testing.T) {
+	tests := []struct {
+		name    string
+		variant Variant
+		fen     string
+		moves   []string
+		status  GameStatus
+		winner  Color
+	}{
+		{"king of the hill", KingOfTheHill{}, "4k3/8/8/8/8/4K3/8/8 w - - 120 1", []string{"e3e4"}, ResultKingOfTheHill, White},
+		{"three check", ThreeCheck{}, "4k3/8/8/8/8/8/8/4K2R w - - 0 1 +2+0", []string{"h1h8"}, ResultThreeCheck, White},
+		{"antichess no pieces", Antichess{}, "8/8/8/8/8/8/1p6/R7 b - - 0 1", []string{"b2a1n"}, ResultVariantWin, White},
+		{"atomic explosion", Atomic{}, "4k3/3p4/8/8/8/8/8/3RK3 w - - 0 1", []string{"d1d7"}, ResultKingExploded, White},
+		{"horde no pieces", Horde{}, "4k3/8/8/8/8/8/3P4/8 b - - 0 1", []string{"e8d7", "d2d4", "d7d6", "d4d5", "d6d5"}, ResultVariantWin, Black},
+	}
+
+	for _, tt := range tests {
+		gs, status := playVariant(t, tt.variant, tt.fen, tt.moves...)
+		if status != tt.status {
+			t.Errorf("%s: status = %s, want %s", tt.name, status, tt.status)
+		}
+		if winner, ok := gs.Winner(); !ok || winner != tt.winner {
+			t.Errorf("%s: Winner() = %v, %v, want %v", tt.name, winner, ok, tt.winner)
+		}
+		if moves := gs.LegalMoves(); len(moves) != 0 {
+			t.Errorf("%s: LegalMoves() after the end = %d moves, want 0", tt.name, len(moves))
+		}
+		// a won game can not be claimed as a draw
+		if gs.CanDrawBy50Move() {
+			if err := gs.MakeDrawBy50Move(); !errors.Is(err, ErrMatchEnd) || gs.State() != tt.status {
+				t.Errorf("%s: MakeDrawBy50Move() error = %v with status %s, want %v", tt.name, err, gs.State(), ErrMatchEnd)
+			}
+		}
+	}
+}
+
+func TestThreeCheckFEN(t *testing.T) {
+	gs, _ := playVariant(t, ThreeCheck{}, "4k3/8/8/8/8/8/8/4K2R w - - 0 1 +0+1", "h1h8", "e8e7")
+	if got, want := gs.ToFEN(), "7R/4k3/8/8/8/8/8/4K3 w - - 2 2 +1+1"; got != want {
+		t.Errorf("ToFEN() = %q, want %q", got, want)
+	}
+
+	if err := gs.Undo(2); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := gs.ToFEN(), "4k3/8/8/8/8/8/8/4K2R w - - 0 1 +0+1"; got != want {
+		t.Errorf("after Undo ToFEN() = %q, want %q", got, want)
+	}
+
+	// the checks are part of the hash, the same board is not a repetition
+	other, _ := playVariant(t, ThreeCheck{}, "4k3/8/8/8/8/8/8/4K2R w - - 0 1 +2+1")
+	if gs.Hash() == other.Hash() {
+		t.Errorf("Hash() of %q and %q are equal", gs.ToFEN(), other.ToFEN())
+	}
+	if want := other.pos.computeZobristHash(); other.Hash() != want {
+		t.Errorf("Hash() = %#x, want %#x", other.Hash(), want)
+	}
+}
+
+func TestAntichessMoves(t *testing.T) {
+	// captures are compulsory
+	gs, _ := playVariant(t, Antichess{}, "4k3/8/8/3p4/4P3/8/8/4K3 w - - 0 1")
+	if moves := gs.LegalMoves(); len(moves) != 1 || moves[0].UCI() != "e4d5" {
+		t.Errorf("LegalMoves() = %v, want [e4d5]", moves)
+	}
+
+	// pawns promote to kings
+	gs, _ = playVariant(t, Antichess{}, "8/P7/8/8/8/8/8/7k w - - 0 1", "a7a8k")
+	if got, want := gs.ToFEN(), "K7/8/8/8/8/8/8/7k b - - 0 1"; got != want {
+		t.Errorf("ToFEN() = %q, want %q", got, want)
+	}
+}
+
+func TestAtomicExplosion(t *testing.T) {
+	// the knight captures on d5, the pieces next to it explode but the pawns do not
+	gs, _ := playVariant(t, Atomic{}, "4k3/8/2b1p3/3p4/2Q5/4N3/8/4K3 w - - 0 1", "e3d5")
+	if got, want := gs.ToFEN(), "4k3/8/4p3/8/8/8/8/4K3 b - - 0 1"; got != want {
+		t.Errorf("ToFEN() = %q, want %q", got, want)
+	}
+
+	if err := gs.Undo(1); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := gs.ToFEN(), "4k3/8/2b1p3/3p4/2Q5/4N3/8/4K3 w - - 0 1"; got != want {
+		t.Errorf("after Undo ToFEN() = %q, want %q", got, want)
+	}
+}
+
+func TestVariantByName(t *testing.T) {
+	for _, v := range Variants {
+		got, err := VariantByName(v.Name())
+		if err != nil || got != v {
+			t.Errorf("VariantByName(%q) = %v, %v, want %v", v.Name(), got, err, v)
+		}
+	}
+
+	if _, err := VariantByName("crazyhouse960"); !errors.Is(err, ErrUnknownVariant) {
+		t.Errorf("VariantByName() error = %v, want %v", err, ErrUnknownVariant)
+	}
+
+	gs := NewGame()
+	gs.SetVariant(Horde{})
+	if err := gs.FromFEN(StartingFEN); !errors.Is(err, ErrInvalidVariantPosition) {
+		t.Errorf("Horde FromFEN(StartingFEN) error = %v, want %v", err, ErrInvalidVariantPosition)
+	}
+}
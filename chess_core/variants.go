@@ -0,0 +1,394 @@
+// King of the Hill, Three-check, Antichess, Atomic and Horde
+//
+// references:
+// - https://lichess.org/variant/kingOfTheHill
+// - https://lichess.org/variant/threeCheck
+// - https://lichess.org/variant/antichess
+// - https://lichess.org/variant/atomic
+// - https://lichess.org/variant/horde
+// - https://github.com/lichess-org/scalachess/tree/master/core/src/main/scala/variant
+
+package chess_core
+
+import "fmt"
+
+// hillSquares are the center squares d4, e4, d5 and e5
+const hillSquares BitBoard = 0x0000001818000000
+
+// KingOfTheHill is won by bringing the king to the center, checkmate wins too
+type KingOfTheHill struct{}
+
+func (KingOfTheHill) Name() string        { return "kingofthehill" }
+func (KingOfTheHill) StartingFEN() string { return StartingFEN }
+
+func (KingOfTheHill) Validate(b *Board) error {
+	return Standard{}.Validate(b)
+}
+
+func (KingOfTheHill) GenerateMoves(b *Board, ml *MoveList) {
+	if b.BitBoards.PiecesByType(b.SideToMove.Opposite(), King)&hillSquares != 0 {
+		return
+	}
+	b.LegalMoves(ml)
+}
+
+func (KingOfTheHill) MakeMove(b *Board, move Move) {
+	b.MakeMove(move)
+}
+
+// Result has no insufficient material draw, a bare king can still walk to the center
+func (KingOfTheHill) Result(b *Board) (GameStatus, Color) {
+	mover := b.SideToMove.Opposite()
+	if b.BitBoards.PiecesByType(mover, King)&hillSquares != 0 {
+		return ResultKingOfTheHill, mover
+	}
+
+	return mateResult(b)
+}
+
+// ThreeCheck is won by giving check three times, checkmate wins too.
+// the checks are written after the fullmove number of the FEN string, ex: "... 0 1 +2+0"
+type ThreeCheck struct{}
+
+// checksToWin is the number of checks that wins a Three-check game
+const checksToWin = 3
+
+func (ThreeCheck) Name() string        { return "threecheck" }
+func (ThreeCheck) StartingFEN() string { return StartingFEN }
+
+func (ThreeCheck) Validate(b *Board) error {
+	return Standard{}.Validate(b)
+}
+
+func (ThreeCheck) GenerateMoves(b *Board, ml *MoveList) {
+	if b.Checks[White] >= checksToWin || b.Checks[Black] >= checksToWin {
+		return
+	}
+	b.LegalMoves(ml)
+}
+
+func (ThreeCheck) MakeMove(b *Board, move Move) {
+	b.MakeMove(move)
+	if b.InCheck() {
+		b.Checks[move.Side()]++
+	}
+}
+
+func (ThreeCheck) Result(b *Board) (GameStatus, Color) {
+	mover := b.SideToMove.Opposite()
+	if b.Checks[mover] >= checksToWin {
+		return ResultThreeCheck, mover
+	}
+
+	if status, winner := mateResult(b); status != ResultOngoing {
+		return status, winner
+	}
+
+	// only bare kings can not give check
+	if b.BitBoards.AllPieces.Count() == 2 {
+		return ResultInsufficientMaterial, Both
+	}
+
+	return ResultOngoing, None
+}
+
+func (ThreeCheck) parseFENField(b *Board, field string) error {
+	var white, black int
+	if _, err := fmt.Sscanf(field, "+%d+%d", &white, &black); err != nil ||
+		white < 0 || white > checksToWin || black < 0 || black > checksToWin {
+		return fmt.Errorf("%w: checks %q", ErrInvalidFEN, field)
+	}
+
+	b.Checks = [2]int{white, black}
+	return nil
+}
+
+func (ThreeCheck) formatFENField(b *Board) string {
+	return fmt.Sprintf("+%d+%d", b.Checks[White], b.Checks[Black])
+}
+
+// Antichess is won by losing all the pieces or by being stalemated.
+// captures are compulsory, the king is a normal piece, pawns can promote to a king and there is no castling
+type Antichess struct{}
+
+func (Antichess) Name() string        { return "antichess" }
+func (Antichess) StartingFEN() string { return "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w - - 0 1" }
+
+func (Antichess) Validate(b *Board) error {
+	bb := b.BitBoards
+
+	if b.CastlingRights != 0 {
+		return fmt.Errorf("%w: no castling in antichess", ErrInvalidCastling)
+	}
+	if (bb.WhitePawns|bb.BlackPawns)&(rankMask(0)|rankMask(7)) != 0 {
+		return ErrPawnOnFirstOrLast
+	}
+
+	return validateEnPassant(bb, b.SideToMove, b.EnPassantSquare)
+}
+
+func (Antichess) GenerateMoves(b *Board, ml *MoveList) {
+	pseudo := make(MoveList, 0, 64)
+	generateNonRoyalMoves(b.BitBoards, b.SideToMove, b.EnPassantSquare, &pseudo)
+
+	captures := false
+	for _, move := range pseudo {
+		if move.IsCapture() {
+			captures = true
+			break
+		}
+	}
+
+	for _, move := range pseudo {
+		if captures && !move.IsCapture() {
+			continue
+		}
+
+		ml.Add(move)
+		if PieceType(move.Promoted()) == Queen {
+			ml.Add(move&^0xF0000 | Move(King)<<16)
+		}
+	}
+}
+
+// promotesToKing reports whether pawns of the variant can promote to a king
+func promotesToKing(v Variant) bool {
+	_, ok := v.(Antichess)
+	return ok
+}
+
+func (Antichess) MakeMove(b *Board, move Move) {
+	b.MakeMove(move)
+}
+
+func (Antichess) Result(b *Board) (GameStatus, Color) {
+	if b.BitBoards.OccupiedBy(b.SideToMove) == 0 || !hasVariantMove(Antichess{}, b) {
+		return ResultVariantWin, b.SideToMove
+	}
+
+	return ResultOngoing, None
+}
+
+// Atomic is won by exploding the enemy king, checkmate wins too.
+// a capture explodes the capturing piece and the pieces next to the target square except the pawns,
+// kings can not capture and kings next to each other are never in check
+type Atomic struct{}
+
+func (Atomic) Name() string        { return "atomic" }
+func (Atomic) StartingFEN() string { return StartingFEN }
+
+func (Atomic) Validate(b *Board) error {
+	bb := b.BitBoards
+	if !kingsAdjacent(bb) {
+		return Standard{}.Validate(b)
+	}
+
+	for _, color := range [2]Color{White, Black} {
+		if err := validateMaterial(bb, color); err != nil {
+			return err
+		}
+	}
+	if (bb.WhitePawns|bb.BlackPawns)&(rankMask(0)|rankMask(7)) != 0 {
+		return ErrPawnOnFirstOrLast
+	}
+	if err := validateCastling(bb, b.CastlingRights, b.castlingSquares()); err != nil {
+		return err
+	}
+
+	return validateEnPassant(bb, b.SideToMove, b.EnPassantSquare)
+}
+
+func (Atomic) GenerateMoves(b *Board, ml *MoveList) {
+	bb, side := b.BitBoards, b.SideToMove
+	if bb.PiecesByType(White, King) == 0 || bb.PiecesByType(Black, King) == 0 {
+		return
+	}
+
+	pseudo := make(MoveList, 0, 64)
+	b.PseudoLegalMoves(&pseudo)
+
+	for _, move := range pseudo {
+		if move.IsCapture() && ASCIIPieces[move.Piece()].Type() == King {
+			continue
+		}
+
+		next := b.Copy()
+		Atomic{}.MakeMove(&next, move)
+		if atomicKingSafe(next.BitBoards, side) {
+			ml.Add(move)
+		}
+	}
+}
+
+func (Atomic) MakeMove(b *Board, move Move) {
+	if b.MakeMove(move) == Empty {
+		return
+	}
+
+	bb := b.BitBoards
+	to := Square(move.To())
+
+	blast := KingAttacks(to)&bb.AllPieces&^(bb.WhitePawns|bb.BlackPawns) | to.ToBB()
+	for blast != 0 {
+		bb.clearSquare(popLSB(&blast))
+	}
+	bb.UpdateAggregate()
+
+	b.CastlingRights = castlingRightsOnBoard(bb, b.CastlingRights, b.castlingSquares())
+}
+
+func (Atomic) Result(b *Board) (GameStatus, Color) {
+	bb, side := b.BitBoards, b.SideToMove
+
+	if bb.PiecesByType(side, King) == 0 {
+		return ResultKingExploded, side.Opposite()
+	}
+
+	if !hasVariantMove(Atomic{}, b) {
+		if !kingsAdjacent(bb) && IsKingAttacked(side, bb) {
+			return ResultCheckmate, side.Opposite()
+		}
+		return ResultStalemate, Both
+	}
+
+	if bb.AllPieces.Count() == 2 {
+		return ResultInsufficientMaterial, Both
+	}
+
+	return ResultOngoing, None
+}
+
+// kingsAdjacent reports whether the two kings stand next to each other
+func kingsAdjacent(bb *BitBoards) bool {
+	king := Square(bb.WhiteKing.LeastSignificantBit())
+	return king.IsValid() && KingAttacks(king)&bb.BlackKing != 0
+}
+
+// atomicKingSafe reports whether the king of side survives the move and is not in check after it
+func atomicKingSafe(bb *BitBoards, side Color) bool {
+	switch {
+	case bb.PiecesByType(side, King) == 0:
+		return false
+	case bb.PiecesByType(side.Opposite(), King) == 0:
+		return true
+	case kingsAdjacent(bb):
+		return true
+	default:
+		return !IsKingAttacked(side, bb)
+	}
+}
+
+// castlingRightsOnBoard drops the castling rights of the kings and rooks that left their squares
+func castlingRightsOnBoard(bb *BitBoards, castling int, ci *castlingInfo) int {
+	for i, right := range castlingRightByIndex {
+		side := White
+		if right == BK || right == BQ {
+			side = Black
+		}
+
+		if !bb.PiecesByType(side, King).IsSet(ci.king[side]) || !bb.PiecesByType(side, Rook).IsSet(ci.rook[i]) {
+			castling &^= right
+		}
+	}
+
+	return castling
+}
+
+// Horde is played by 36 white pawns and pieces without a king against the black army.
+// White wins by checkmate and Black by capturing all the white pieces, white pawns on the first rank can move two squares
+type Horde struct{}
+
+func (Horde) Name() string { return "horde" }
+func (Horde) StartingFEN() string {
+	return "rnbqkbnr/pppppppp/8/1PP2PP1/PPPPPPPP/PPPPPPPP/PPPPPPPP/PPPPPPPP w kq - 0 1"
+}
+
+func (Horde) Validate(b *Board) error {
+	bb := b.BitBoards
+
+	if bb.WhiteKing != 0 {
+		return fmt.Errorf("%w: white king in horde", ErrInvalidVariantPosition)
+	}
+	if bb.WhitePieces.Count() > 36 {
+		return fmt.Errorf("%w: more than 36 White pieces", ErrTooManyPieces)
+	}
+	if err := validateMaterial(bb, Black); err != nil {
+		return err
+	}
+
+	if bb.WhitePawns&rankMask(7) != 0 || bb.BlackPawns&(rankMask(0)|rankMask(7)) != 0 {
+		return ErrPawnOnFirstOrLast
+	}
+
+	if b.SideToMove == White && IsKingAttacked(Black, bb) {
+		return fmt.Errorf("%w: %s", ErrOpponentInCheck, Black)
+	}
+
+	if err := validateCastling(bb, b.CastlingRights, b.castlingSquares()); err != nil {
+		return err
+	}
+
+	return validateEnPassant(bb, b.SideToMove, b.EnPassantSquare)
+}
+
+func (Horde) GenerateMoves(b *Board, ml *MoveList) {
+	bb := b.BitBoards
+
+	if b.SideToMove == Black {
+		if bb.WhitePieces != 0 {
+			b.LegalMoves(ml)
+		}
+		return
+	}
+
+	// without a king every move is legal
+	generateNonRoyalMoves(bb, White, b.EnPassantSquare, ml)
+
+	// pawns on the first rank move two squares without an en passant square
+	pawn := uint32(encodedPieceIndex(WPawn))
+	single := (bb.WhitePawns & rankMask(0) << 8) &^ bb.AllPieces
+	for double := single << 8 &^ bb.AllPieces; double != 0; {
+		to := popLSB(&double)
+		ml.Add(NewMove(uint32(to-16), uint32(to), pawn, 0, 0, 0, 0, 0))
+	}
+}
+
+func (Horde) MakeMove(b *Board, move Move) {
+	b.MakeMove(move)
+}
+
+func (Horde) Result(b *Board) (GameStatus, Color) {
+	if b.BitBoards.WhitePieces == 0 {
+		return ResultVariantWin, Black
+	}
+
+	if !hasVariantMove(Horde{}, b) {
+		if b.InCheck() {
+			return ResultCheckmate, White
+		}
+		return ResultStalemate, Both
+	}
+
+	return ResultOngoing, None
+}
+
+// generateNonRoyalMoves generates the pseudo legal moves of side with the kings moving like the other pieces, without castling
+func generateNonRoyalMoves(bb *BitBoards, side Color, enPassantSquare Square, ml *MoveList) {
+	generatePawnMoves(bb, side, enPassantSquare, ml)
+	generateKnightMoves(bb, side, ml)
+	generateBishopMoves(bb, side, ml)
+	generateRookMoves(bb, side, ml)
+	generateQueenMoves(bb, side, ml)
+
+	king := uint32(encodedPieceIndex(getPieceBySide(King, side)))
+	allyPieces, enemyPieces := bb.OccupiedBy(side), bb.OccupiedBy(side.Opposite())
+	for kings := bb.PiecesByType(side, King); kings != 0; {
+		from := popLSB(&kings)
+
+		for attacks := KingAttacks(from) &^ allyPieces; attacks != 0; {
+			to := popLSB(&attacks)
+			isCapture := (enemyPieces >> to) & 1
+			ml.Add(NewMove(uint32(from), uint32(to), king, 0, uint32(isCapture), 0, 0, 0))
+		}
+	}
+}
@@ -1,45 +1,118 @@
 package chess_core
 
-import (
-	"math/rand"
-)
+// DefaultZobristSeed is the seed of the zobrist keys,
+// hashes are the same in every process and across restarts as long as the seed is the same
+const DefaultZobristSeed uint64 = 0x9E3779B97F4A7C15
 
 func init() {
-	initRandomZobristKey()
+	initZobristKeys(DefaultZobristSeed)
 }
 
-// random piece keys [piece][square]
+// piece keys [piece][square]
 var pieceKeys [12][64]uint64
 
-// random enpassant keys [square]
+// enpassant keys [square]
 var enpassantKeys [64]uint64
 
-// random castling keys
+// castling keys
 var castleKeys [16]uint64
 
-// random side key
+// side key
 var sideKey uint64
 
-func initRandomZobristKey() {
+// maxPocketKeyCount is the highest count of a piece type in hand with its own key, higher counts share it
+const maxPocketKeyCount = 16
+
+// pocket keys [color][piece type][count - 1], empty pockets have no key
+var pocketKeys [2][King][maxPocketKeyCount]uint64
+
+// check keys [color][checks - 1] of Three-check, no checks have no key
+var checkKeys [2][checksToWin]uint64
+
+// SetZobristSeed regenerates the zobrist keys from seed,
+// it is not safe for concurrent use and must be called before any game is created
+// because the hashes of existing games become invalid
+func SetZobristSeed(seed uint64) {
+	initZobristKeys(seed)
+}
+
+// initZobristKeys fills the zobrist keys with a splitmix64 sequence,
+// the generator is defined here so the keys never depend on the standard library
+func initZobristKeys(seed uint64) {
+	rng := splitMix64(seed)
+
 	// 12 pieces × 64 squares
 	for piece := range 12 {
 		for sq := range 64 {
-			pieceKeys[piece][sq] = rand.Uint64() // random 64-bit int
+			pieceKeys[piece][sq] = rng.next()
 		}
 	}
 
 	// 64 en passant target squares
 	for sq := range 64 {
-		enpassantKeys[sq] = rand.Uint64()
+		enpassantKeys[sq] = rng.next()
 	}
 
 	// 16 castling rights states (bitmask 0–15)
 	for i := range 16 {
-		castleKeys[i] = rand.Uint64()
+		castleKeys[i] = rng.next()
+	}
+
+	// side to move key
+	sideKey = rng.next()
+
+	// pieces in hand, generated last so the other keys do not depend on them
+	for color := range pocketKeys {
+		for pt := Pawn; pt < King; pt++ {
+			for count := range maxPocketKeyCount {
+				pocketKeys[color][pt][count] = rng.next()
+			}
+		}
 	}
 
-	// random side to move key
-	sideKey = rand.Uint64()
+	// checks given in Three-check, generated after the pocket keys
+	for color := range checkKeys {
+		for i := range checksToWin {
+			checkKeys[color][i] = rng.next()
+		}
+	}
+}
+
+// splitMix64 is the state of a splitmix64 generator
+//
+// references:
+// - https://prng.di.unimi.it/splitmix64.c
+type splitMix64 uint64
+
+func (s *splitMix64) next() uint64 {
+	*s += 0x9E3779B97F4A7C15
+
+	z := uint64(*s)
+	z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+	z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+	return z ^ (z >> 31)
+}
+
+// pieceKey returns the zobrist key of piece on square sq
+func pieceKey(piece Piece, sq Square) uint64 {
+	return pieceKeys[encodedPieceIndex(piece)-1][sq]
+}
+
+// nextZobristHash returns the hash after a move from the hash before it,
+// key is the piece square key returned by makeUnsafeMove
+func nextZobristHash(hash, key uint64, castling, nextCastling int, enPassant, nextEnPassant Square) uint64 {
+	hash ^= key ^ sideKey
+
+	hash ^= castleKeys[castling] ^ castleKeys[nextCastling]
+
+	if enPassant.IsValid() {
+		hash ^= enpassantKeys[enPassant]
+	}
+	if nextEnPassant.IsValid() {
+		hash ^= enpassantKeys[nextEnPassant]
+	}
+
+	return hash
 }
 
 func computeZobristHash(bbs *BitBoards, sideToMove Color, epSp Square, castlingRights int) uint64 {
@@ -67,3 +140,27 @@ func computeZobristHash(bbs *BitBoards, sideToMove Color, epSp Square, castlingR
 
 	return h
 }
+
+// pocketsHash returns the zobrist hash of the pieces in hand
+func pocketsHash(p *Pockets) uint64 {
+	var h uint64
+	for color := range p {
+		for pt := Pawn; pt < King; pt++ {
+			if count := p[color][pt]; count > 0 {
+				h ^= pocketKeys[color][pt][min(count, maxPocketKeyCount)-1]
+			}
+		}
+	}
+	return h
+}
+
+// checksHash returns the zobrist key of the checks given by each side, see ThreeCheck
+func checksHash(checks [2]int) uint64 {
+	var h uint64
+	for color, n := range checks {
+		if n > 0 {
+			h ^= checkKeys[color][min(n, checksToWin)-1]
+		}
+	}
+	return h
+}
@@ -0,0 +1,63 @@
+package chess_core
+
+import "testing"
+
+func TestSplitMix64(t *testing.T) {
+	// first outputs of the reference implementation seeded with 0
+	want := []uint64{0xE220A8397B1DCDAF, 0x6E789E6AA1B965F4, 0x06C45D188009454F}
+
+	rng := splitMix64(0)
+	for i, w := range want {
+		if got := rng.next(); got != w {
+			t.Errorf("output %d = %#016x, want %#016x", i, got, w)
+		}
+	}
+}
+
+func TestHashIsStable(t *testing.T) {
+	newGame := func(moves ...string) *GameState {
+		gs := NewGame()
+		if err := gs.FromFEN(StartingFEN); err != nil {
+			t.Fatal(err)
+		}
+		for _, move := range moves {
+			if _, err := gs.MakeMoveUCI(gs.SideToMove(), move); err != nil {
+				t.Fatalf("move %s: %v", move, err)
+			}
+		}
+		return gs
+	}
+
+	// changing this value invalidates every stored hash
+	const startHash uint64 = 0xd79f6a3f93f195ad
+	if got := newGame().Hash(); got != startHash {
+		t.Errorf("start position hash = %#016x, want %#016x", got, startHash)
+	}
+
+	// transpositions have the same hash
+	a := newGame("g1f3", "g8f6", "b1c3", "b8c6")
+	b := newGame("b1c3", "b8c6", "g1f3", "g8f6")
+	if a.Hash() != b.Hash() {
+		t.Errorf("transposition hashes differ: %#016x, %#016x", a.Hash(), b.Hash())
+	}
+
+	// a position restored from FEN has the hash of the game
+	restored := NewGame()
+	if err := restored.FromFEN(a.ToFEN()); err != nil {
+		t.Fatal(err)
+	}
+	if restored.Hash() != a.Hash() {
+		t.Errorf("restored hash = %#016x, want %#016x", restored.Hash(), a.Hash())
+	}
+
+	SetZobristSeed(1)
+	seeded := newGame().Hash()
+	SetZobristSeed(DefaultZobristSeed)
+
+	if seeded == startHash {
+		t.Error("SetZobristSeed() did not change the hash")
+	}
+	if got := newGame().Hash(); got != startHash {
+		t.Errorf("hash after restoring the default seed = %#016x, want %#016x", got, startHash)
+	}
+}